@@ -32,14 +32,21 @@ const (
 	LoopMirror
 )
 
+// Clock provides the time used on Animation.Update, gorge.Time implements it.
+type Clock interface {
+	Total() float64
+}
+
 // Animation will track time and sent time to channels.
 type Animation struct {
-	loop      LoopType
-	scale     time.Duration
-	startTime time.Time
-	curTime   float32
-	channels  []Channeler
-	state     State
+	loop       LoopType
+	scale      time.Duration
+	clock      Clock
+	startTime  time.Time
+	clockStart float64
+	curTime    float32
+	channels   []Channeler
+	state      State
 
 	endfn func()
 }
@@ -54,6 +61,12 @@ func (a *Animation) SetScale(d time.Duration) {
 	a.scale = d
 }
 
+// SetClock sets the clock used by Update instead of the wall clock, gorge.Time
+// follows the time scale and pause of its instance.
+func (a *Animation) SetClock(c Clock) {
+	a.clock = c
+}
+
 // SetLoop sets the looping mode for this track.
 func (a *Animation) SetLoop(l LoopType) {
 	a.loop = l
@@ -69,6 +82,12 @@ func (a *Animation) State() State {
 	return a.state
 }
 
+// Done returns true if the animation is not running, it can be awaited by a
+// gorge.Task.
+func (a *Animation) Done() bool {
+	return a.state != StateRunning
+}
+
 // Start animation.
 func (a *Animation) Start() {
 	if a.scale == 0 {
@@ -76,6 +95,9 @@ func (a *Animation) Start() {
 	}
 	// Recalc totalTime from tracks regardless duration
 	a.startTime = time.Now()
+	if a.clock != nil {
+		a.clockStart = a.clock.Total()
+	}
 	a.curTime = 0
 	a.state = StateRunning
 }
@@ -83,6 +105,9 @@ func (a *Animation) Start() {
 // Update using internal timing to update.
 func (a *Animation) Update() {
 	curDur := time.Since(a.startTime)
+	if a.clock != nil {
+		curDur = time.Duration((a.clock.Total() - a.clockStart) * float64(time.Second))
+	}
 	a.curTime = float32(curDur) / float32(a.scale)
 	a.update()
 }
@@ -1,5 +1,7 @@
 package gorge
 
+import "time"
+
 // AudioResourcer interface to return an audio resource.
 type AudioResourcer interface {
 	Resource() AudioResource
@@ -10,9 +12,50 @@ type AudioResource interface {
 	isAudio()
 }
 
-// AudioFormat audio format for clipData.
+// AudioFormat audio sample format for clipData, samples are little endian
+// and interleaved by channel.
 type AudioFormat int
 
+// Audio sample formats.
+const (
+	AudioFormatS16 = AudioFormat(iota)
+	AudioFormatU8
+	AudioFormatS24
+	AudioFormatS32
+	AudioFormatF32
+)
+
+func (f AudioFormat) String() string {
+	switch f {
+	case AudioFormatS16:
+		return "S16"
+	case AudioFormatU8:
+		return "U8"
+	case AudioFormatS24:
+		return "S24"
+	case AudioFormatS32:
+		return "S32"
+	case AudioFormatF32:
+		return "F32"
+	default:
+		return "Unknown"
+	}
+}
+
+// Size returns the size of a sample in bytes.
+func (f AudioFormat) Size() int {
+	switch f {
+	case AudioFormatU8:
+		return 1
+	case AudioFormatS24:
+		return 3
+	case AudioFormatS32, AudioFormatF32:
+		return 4
+	default:
+		return 2
+	}
+}
+
 // AudioClip is the resource controller for audio (similar to material, texture, mesh).
 type AudioClip struct {
 	Resourcer AudioResourcer
@@ -57,12 +100,42 @@ func (a *AudioListener) AudioListenerComponent() *AudioListener { return a }
 
 // AudioClipData base audio data.
 type AudioClipData struct {
-	Format  AudioFormat
-	Data    []byte
-	Updates int
+	Format AudioFormat
+	// SampleRate in Hz, defaults to 44100 if zero.
+	SampleRate int
+	// Channels defaults to 2 if zero.
+	Channels int
+	Data     []byte
+	Updates  int
 }
 
 // Resource implements the AudioResourcer interface.
 func (d *AudioClipData) Resource() AudioResource { return d }
 
+// Rate returns the sample rate or the default if not set.
+func (d *AudioClipData) Rate() int {
+	if d.SampleRate == 0 {
+		return 44100
+	}
+	return d.SampleRate
+}
+
+// NumChannels returns the number of channels or the default if not set.
+func (d *AudioClipData) NumChannels() int {
+	if d.Channels == 0 {
+		return 2
+	}
+	return d.Channels
+}
+
+// Frames returns the number of samples per channel.
+func (d *AudioClipData) Frames() int {
+	return len(d.Data) / (d.Format.Size() * d.NumChannels())
+}
+
+// Duration returns the clip duration.
+func (d *AudioClipData) Duration() time.Duration {
+	return time.Duration(d.Frames()) * time.Second / time.Duration(d.Rate())
+}
+
 func (AudioClipData) isAudio() {}
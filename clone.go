@@ -0,0 +1,244 @@
+package gorge
+
+import "reflect"
+
+// EntityCloner is implemented by entities that clone themselves, usually
+// containers that keep their children in unexported fields.
+type EntityCloner interface {
+	CloneEntity(c *Cloner) Entity
+}
+
+// Cloner deep clones entity graphs, transforms and components are copied,
+// meshes are cloned so shader props stay per instance while materials,
+// textures and the underlying mesh data are shared.
+//
+// Entities implementing EntityCloner are cloned with CloneEntity, other
+// struct entities are copied and their component fields are cloned, the
+// remaining fields are copied as is.
+type Cloner struct {
+	entities   map[Entity]Entity
+	transforms map[*TransformComponent]*TransformComponent
+	meshes     map[*Mesh]*Mesh
+	// cloned transforms in order to resolve parents on Finish.
+	order []*TransformComponent
+}
+
+// NewCloner returns a new cloner.
+func NewCloner() *Cloner {
+	return &Cloner{
+		entities:   map[Entity]Entity{},
+		transforms: map[*TransformComponent]*TransformComponent{},
+		meshes:     map[*Mesh]*Mesh{},
+	}
+}
+
+// Clone deep clones an entity graph, parents of transforms outside the graph
+// are kept.
+func Clone[T Entity](e T) T {
+	c := NewCloner()
+	v := c.Entity(e)
+	c.Finish()
+	return v.(T)
+}
+
+// Entity returns the clone of e, entities cloned more than once with the same
+// cloner return the same clone.
+func (c *Cloner) Entity(e Entity) Entity {
+	if e == nil {
+		return nil
+	}
+	comparable := reflect.TypeOf(e).Comparable()
+	if comparable {
+		if v, ok := c.entities[e]; ok {
+			return v
+		}
+	}
+	var v Entity
+	switch e := e.(type) {
+	case EntityCloner:
+		v = e.CloneEntity(c)
+	case *Container:
+		v = c.container(*e)
+	case Container:
+		v = *c.container(e)
+	default:
+		v = c.value(e)
+	}
+	if comparable {
+		c.entities[e] = v
+	}
+	return v
+}
+
+// Entities clones each entity.
+func (c *Cloner) Entities(ents []Entity) []Entity {
+	if ents == nil {
+		return nil
+	}
+	ret := make([]Entity, len(ents))
+	for i, e := range ents {
+		ret[i] = c.Entity(e)
+	}
+	return ret
+}
+
+// Transform returns the clone of t, the parent is set on Finish.
+func (c *Cloner) Transform(t *TransformComponent) *TransformComponent {
+	if t == nil {
+		return nil
+	}
+	if v, ok := c.transforms[t]; ok {
+		return v
+	}
+	v := &TransformComponent{affine: t.affine}
+	c.cloneTransform(t, v)
+	return v
+}
+
+// Renderable returns a copy of r with a cloned mesh.
+func (c *Cloner) Renderable(r *RenderableComponent) *RenderableComponent {
+	if r == nil {
+		return nil
+	}
+	v := *r
+	v.GPU = GPU{}
+	v.Mesh = c.Mesh(r.Mesh)
+	return &v
+}
+
+// Mesh returns a clone of m sharing its resource, meshes cloned more than
+// once with the same cloner return the same clone.
+func (c *Cloner) Mesh(m *Mesh) *Mesh {
+	if m == nil {
+		return nil
+	}
+	if v, ok := c.meshes[m]; ok {
+		return v
+	}
+	v := m.Clone()
+	c.meshes[m] = v
+	return v
+}
+
+// Finish sets the parents of the cloned transforms, parents that were cloned
+// are replaced by their clones.
+func (c *Cloner) Finish() {
+	for _, t := range c.order {
+		v := c.transforms[t]
+		if t.parent == nil || v.parent != nil {
+			continue
+		}
+		p := t.parent
+		if e, ok := c.cloned(p); ok {
+			p = e
+		} else if pt, ok := c.transforms[t.parentTransform()]; ok {
+			p = pt
+		}
+		v.SetParent(p)
+	}
+	c.order = nil
+}
+
+// cloned returns the clone of an entity parent.
+func (c *Cloner) cloned(p Matrixer) (Matrixer, bool) {
+	if !reflect.TypeOf(p).Comparable() {
+		return nil, false
+	}
+	e, ok := c.entities[p].(Matrixer)
+	return e, ok
+}
+
+func (c *Cloner) cloneTransform(t, v *TransformComponent) {
+	c.transforms[t] = v
+	c.order = append(c.order, t)
+}
+
+func (c *Cloner) container(ents Container) *Container {
+	v := Container(c.Entities(ents))
+	return &v
+}
+
+var (
+	transformType  = reflect.TypeOf(TransformComponent{})
+	renderableType = reflect.TypeOf(RenderableComponent{})
+	componentTypes = map[reflect.Type]bool{
+		reflect.TypeOf(ColorableComponent{}): true,
+		reflect.TypeOf(LightComponent{}):     true,
+		reflect.TypeOf(CameraComponent{}):    true,
+	}
+)
+
+// value clones struct or pointer to struct entities.
+func (c *Cloner) value(e Entity) Entity {
+	src := reflect.ValueOf(e)
+	isPtr := src.Kind() == reflect.Pointer
+	if isPtr {
+		if src.IsNil() {
+			return e
+		}
+		src = src.Elem()
+	}
+	if src.Kind() != reflect.Struct {
+		return e
+	}
+	if !isPtr {
+		// fields are read by address.
+		v := reflect.New(src.Type()).Elem()
+		v.Set(src)
+		src = v
+	}
+	dst := reflect.New(src.Type())
+	dst.Elem().Set(src)
+	c.fields(src, dst.Elem())
+	if isPtr {
+		return dst.Interface()
+	}
+	return dst.Elem().Interface()
+}
+
+func (c *Cloner) fields(src, dst reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		f := dst.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		sf := src.Field(i)
+		switch {
+		case f.Type() == transformType:
+			t := sf.Addr().Interface().(*TransformComponent)
+			v := f.Addr().Interface().(*TransformComponent)
+			*v = TransformComponent{affine: t.affine}
+			c.cloneTransform(t, v)
+		case f.Type() == reflect.PointerTo(transformType):
+			f.Set(reflect.ValueOf(c.Transform(sf.Interface().(*TransformComponent))))
+		case f.Type() == renderableType:
+			r := c.Renderable(sf.Addr().Interface().(*RenderableComponent))
+			f.Set(reflect.ValueOf(*r))
+		case f.Type() == reflect.PointerTo(renderableType):
+			f.Set(reflect.ValueOf(c.Renderable(sf.Interface().(*RenderableComponent))))
+		case f.Kind() == reflect.Pointer && componentTypes[f.Type().Elem()]:
+			if sf.IsNil() {
+				continue
+			}
+			v := reflect.New(f.Type().Elem())
+			v.Elem().Set(sf.Elem())
+			f.Set(v)
+		}
+	}
+}
+
+// Prefab is an entity graph used as a template, spawned entities are deep
+// clones of it.
+type Prefab struct {
+	Entity Entity
+}
+
+// NewPrefab returns a prefab for the entity graph.
+func NewPrefab(e Entity) *Prefab {
+	return &Prefab{Entity: e}
+}
+
+// Spawn returns a new clone of the prefab entity.
+func (p *Prefab) Spawn() Entity {
+	return Clone(p.Entity)
+}
@@ -0,0 +1,79 @@
+package gorge_test
+
+import (
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+)
+
+type cloneEntity struct {
+	Name string
+	gorge.TransformComponent
+	*gorge.RenderableComponent
+	*gorge.ColorableComponent
+}
+
+func TestClone(t *testing.T) {
+	mesh := gorge.NewMesh(&gorge.MeshData{})
+	mat := gorge.NewMaterial()
+	newEntity := func(name string) *cloneEntity {
+		return &cloneEntity{
+			Name:                name,
+			TransformComponent:  gorge.TransformIdent(),
+			RenderableComponent: gorge.NewRenderableComponent(mesh, mat),
+			ColorableComponent:  gorge.NewColorableComponent(1, 1, 1, 1),
+		}
+	}
+	outside := gorge.NewTransformComponent()
+	root := newEntity("root")
+	root.SetParent(outside)
+	root.SetPosition(1, 0, 0)
+	child := newEntity("child")
+	child.SetParent(root)
+	child.SetPosition(0, 1, 0)
+
+	prefab := gorge.NewPrefab(gorge.Container{root, child})
+	spawned := prefab.Spawn().(gorge.Container)
+	r2 := spawned[0].(*cloneEntity)
+	c2 := spawned[1].(*cloneEntity)
+
+	if r2 == root || c2 == child || r2.Name != "root" {
+		t.Fatal("want cloned entities")
+	}
+	if c2.Parent() != r2 {
+		t.Errorf("want child parent remapped to clone, got %T", c2.Parent())
+	}
+	if r2.Parent() != outside {
+		t.Errorf("want parent outside the graph kept, got %T", r2.Parent())
+	}
+	if len(root.Children()) != 1 || len(r2.Children()) != 1 {
+		t.Errorf("want 1 child each, got %d and %d", len(root.Children()), len(r2.Children()))
+	}
+	if want, got := (gm.Vec3{1, 1, 0}), c2.WorldPosition(); want != got {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+
+	if r2.Material != mat {
+		t.Error("want material shared")
+	}
+	if r2.Mesh == mesh || r2.Mesh.Resource() != mesh.Resource() {
+		t.Error("want mesh cloned sharing the resource")
+	}
+	if r2.Mesh != c2.Mesh {
+		t.Error("want shared mesh cloned once")
+	}
+	r2.Mesh.Set("u_value", float32(1))
+	if v := mesh.Get("u_value"); v != nil {
+		t.Errorf("want per instance mesh props, got %v", v)
+	}
+	c2.SetColor(1, 0, 0, 1)
+	if child.Color != (gm.Vec4{1, 1, 1, 1}) {
+		t.Errorf("want colorable copied, got %v", child.Color)
+	}
+
+	r2.SetPosition(0, 0, 5)
+	if want, got := (gm.Vec3{1, 1, 0}), child.WorldPosition(); want != got {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+}
@@ -0,0 +1,97 @@
+// Command gorgeassets verifies asset manifests and writes HTTPFS manifests.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/stdiopt/gorge/systems/resource"
+
+	// Register asset types, these must not depend on the renderer so the
+	// tool builds without cgo.
+	_ "github.com/stdiopt/gorge/text"
+	_ "github.com/stdiopt/gorge/x/gltf"
+)
+
+func main() {
+	log.SetFlags(0)
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal("err: ", err)
+	}
+}
+
+func usage(fl *flag.FlagSet) {
+	usage := []string{
+		"Usage:",
+		"",
+		"\tverify [-dir assets] <manifest.json>",
+		"\thttpmanifest [-dir assets] [-o manifest.json]",
+		"",
+	}
+	fmt.Fprintln(os.Stderr, strings.Join(usage, "\n"))
+
+	if fl != nil {
+		fl.Usage()
+	}
+}
+
+func run(args []string) error {
+	if len(args) < 1 {
+		usage(nil)
+		return errors.New("missing arguments")
+	}
+
+	switch args[0] {
+	case "verify":
+		dir := "."
+		fl := flag.NewFlagSet(args[0], flag.ExitOnError)
+		fl.StringVar(&dir, "dir", ".", "assets directory")
+		if err := fl.Parse(args[1:]); err != nil {
+			return err
+		}
+		name := fl.Arg(0)
+		if name == "" {
+			fl.Usage()
+			return errors.New("verify: missing arg <manifest.json>")
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint: errcheck
+		m, err := resource.ReadManifest(f)
+		if err != nil {
+			return err
+		}
+		if err := m.Verify(os.DirFS(dir)); err != nil {
+			return err
+		}
+		log.Printf("%s: %d assets in %d groups ok", name, len(m.Assets), len(m.Groups()))
+	case "httpmanifest":
+		dir, output := ".", ""
+		fl := flag.NewFlagSet(args[0], flag.ExitOnError)
+		fl.StringVar(&dir, "dir", ".", "assets directory")
+		fl.StringVar(&output, "o", "", "output file, defaults to stdout")
+		if err := fl.Parse(args[1:]); err != nil {
+			return err
+		}
+		w := os.Stdout
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer f.Close() // nolint: errcheck
+			w = f
+		}
+		return resource.WriteHTTPManifest(w, os.DirFS(dir))
+	default:
+		usage(nil)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return nil
+}
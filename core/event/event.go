@@ -1,7 +1,5 @@
 package event
 
-import "github.com/stdiopt/gorge/core/setlist"
-
 type Event any
 
 type Handler interface {
@@ -16,26 +14,75 @@ type handleEvent struct {
 
 func (h *handleEvent) HandleEvent(e Event) { h.fn(e) }
 
+// listener wraps a typed handler func so it can be identified for removal.
+type listener[T any] struct {
+	fn      HandlerFunc[T]
+	prio    int
+	removed bool
+}
+
+func (l *listener[T]) priority() int { return l.prio }
+
+// listeners holds every typed listener for a single event type, it is stored
+// as a pointer in Bus.listeners so it can be grown in place.
+type listeners[T any] struct {
+	items []*listener[T]
+}
+
+// handler wraps an Handler with its priority.
+type handler struct {
+	h       Handler
+	prio    int
+	removed bool
+}
+
+func (h *handler) priority() int { return h.prio }
+
 type Buser interface {
 	bus() *Bus
 }
 
 type Bus struct {
-	listeners []any // slices
+	listeners []any // *listeners[T]
 
-	handlers setlist.SetList[Handler]
+	handlers []*handler
 
 	children []Buser
 }
 
 func (b *Bus) bus() *Bus { return b }
 
+// AddHandler adds a catch all handler with default priority.
 func (b *Bus) AddHandler(h Handler) {
-	b.handlers.Add(h)
+	b.AddHandlerPriority(h, 0)
 }
 
+// AddHandlerPriority adds a catch all handler, handlers with higher priority
+// are called first, adding the same handler twice is a no op.
+func (b *Bus) AddHandlerPriority(h Handler, prio int) {
+	if b.handlerIndex(h) != -1 {
+		return
+	}
+	b.handlers = insert(b.handlers, &handler{h: h, prio: prio})
+}
+
+// Remove removes a catch all handler.
 func (b *Bus) Remove(h Handler) {
-	b.handlers.Remove(h)
+	i := b.handlerIndex(h)
+	if i == -1 {
+		return
+	}
+	b.handlers[i].removed = true
+	b.handlers = remove(b.handlers, b.handlers[i])
+}
+
+func (b *Bus) handlerIndex(h Handler) int {
+	for i, hh := range b.handlers {
+		if hh.h == h {
+			return i
+		}
+	}
+	return -1
 }
 
 // AddBus adds a children bus.
@@ -55,46 +102,189 @@ func (b *Bus) RemoveBus(bu Buser) {
 	}
 }
 
+// Trigger calls typed listeners and catch all handlers ordered by priority
+// and then the children buses, if the event implements
+// PropagationStopped and it returns true the remaining handlers and children
+// are skipped.
 func Trigger[T any](bb Buser, v T) {
 	b := bb.bus()
-	var t []HandlerFunc[T]
-	for _, l := range b.listeners {
-		if tt, ok := l.([]HandlerFunc[T]); ok {
-			t = tt
-			break
-		}
-	}
-	for _, fn := range t {
-		fn(v)
+	st, _ := any(v).(stopper)
+	stopped := func() bool { return st != nil && st.PropagationStopped() }
+
+	var ls []*listener[T]
+	if l := search[T](b); l != nil {
+		ls = l.items
 	}
-	for _, h := range b.handlers.Items() {
-		h.HandleEvent(v)
+	hs := b.handlers
+	// Merge both lists by priority, typed listeners goes first on the same
+	// priority.
+	for i, j := 0, 0; i < len(ls) || j < len(hs); {
+		if stopped() {
+			return
+		}
+		if j >= len(hs) || (i < len(ls) && ls[i].prio >= hs[j].prio) {
+			ln := ls[i]
+			i++
+			if !ln.removed {
+				ln.fn(v)
+			}
+			continue
+		}
+		h := hs[j]
+		j++
+		if !h.removed {
+			h.h.HandleEvent(v)
+		}
 	}
 	for _, c := range b.children {
+		if stopped() {
+			return
+		}
 		Trigger(c, v)
 	}
 }
 
-func Handle[T any](bb Buser, fn HandlerFunc[T]) {
+// Handle registers fn to be called when an event of type T is triggered on
+// the bus, the returned Subscription can be used to remove the handler.
+func Handle[T any](bb Buser, fn HandlerFunc[T]) *Subscription {
+	return HandlePriority(bb, 0, fn)
+}
+
+// HandlePriority registers fn like Handle, handlers with higher priority are
+// called first, handlers with the same priority are called in the order they
+// were added.
+func HandlePriority[T any](bb Buser, prio int, fn HandlerFunc[T]) *Subscription {
 	b := bb.bus()
 
 	if fn, ok := any(fn).(HandlerFunc[Event]); ok {
-		b.handlers.Add(&handleEvent{fn: fn})
-		return
+		h := &handleEvent{fn: fn}
+		b.AddHandlerPriority(h, prio)
+		return &Subscription{cancel: func() { b.Remove(h) }}
 	}
 
-	i, l := search[T](bb)
-	if i != -1 {
-		b.listeners[i] = append(l, fn)
+	l := search[T](b)
+	if l == nil {
+		l = &listeners[T]{}
+		b.listeners = append(b.listeners, l)
 	}
-	b.listeners = append(b.listeners, []HandlerFunc[T]{fn})
+	ln := &listener[T]{fn: fn, prio: prio}
+	l.items = insert(l.items, ln)
+
+	return &Subscription{cancel: func() {
+		ln.removed = true
+		l.items = remove(l.items, ln)
+	}}
 }
 
-func search[T any](bb Buser) (int, []HandlerFunc[T]) {
-	for i, l := range bb.bus().listeners {
-		if tt, ok := l.([]HandlerFunc[T]); ok {
-			return i, tt
+// HandleOnce registers fn like Handle but the handler is removed right before
+// the first call.
+func HandleOnce[T any](bb Buser, fn HandlerFunc[T]) *Subscription {
+	var sub *Subscription
+	sub = Handle(bb, func(v T) {
+		sub.Cancel()
+		fn(v)
+	})
+	return sub
+}
+
+type prioritized interface {
+	comparable
+	priority() int
+}
+
+// insert returns a new slice with e inserted after every item with the same
+// or higher priority, the original slice is not modified since Trigger might
+// be iterating it.
+func insert[E prioritized](s []E, e E) []E {
+	n := len(s)
+	for i, v := range s {
+		if v.priority() < e.priority() {
+			n = i
+			break
+		}
+	}
+	r := make([]E, 0, len(s)+1)
+	r = append(r, s[:n]...)
+	r = append(r, e)
+	return append(r, s[n:]...)
+}
+
+// remove returns a new slice without e.
+func remove[E comparable](s []E, e E) []E {
+	for i, v := range s {
+		if v != e {
+			continue
 		}
+		r := make([]E, 0, len(s)-1)
+		r = append(r, s[:i]...)
+		return append(r, s[i+1:]...)
 	}
-	return -1, nil
+	return s
+}
+
+func search[T any](b *Bus) *listeners[T] {
+	for _, l := range b.listeners {
+		if tt, ok := l.(*listeners[T]); ok {
+			return tt
+		}
+	}
+	return nil
+}
+
+type stopper interface {
+	PropagationStopped() bool
+}
+
+// Propagation can be embedded in events to let handlers stop the event from
+// reaching the remaining handlers and children buses, events must be
+// triggered as a pointer so the state is shared between handlers.
+type Propagation struct {
+	stopped bool
+}
+
+// StopPropagation marks the event as handled.
+func (p *Propagation) StopPropagation() { p.stopped = true }
+
+// PropagationStopped returns true if StopPropagation was called.
+func (p *Propagation) PropagationStopped() bool { return p.stopped }
+
+// Subscription is returned when registering handlers and can be used to
+// remove the handler from the bus.
+type Subscription struct {
+	cancel func()
+}
+
+// Cancel removes the handler from the bus, it is safe to call more than once
+// and on a nil Subscription.
+func (s *Subscription) Cancel() {
+	if s == nil || s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.cancel = nil
+}
+
+// Group holds several subscriptions so they can be cancelled together,
+// useful to tie handlers to the lifetime of something else.
+type Group struct {
+	subs []*Subscription
+}
+
+// Add adds subscriptions to the group.
+func (g *Group) Add(subs ...*Subscription) {
+	g.subs = append(g.subs, subs...)
+}
+
+// Cancel cancels every subscription in the group and empties it.
+func (g *Group) Cancel() {
+	for i, s := range g.subs {
+		s.Cancel()
+		g.subs[i] = nil
+	}
+	g.subs = g.subs[:0]
+}
+
+// Len returns the number of subscriptions in the group.
+func (g *Group) Len() int {
+	return len(g.subs)
 }
@@ -0,0 +1,153 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/stdiopt/gorge/core/event"
+)
+
+type eventA int
+
+type eventB string
+
+type eventC struct {
+	event.Propagation
+}
+
+type handlerFunc func(event.Event)
+
+func (fn handlerFunc) HandleEvent(e event.Event) { fn(e) }
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(b *event.Bus, calls *[]string)
+		want []string
+	}{
+		{
+			name: "typed",
+			fn: func(b *event.Bus, calls *[]string) {
+				event.Handle(b, func(eventA) { *calls = append(*calls, "a1") })
+				event.Handle(b, func(eventA) { *calls = append(*calls, "a2") })
+				event.Handle(b, func(eventB) { *calls = append(*calls, "b") })
+				event.Trigger(b, eventA(1))
+			},
+			want: []string{"a1", "a2"},
+		},
+		{
+			name: "cancel",
+			fn: func(b *event.Bus, calls *[]string) {
+				s := event.Handle(b, func(eventA) { *calls = append(*calls, "a1") })
+				event.Handle(b, func(eventA) { *calls = append(*calls, "a2") })
+				s.Cancel()
+				s.Cancel()
+				event.Trigger(b, eventA(1))
+			},
+			want: []string{"a2"},
+		},
+		{
+			name: "cancel catch all",
+			fn: func(b *event.Bus, calls *[]string) {
+				s := event.Handle(b, func(event.Event) { *calls = append(*calls, "all") })
+				event.Trigger(b, eventA(1))
+				s.Cancel()
+				event.Trigger(b, eventA(1))
+			},
+			want: []string{"all"},
+		},
+		{
+			name: "once",
+			fn: func(b *event.Bus, calls *[]string) {
+				event.HandleOnce(b, func(eventA) { *calls = append(*calls, "once") })
+				event.Handle(b, func(eventA) { *calls = append(*calls, "a") })
+				event.Trigger(b, eventA(1))
+				event.Trigger(b, eventA(1))
+			},
+			want: []string{"once", "a", "a"},
+		},
+		{
+			name: "cancel next while triggering",
+			fn: func(b *event.Bus, calls *[]string) {
+				var s *event.Subscription
+				event.Handle(b, func(eventA) {
+					*calls = append(*calls, "a1")
+					s.Cancel()
+				})
+				s = event.Handle(b, func(eventA) { *calls = append(*calls, "a2") })
+				event.Trigger(b, eventA(1))
+			},
+			want: []string{"a1"},
+		},
+		{
+			name: "group",
+			fn: func(b *event.Bus, calls *[]string) {
+				var g event.Group
+				g.Add(
+					event.Handle(b, func(eventA) { *calls = append(*calls, "a") }),
+					event.Handle(b, func(eventB) { *calls = append(*calls, "b") }),
+				)
+				event.Handle(b, func(eventB) { *calls = append(*calls, "keep") })
+				g.Cancel()
+				event.Trigger(b, eventA(1))
+				event.Trigger(b, eventB("b"))
+			},
+			want: []string{"keep"},
+		},
+		{
+			name: "priority",
+			fn: func(b *event.Bus, calls *[]string) {
+				event.Handle(b, func(eventA) { *calls = append(*calls, "a0") })
+				event.HandlePriority(b, -1, func(eventA) { *calls = append(*calls, "a-1") })
+				event.HandlePriority(b, 10, func(eventA) { *calls = append(*calls, "a10") })
+				event.Handle(b, func(eventA) { *calls = append(*calls, "a0 2") })
+				b.AddHandlerPriority(handlerFunc(func(event.Event) {
+					*calls = append(*calls, "all5")
+				}), 5)
+				event.Trigger(b, eventA(1))
+			},
+			want: []string{"a10", "all5", "a0", "a0 2", "a-1"},
+		},
+		{
+			name: "stop propagation",
+			fn: func(b *event.Bus, calls *[]string) {
+				var child event.Bus
+				b.AddBus(&child)
+				event.Handle(&child, func(*eventC) { *calls = append(*calls, "child") })
+				event.Handle(b, func(*eventC) { *calls = append(*calls, "low") })
+				event.HandlePriority(b, 1, func(e *eventC) {
+					*calls = append(*calls, "high")
+					e.StopPropagation()
+				})
+				event.Trigger(b, &eventC{})
+			},
+			want: []string{"high"},
+		},
+		{
+			name: "propagate to children",
+			fn: func(b *event.Bus, calls *[]string) {
+				var child event.Bus
+				b.AddBus(&child)
+				event.Handle(&child, func(*eventC) { *calls = append(*calls, "child") })
+				event.Handle(b, func(*eventC) { *calls = append(*calls, "parent") })
+				event.Trigger(b, &eventC{})
+			},
+			want: []string{"parent", "child"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b event.Bus
+			var calls []string
+			tt.fn(&b, &calls)
+			if len(calls) != len(tt.want) {
+				t.Fatalf("\nwant: %v\n got: %v\n", tt.want, calls)
+			}
+			for i := range calls {
+				if calls[i] != tt.want[i] {
+					t.Fatalf("\nwant: %v\n got: %v\n", tt.want, calls)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// QueueStats contains queue metrics.
+type QueueStats struct {
+	// Len is the current number of queued funcs.
+	Len int
+	// MaxLen is the highest number of queued funcs seen.
+	MaxLen int
+	// Posted is the total number of posted funcs.
+	Posted uint64
+	// Flushed is the total number of funcs that were called.
+	Flushed uint64
+	// LastFlush is the number of funcs called on the last flush.
+	LastFlush int
+}
+
+// Queue is a thread safe queue of deferred funcs, funcs can be posted from
+// any goroutine and will be called on the goroutine that calls Flush.
+type Queue struct {
+	mu    sync.Mutex
+	items []func()
+	spare []func()
+	stats QueueStats
+}
+
+// Post queues fn to be called on the next Flush, it never blocks.
+func (q *Queue) Post(fn func()) {
+	q.mu.Lock()
+	q.items = append(q.items, fn)
+	q.stats.Posted++
+	if n := len(q.items); n > q.stats.MaxLen {
+		q.stats.MaxLen = n
+	}
+	q.mu.Unlock()
+}
+
+// Len returns the number of queued funcs.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Stats returns the queue metrics.
+func (q *Queue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s := q.stats
+	s.Len = len(q.items)
+	return s
+}
+
+// Flush calls the funcs queued so far in the order they were posted, funcs
+// posted while flushing are left for the next Flush.
+// If budget is greater than 0 it stops once the budget is exceeded and keeps
+// the remaining funcs queued, at least one func is always called.
+// It returns the number of funcs called.
+func (q *Queue) Flush(budget time.Duration) int {
+	q.mu.Lock()
+	items := q.items
+	q.items, q.spare = q.spare[:0], nil
+	q.mu.Unlock()
+
+	var mark time.Time
+	if budget > 0 {
+		mark = time.Now()
+	}
+	n := 0
+	for n < len(items) {
+		fn := items[n]
+		items[n] = nil
+		n++
+		fn()
+		if budget > 0 && time.Since(mark) >= budget {
+			break
+		}
+	}
+
+	q.mu.Lock()
+	if rest := items[n:]; len(rest) > 0 {
+		q.items = append(rest, q.items...)
+	} else {
+		q.spare = items[:0]
+	}
+	q.stats.Flushed += uint64(n)
+	q.stats.LastFlush = n
+	q.mu.Unlock()
+	return n
+}
+
+// Post queues an event to be triggered on bb when q is flushed, it is safe to
+// be called from any goroutine.
+func Post[T any](q *Queue, bb Buser, v T) {
+	q.Post(func() { Trigger(bb, v) })
+}
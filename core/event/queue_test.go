@@ -0,0 +1,77 @@
+package event_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stdiopt/gorge/core/event"
+)
+
+func TestQueue(t *testing.T) {
+	var q event.Queue
+	var b event.Bus
+	var got []eventA
+	event.Handle(&b, func(e eventA) {
+		got = append(got, e)
+		// Posted while flushing, should only be called on the next flush.
+		if e == 0 {
+			event.Post(&q, &b, eventA(100))
+		}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			event.Post(&q, &b, eventA(i))
+		}(i)
+	}
+	wg.Wait()
+
+	if n := q.Len(); n != 10 {
+		t.Fatalf("want len 10, got %d", n)
+	}
+	if n := q.Flush(0); n != 10 {
+		t.Fatalf("want 10 flushed, got %d", n)
+	}
+	if len(got) != 10 {
+		t.Fatalf("want 10 events, got %v", got)
+	}
+	if n := q.Flush(0); n != 1 || got[10] != 100 {
+		t.Fatalf("want posted event on second flush, got %v", got)
+	}
+
+	st := q.Stats()
+	if st.Posted != 11 || st.Flushed != 11 || st.MaxLen != 10 || st.Len != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestQueueBudget(t *testing.T) {
+	var q event.Queue
+	var calls []int
+	for i := 0; i < 3; i++ {
+		i := i
+		q.Post(func() {
+			calls = append(calls, i)
+			time.Sleep(2 * time.Millisecond)
+		})
+	}
+	if n := q.Flush(time.Millisecond); n != 1 {
+		t.Fatalf("want 1 call within budget, got %d", n)
+	}
+	q.Post(func() { calls = append(calls, 3) })
+	q.Flush(0)
+
+	want := []int{0, 1, 2, 3}
+	if len(calls) != len(want) {
+		t.Fatalf("\nwant: %v\n got: %v\n", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("\nwant: %v\n got: %v\n", want, calls)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package layerfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+)
+
+// Archive is a read only fs.FS backed by a zip or pack archive, entries are
+// decompressed when read. It can be mounted with FS.Mount.
+type Archive struct {
+	fsys   fs.FS
+	closer io.Closer
+}
+
+// NewArchive returns an archive reading from r, the format is detected from
+// the data.
+func NewArchive(r io.ReaderAt, size int64) (*Archive, error) {
+	if isPack(r, size) {
+		p, err := newPackFS(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return &Archive{fsys: p}, nil
+	}
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return &Archive{fsys: z}, nil
+}
+
+// OpenArchive opens an archive file, the file is kept open until Close.
+func OpenArchive(name string) (*Archive, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close() // nolint: errcheck
+		return nil, err
+	}
+	a, err := NewArchive(f, info.Size())
+	if err != nil {
+		f.Close() // nolint: errcheck
+		return nil, err
+	}
+	a.closer = f
+	return a, nil
+}
+
+// ArchiveBytes returns an archive from in memory data.
+func ArchiveBytes(data []byte) (*Archive, error) {
+	return NewArchive(bytes.NewReader(data), int64(len(data)))
+}
+
+// OpenHTTPArchive returns an archive read lazily from url with HTTP range
+// requests, only the index and the entries being read are downloaded.
+// If client is nil http.DefaultClient is used.
+func OpenHTTPArchive(url string, client *http.Client) (*Archive, error) {
+	r, err := NewRangeReader(url, client)
+	if err != nil {
+		return nil, err
+	}
+	return NewArchive(r, r.Size())
+}
+
+// Open implements fs.FS.
+func (a *Archive) Open(name string) (fs.File, error) {
+	return a.fsys.Open(name)
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (a *Archive) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(a.fsys, name)
+}
+
+// Stat implements fs.StatFS.
+func (a *Archive) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(a.fsys, name)
+}
+
+// Close releases the archive source if it was opened by OpenArchive.
+func (a *Archive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
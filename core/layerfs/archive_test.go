@@ -0,0 +1,141 @@
+package layerfs_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stdiopt/gorge/core/layerfs"
+)
+
+var archiveFiles = fstest.MapFS{
+	"shaders/default.glsl":   {Data: []byte("void main() {}"), ModTime: time.Unix(100, 0)},
+	"textures/a.png":         {Data: bytes.Repeat([]byte("a"), 200*1024)},
+	"textures/ui/button.png": {Data: []byte("button")},
+	"readme.txt":             {Data: []byte("hello")},
+}
+
+func packData(t *testing.T, compress bool) []byte {
+	buf := &bytes.Buffer{}
+	if err := layerfs.WritePack(buf, archiveFiles, compress); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipData(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, f := range archiveFiles {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: f.ModTime,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(f.Data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchive(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{"pack", func(t *testing.T) []byte { return packData(t, false) }},
+		{"pack deflate", func(t *testing.T) []byte { return packData(t, true) }},
+		{"zip", zipData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := layerfs.ArchiveBytes(tt.data(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fstest.TestFS(a,
+				"shaders/default.glsl",
+				"textures/a.png",
+				"textures/ui/button.png",
+				"readme.txt",
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			lfs := layerfs.FS{}
+			lfs.Mount("assets", a)
+			got, err := fs.ReadFile(lfs, "assets/textures/ui/button.png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "button" {
+				t.Errorf("\nwant: %q\n got: %q\n", "button", got)
+			}
+		})
+	}
+}
+
+func TestPackCorrupt(t *testing.T) {
+	tests := []struct {
+		name  string
+		count uint32
+	}{
+		{"huge count", 0xFFFFFFFF},
+		{"count over index", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := packData(t, false)
+			// footer: index offset uint64, entry count uint32, magic.
+			binary.LittleEndian.PutUint32(data[len(data)-8:], tt.count)
+			if _, err := layerfs.ArchiveBytes(data); err == nil {
+				t.Errorf("want error for entry count %d", tt.count)
+			}
+		})
+	}
+}
+
+func TestHTTPArchive(t *testing.T) {
+	data := packData(t, true)
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.ServeContent(w, r, "assets.pack", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	a, err := layerfs.OpenHTTPArchive(srv.URL, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.ReadFile(a, "readme.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("\nwant: %q\n got: %q\n", "hello", got)
+	}
+	info, err := fs.Stat(a, "textures/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(200 * 1024); info.Size() != want {
+		t.Errorf("\nwant: %v\n got: %v\n", want, info.Size())
+	}
+	if requests > 4 {
+		t.Errorf("want only index and entry blocks requested, got %d requests", requests)
+	}
+}
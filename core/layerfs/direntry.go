@@ -9,7 +9,7 @@ type dirEntry struct {
 // Type returns the type bits for the entry.
 // The type bits are a subset of the usual FileMode bits, those returned by the FileMode.Type method.
 func (d dirEntry) Type() fs.FileMode {
-	return d.Mode()
+	return d.Mode().Type()
 }
 
 // Info returns the FileInfo for the file or subdirectory described by the entry.
@@ -40,6 +40,18 @@ func (f FS) ReadDir(name string) ([]fs.DirEntry, error) {
 	return entries.list, nil
 }
 
+// Stat returns the file info from the last fs to first, the latest file
+// prevails.
+func (f FS) Stat(name string) (fs.FileInfo, error) {
+	for i := len(f) - 1; i >= 0; i-- {
+		info, err := fs.Stat(f[i], name)
+		if err == nil {
+			return info, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
 // Open opens the named file from the last fs to first, the latest file
 // prevails.
 func (f FS) Open(name string) (fs.File, error) {
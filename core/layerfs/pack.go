@@ -0,0 +1,404 @@
+package layerfs
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+// Pack format, file data is followed by the index and a footer:
+//
+//	index entry: name len uint16, name, method uint8, offset uint64,
+//	             compressed size uint64, size uint64, mod time unix nano int64
+//	footer:      index offset uint64, entry count uint32, magic "GPK1"
+//
+// Integers are little endian.
+const (
+	packMagic      = "GPK1"
+	packFooterSize = 8 + 4 + len(packMagic)
+	// smallest index entry, a one byte name.
+	packEntryMinSize = 2 + 1 + 1 + 8 + 8 + 8 + 8
+)
+
+// Pack entry compression methods.
+const (
+	packStore   = uint8(0)
+	packDeflate = uint8(1)
+)
+
+type packEntry struct {
+	name    string
+	method  uint8
+	offset  int64
+	csize   int64
+	size    int64
+	modTime time.Time
+}
+
+// PackWriter writes a pack archive, Close must be called to write the index.
+type PackWriter struct {
+	w       io.Writer
+	off     int64
+	entries []packEntry
+	names   map[string]struct{}
+	closed  bool
+}
+
+// NewPackWriter returns a pack writer that writes to w.
+func NewPackWriter(w io.Writer) *PackWriter {
+	return &PackWriter{w: w, names: map[string]struct{}{}}
+}
+
+// Add adds a file read from r, if compress is true the data is deflated.
+func (p *PackWriter) Add(name string, r io.Reader, modTime time.Time, compress bool) error {
+	if p.closed {
+		return errors.New("pack writer closed")
+	}
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "add", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, ok := p.names[name]; ok {
+		return &fs.PathError{Op: "add", Path: name, Err: fs.ErrExist}
+	}
+	e := packEntry{
+		name:    name,
+		method:  packStore,
+		offset:  p.off,
+		modTime: modTime,
+	}
+	cw := &countWriter{w: p.w}
+	var size int64
+	if compress {
+		e.method = packDeflate
+		fw, err := flate.NewWriter(cw, flate.DefaultCompression)
+		if err != nil {
+			return err
+		}
+		if size, err = io.Copy(fw, r); err != nil {
+			return err
+		}
+		if err := fw.Close(); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if size, err = io.Copy(cw, r); err != nil {
+			return err
+		}
+	}
+	e.size = size
+	e.csize = cw.n
+	p.off += cw.n
+	p.names[name] = struct{}{}
+	p.entries = append(p.entries, e)
+	return nil
+}
+
+// AddFS adds every regular file in fsys.
+func (p *PackWriter) AddFS(fsys fs.FS, compress bool) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint: errcheck
+		return p.Add(name, f, info.ModTime(), compress)
+	})
+}
+
+// Close writes the index and footer, it doesn't close the underlying
+// writer.
+func (p *PackWriter) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	buf := &bytes.Buffer{}
+	le := binary.LittleEndian
+	for _, e := range p.entries {
+		if len(e.name) > 0xFFFF {
+			return fmt.Errorf("pack entry name too long: %q", e.name)
+		}
+		var modTime int64
+		if !e.modTime.IsZero() {
+			modTime = e.modTime.UnixNano()
+		}
+		binary.Write(buf, le, uint16(len(e.name))) // nolint: errcheck
+		buf.WriteString(e.name)
+		buf.WriteByte(e.method)
+		binary.Write(buf, le, uint64(e.offset)) // nolint: errcheck
+		binary.Write(buf, le, uint64(e.csize))  // nolint: errcheck
+		binary.Write(buf, le, uint64(e.size))   // nolint: errcheck
+		binary.Write(buf, le, modTime)          // nolint: errcheck
+	}
+	binary.Write(buf, le, uint64(p.off))          // nolint: errcheck
+	binary.Write(buf, le, uint32(len(p.entries))) // nolint: errcheck
+	buf.WriteString(packMagic)
+	_, err := p.w.Write(buf.Bytes())
+	return err
+}
+
+// WritePack writes every regular file in fsys as a pack archive to w.
+func WritePack(w io.Writer, fsys fs.FS, compress bool) error {
+	p := NewPackWriter(w)
+	if err := p.AddFS(fsys, compress); err != nil {
+		return err
+	}
+	return p.Close()
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// isPack returns true if the data ends with a pack footer.
+func isPack(r io.ReaderAt, size int64) bool {
+	if size < int64(packFooterSize) {
+		return false
+	}
+	magic := make([]byte, len(packMagic))
+	if _, err := r.ReadAt(magic, size-int64(len(packMagic))); err != nil {
+		return false
+	}
+	return string(magic) == packMagic
+}
+
+// packFS is a read only fs.FS for a pack archive, entries are decompressed
+// on demand.
+type packFS struct {
+	r     io.ReaderAt
+	files map[string]*packEntry
+	dirs  map[string][]fs.DirEntry
+}
+
+func newPackFS(r io.ReaderAt, size int64) (*packFS, error) {
+	if !isPack(r, size) {
+		return nil, errors.New("not a pack archive")
+	}
+	le := binary.LittleEndian
+	footer := make([]byte, packFooterSize)
+	if _, err := r.ReadAt(footer, size-int64(packFooterSize)); err != nil {
+		return nil, err
+	}
+	indexOff := int64(le.Uint64(footer))
+	count := int(le.Uint32(footer[8:]))
+	indexSize := size - int64(packFooterSize) - indexOff
+	if indexOff < 0 || indexSize < 0 {
+		return nil, errors.New("pack: invalid index offset")
+	}
+	if int64(count) > indexSize/packEntryMinSize {
+		return nil, fmt.Errorf("pack: entry count %d exceeds index size", count)
+	}
+	index := make([]byte, indexSize)
+	if _, err := r.ReadAt(index, indexOff); err != nil {
+		return nil, err
+	}
+
+	p := &packFS{
+		r:     r,
+		files: map[string]*packEntry{},
+		dirs:  map[string][]fs.DirEntry{".": nil},
+	}
+	rd := bytes.NewReader(index)
+	for i := 0; i < count; i++ {
+		var nameLen uint16
+		if err := binary.Read(rd, le, &nameLen); err != nil {
+			return nil, fmt.Errorf("pack: reading index: %w", err)
+		}
+		name := make([]byte, nameLen)
+		if _, err := io.ReadFull(rd, name); err != nil {
+			return nil, fmt.Errorf("pack: reading index: %w", err)
+		}
+		var h struct {
+			Method  uint8
+			Offset  uint64
+			CSize   uint64
+			Size    uint64
+			ModTime int64
+		}
+		if err := binary.Read(rd, le, &h); err != nil {
+			return nil, fmt.Errorf("pack: reading index: %w", err)
+		}
+		e := &packEntry{
+			name:   string(name),
+			method: h.Method,
+			offset: int64(h.Offset),
+			csize:  int64(h.CSize),
+			size:   int64(h.Size),
+		}
+		if h.ModTime != 0 {
+			e.modTime = time.Unix(0, h.ModTime)
+		}
+		if !fs.ValidPath(e.name) || e.name == "." {
+			return nil, fmt.Errorf("pack: invalid entry name %q", e.name)
+		}
+		if _, ok := p.files[e.name]; ok {
+			return nil, fmt.Errorf("pack: duplicate entry %q", e.name)
+		}
+		if e.offset < 0 || e.csize < 0 || e.offset+e.csize > indexOff {
+			return nil, fmt.Errorf("pack: entry %q out of bounds", e.name)
+		}
+		p.files[e.name] = e
+		p.addDirs(e)
+	}
+	for _, list := range p.dirs {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Name() < list[j].Name()
+		})
+	}
+	return p, nil
+}
+
+// addDirs adds the entry to its parent directory creating missing parents.
+func (p *packFS) addDirs(e *packEntry) {
+	var d fs.DirEntry = dirEntry{e.info()}
+	name := e.name
+	for {
+		dir := path.Dir(name)
+		_, exists := p.dirs[dir]
+		p.dirs[dir] = append(p.dirs[dir], d)
+		if exists || dir == "." {
+			return
+		}
+		d = dirEntry{dirInfo(path.Base(dir))}
+		name = dir
+	}
+}
+
+func (e *packEntry) info() fileInfo {
+	return fileInfo{
+		name:    path.Base(e.name),
+		size:    e.size,
+		mode:    0444,
+		modTime: e.modTime,
+	}
+}
+
+func dirInfo(name string) fileInfo {
+	return fileInfo{
+		name:  name,
+		mode:  fs.ModeDir | 0555,
+		isDir: true,
+	}
+}
+
+// Open implements fs.FS.
+func (p *packFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if e, ok := p.files[name]; ok {
+		sr := io.NewSectionReader(p.r, e.offset, e.csize)
+		switch e.method {
+		case packStore:
+			return &packFile{info: e.info(), Reader: sr}, nil
+		case packDeflate:
+			fr := flate.NewReader(sr)
+			return &packFile{info: e.info(), Reader: fr, closer: fr}, nil
+		default:
+			return nil, &fs.PathError{
+				Op:   "open",
+				Path: name,
+				Err:  fmt.Errorf("unsupported method %d", e.method),
+			}
+		}
+	}
+	if list, ok := p.dirs[name]; ok {
+		return &packDir{info: dirInfo(path.Base(name)), entries: list}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (p *packFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	list, ok := p.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: os.ErrNotExist}
+	}
+	return append([]fs.DirEntry(nil), list...), nil
+}
+
+// Stat implements fs.StatFS.
+func (p *packFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if e, ok := p.files[name]; ok {
+		return e.info(), nil
+	}
+	if _, ok := p.dirs[name]; ok {
+		return dirInfo(path.Base(name)), nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+type packFile struct {
+	info fileInfo
+	io.Reader
+	closer io.Closer
+}
+
+func (f *packFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *packFile) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+type packDir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	off     int
+}
+
+func (d *packDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *packDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *packDir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *packDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.off:]
+	if n <= 0 {
+		d.off = len(d.entries)
+		return append([]fs.DirEntry(nil), rest...), nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.off += n
+	return append([]fs.DirEntry(nil), rest[:n]...), nil
+}
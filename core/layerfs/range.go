@@ -0,0 +1,165 @@
+package layerfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	rangeBlockSize = 64 * 1024
+	rangeMaxBlocks = 32
+)
+
+// RangeReader is an io.ReaderAt that reads a remote file with HTTP range
+// requests, reads are done in blocks and the latest blocks are cached.
+type RangeReader struct {
+	url    string
+	client *http.Client
+	size   int64
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	order  []int64
+}
+
+// NewRangeReader returns a range reader for url, the size is fetched with a
+// HEAD request. If client is nil http.DefaultClient is used.
+func NewRangeReader(url string, client *http.Client) (*RangeReader, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &RangeReader{
+		url:    url,
+		client: client,
+		blocks: map[int64][]byte{},
+	}
+	size, err := r.fetchSize()
+	if err != nil {
+		return nil, err
+	}
+	r.size = size
+	return r, nil
+}
+
+// Size returns the remote file size.
+func (r *RangeReader) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *RangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("range reader: negative offset")
+	}
+	n := 0
+	for n < len(p) {
+		cur := off + int64(n)
+		if cur >= r.size {
+			return n, io.EOF
+		}
+		start := cur - cur%rangeBlockSize
+		block, err := r.block(start)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], block[cur-start:])
+	}
+	return n, nil
+}
+
+func (r *RangeReader) block(start int64) ([]byte, error) {
+	r.mu.Lock()
+	if b, ok := r.blocks[start]; ok {
+		r.mu.Unlock()
+		return b, nil
+	}
+	r.mu.Unlock()
+
+	end := start + rangeBlockSize
+	if end > r.size {
+		end = r.size
+	}
+	b, err := r.fetch(start, end-1)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.blocks[start]; !ok {
+		r.blocks[start] = b
+		r.order = append(r.order, start)
+		if len(r.order) > rangeMaxBlocks {
+			delete(r.blocks, r.order[0])
+			r.order = r.order[1:]
+		}
+	}
+	return b, nil
+}
+
+func (r *RangeReader) fetch(first, last int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", first, last))
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close() // nolint: errcheck
+	if res.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("range request %s: unexpected status %s", r.url, res.Status)
+	}
+	b := make([]byte, last-first+1)
+	if _, err := io.ReadFull(res.Body, b); err != nil {
+		return nil, fmt.Errorf("range request %s: %w", r.url, err)
+	}
+	return b, nil
+}
+
+// fetchSize returns the content length from a HEAD request, servers that
+// don't report it are asked for the first byte to read Content-Range.
+func (r *RangeReader) fetchSize() (int64, error) {
+	res, err := r.client.Head(r.url)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close() // nolint: errcheck
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("head %s: unexpected status %s", r.url, res.Status)
+	}
+	if res.ContentLength >= 0 && res.Header.Get("Accept-Ranges") == "bytes" {
+		return res.ContentLength, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	res, err = r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close() // nolint: errcheck
+	if res.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("%s: range requests not supported", r.url)
+	}
+	// Content-Range: bytes 0-0/size
+	cr := res.Header.Get("Content-Range")
+	n := strings.LastIndex(cr, "/")
+	if n == -1 {
+		return 0, fmt.Errorf("%s: invalid content range %q", r.url, cr)
+	}
+	size, err := strconv.ParseInt(cr[n+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid content range %q", r.url, cr)
+	}
+	return size, nil
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -34,6 +35,7 @@ var (
 	}
 
 	// Color per Prefix.
+	prefixMu     sync.Mutex
 	prefixStyle  = map[string]int{}
 	prefixColors = []string{
 		"\033[31m", "\033[32m", "\033[33m", "\033[35m", "\033[36m",
@@ -123,19 +125,10 @@ func (w *Writer) writeLine(b []byte) error {
 	if w.prefix != "" {
 		prefixStr = w.prefix
 	}
-	// Colored prefix, it will match a string in the prefix map
-	// and fetch correspondent color in the color list
-	prefixStyleID, ok := prefixStyle[prefixStr]
-	if !ok {
-		prefixStyleID = len(prefixStyle)
-		prefixStyle[prefixStr] = prefixStyleID
-	}
-	prefixColor := prefixColors[prefixStyleID%len(prefixColors)]
-
 	str := fmt.Sprintf("[%s:%s %s]: %s %s %s\n",
 		GlobalStyle.Counter.Get(w.counter),
 		GlobalStyle.Time.Get(time.Now().Format("2006-01-02 15:04:05.000")),
-		GlobalStyle.Prefix.GetCustom(prefixColor, "\033[0m", prefixStr),
+		GlobalStyle.Prefix.GetCustom(prefixColor(prefixStr), "\033[0m", prefixStr),
 		GlobalStyle.Message.Get(msg),
 
 		GlobalStyle.Duration.Get(duration),
@@ -170,6 +163,19 @@ func Global() {
 	log.SetOutput(NewWriter(""))
 }
 
+// prefixColor returns the color for a prefix, it will match a string in the
+// prefix map and fetch correspondent color in the color list.
+func prefixColor(prefix string) string {
+	prefixMu.Lock()
+	defer prefixMu.Unlock()
+	id, ok := prefixStyle[prefix]
+	if !ok {
+		id = len(prefixStyle)
+		prefixStyle[prefix] = id
+	}
+	return prefixColors[id%len(prefixColors)]
+}
+
 func durationStr(dur time.Duration) string {
 	fdurationSuf := "ms"
 	fduration := float64(dur.Nanoseconds()) / 1000000.0
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var levelColors = map[Level]string{
+	LevelDebug: "\033[90m",
+	LevelInfo:  "\033[37m",
+	LevelWarn:  "\033[33m",
+	LevelError: "\033[01;31m",
+}
+
+// TextSink returns a sink that writes colored human readable lines to w.
+func TextSink(w io.Writer) Sink {
+	return &textSink{w: w}
+}
+
+type textSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *textSink) Log(e Entry) error {
+	buf := &bytes.Buffer{}
+	name := e.Name
+	if name == "" {
+		name = "-"
+	}
+	fmt.Fprintf(buf, "[%s %s]: %s%-5s\033[0m %s",
+		GlobalStyle.Time.Get(e.Time.Format("2006-01-02 15:04:05.000")),
+		GlobalStyle.Prefix.GetCustom(prefixColor(name), "\033[0m", name),
+		levelColors[e.Level], e.Level,
+		GlobalStyle.Message.Get(e.Message),
+	)
+	for _, f := range e.Fields {
+		fmt.Fprintf(buf, " \033[36m%s\033[0m=%v", f.Key, f.Value)
+	}
+	if e.File != "" {
+		fmt.Fprintf(buf, " %s", GlobalStyle.File.Get(
+			fmt.Sprintf("%s:%d", filepath.Base(e.File), e.Line),
+		))
+	}
+	buf.WriteByte('\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+// JSONSink returns a sink that writes an entry per line as a JSON object
+// with time, level, name, msg, file and the entry fields.
+func JSONSink(w io.Writer) Sink {
+	return &jsonSink{w: w}
+}
+
+type jsonSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *jsonSink) Log(e Entry) error {
+	m := make(map[string]any, len(e.Fields)+5)
+	for _, f := range e.Fields {
+		m[f.Key] = jsonValue(f.Value)
+	}
+	m["time"] = e.Time.Format(time.RFC3339Nano)
+	m["level"] = e.Level
+	m["msg"] = e.Message
+	if e.Name != "" {
+		m["name"] = e.Name
+	}
+	if e.File != "" {
+		m["file"] = fmt.Sprintf("%s:%d", filepath.Base(e.File), e.Line)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(data)
+	return err
+}
+
+// jsonValue returns a value that marshals to something readable, errors and
+// values that fail to marshal are written as strings.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return v
+}
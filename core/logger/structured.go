@@ -0,0 +1,251 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Field is a log entry key value pair.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Entry is a structured log entry passed to sinks.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Name    string
+	Message string
+	Fields  []Field
+	File    string
+	Line    int
+}
+
+// Sink receives log entries, sinks are called from the logging goroutine.
+type Sink interface {
+	Log(e Entry) error
+}
+
+// SinkFunc is a func that implements Sink.
+type SinkFunc func(e Entry) error
+
+// Log implements Sink.
+func (fn SinkFunc) Log(e Entry) error { return fn(e) }
+
+// core is the state shared by a logger and the loggers derived from it.
+type core struct {
+	mu     sync.Mutex
+	level  Level
+	levels map[string]Level
+	sinks  []Sink
+}
+
+// Logger is a leveled structured logger, derived loggers created with Named,
+// With and WithSink share the level configuration and sinks.
+type Logger struct {
+	core   *core
+	name   string
+	fields []Field
+	sinks  []Sink
+}
+
+// NewLogger returns a logger at LevelInfo writing to sinks.
+func NewLogger(sinks ...Sink) *Logger {
+	return &Logger{
+		core: &core{
+			level:  LevelInfo,
+			levels: map[string]Level{},
+			sinks:  sinks,
+		},
+	}
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultLogger *Logger
+)
+
+// Default returns the default logger, it is configured with the GORGE_LOG
+// environment variable as "text" or "json" and GORGE_LOG_LEVEL.
+func Default() *Logger {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultLogger == nil {
+		defaultLogger = FromEnv()
+	}
+	return defaultLogger
+}
+
+// SetDefault replaces the default logger.
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+// FromEnv returns a logger to stderr configured by GORGE_LOG and
+// GORGE_LOG_LEVEL.
+func FromEnv() *Logger {
+	var sink Sink = TextSink(os.Stderr)
+	if strings.ToLower(os.Getenv("GORGE_LOG")) == "json" {
+		sink = JSONSink(os.Stderr)
+	}
+	l := NewLogger(sink)
+	if lvl, err := ParseLevel(os.Getenv("GORGE_LOG_LEVEL")); err == nil {
+		l.SetLevel(lvl)
+	}
+	return l
+}
+
+// Name returns the logger name.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Named returns a logger with name appended to the current name with a dot.
+func (l *Logger) Named(name string) *Logger {
+	n := l.derive()
+	if l.name != "" && name != "" {
+		name = l.name + "." + name
+	} else if name == "" {
+		name = l.name
+	}
+	n.name = name
+	return n
+}
+
+// With returns a logger that adds key value pairs to every entry.
+func (l *Logger) With(kv ...any) *Logger {
+	n := l.derive()
+	n.fields = append(n.fields, fields(kv)...)
+	return n
+}
+
+// WithSink returns a logger that also writes to s.
+func (l *Logger) WithSink(s Sink) *Logger {
+	n := l.derive()
+	n.sinks = append(n.sinks, s)
+	return n
+}
+
+// SetLevel sets the minimum level for loggers without a named level.
+func (l *Logger) SetLevel(lvl Level) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.level = lvl
+}
+
+// SetNameLevel sets the minimum level for the named logger and its children,
+// i.e: SetNameLevel("render", LevelDebug).
+func (l *Logger) SetNameLevel(name string, lvl Level) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.levels[name] = lvl
+}
+
+// ResetNameLevel removes a level set with SetNameLevel.
+func (l *Logger) ResetNameLevel(name string) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	delete(l.core.levels, name)
+}
+
+// Level returns the minimum level for this logger.
+func (l *Logger) Level() Level {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	name := l.name
+	for {
+		if lvl, ok := l.core.levels[name]; ok {
+			return lvl
+		}
+		n := strings.LastIndex(name, ".")
+		if n == -1 {
+			break
+		}
+		name = name[:n]
+	}
+	return l.core.level
+}
+
+// Enabled returns true if entries with lvl are logged.
+func (l *Logger) Enabled(lvl Level) bool {
+	return lvl >= l.Level()
+}
+
+// Debug logs a debug message with key value pairs.
+func (l *Logger) Debug(msg string, kv ...any) { l.log(LevelDebug, msg, kv) }
+
+// Info logs an info message with key value pairs.
+func (l *Logger) Info(msg string, kv ...any) { l.log(LevelInfo, msg, kv) }
+
+// Warn logs a warning message with key value pairs.
+func (l *Logger) Warn(msg string, kv ...any) { l.log(LevelWarn, msg, kv) }
+
+// Error logs an error message with key value pairs.
+func (l *Logger) Error(msg string, kv ...any) { l.log(LevelError, msg, kv) }
+
+// Log logs a message at level lvl.
+func (l *Logger) Log(lvl Level, msg string, kv ...any) { l.log(lvl, msg, kv) }
+
+func (l *Logger) derive() *Logger {
+	return &Logger{
+		core:   l.core,
+		name:   l.name,
+		fields: l.fields[:len(l.fields):len(l.fields)],
+		sinks:  l.sinks[:len(l.sinks):len(l.sinks)],
+	}
+}
+
+func (l *Logger) log(lvl Level, msg string, kv []any) {
+	if !l.Enabled(lvl) {
+		return
+	}
+	e := Entry{
+		Time:    time.Now(),
+		Level:   lvl,
+		Name:    l.name,
+		Message: msg,
+		Fields:  append(l.fields[:len(l.fields):len(l.fields)], fields(kv)...),
+	}
+	// Skip log and the level method.
+	if _, file, line, ok := runtime.Caller(2); ok {
+		e.File, e.Line = file, line
+	}
+
+	l.core.mu.Lock()
+	sinks := append(l.core.sinks[:len(l.core.sinks):len(l.core.sinks)], l.sinks...)
+	l.core.mu.Unlock()
+	for _, s := range sinks {
+		if err := s.Log(e); err != nil {
+			fmt.Fprintln(os.Stderr, "logger sink error:", err)
+		}
+	}
+}
+
+// fields converts key value pairs, a missing value is set as nil and a non
+// string key is converted with fmt.
+func fields(kv []any) []Field {
+	if len(kv) == 0 {
+		return nil
+	}
+	ret := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		f := Field{}
+		if k, ok := kv[i].(string); ok {
+			f.Key = k
+		} else {
+			f.Key = fmt.Sprint(kv[i])
+		}
+		if i+1 < len(kv) {
+			f.Value = kv[i+1]
+		}
+		ret = append(ret, f)
+	}
+	return ret
+}
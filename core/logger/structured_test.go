@@ -0,0 +1,65 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stdiopt/gorge/core/logger"
+)
+
+func TestLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	root := logger.NewLogger(logger.JSONSink(buf))
+	root.SetNameLevel("render", logger.LevelDebug)
+
+	render := root.Named("render").With("frame", 1)
+	render.Named("shader").Debug("compile", "hash", 10)
+	root.Named("input").Debug("skipped")
+	root.Named("input").Warn("key not mapped", "key", "F13", "err", errors.New("boom"))
+
+	dec := json.NewDecoder(buf)
+	var got []map[string]any
+	for dec.More() {
+		var m map[string]any
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, m)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 entries, got %d: %v", len(got), got)
+	}
+	tests := []struct {
+		entry int
+		key   string
+		want  any
+	}{
+		{0, "name", "render.shader"},
+		{0, "level", "debug"},
+		{0, "msg", "compile"},
+		{0, "frame", float64(1)},
+		{0, "hash", float64(10)},
+		{1, "name", "input"},
+		{1, "level", "warn"},
+		{1, "key", "F13"},
+		{1, "err", "boom"},
+	}
+	for _, tt := range tests {
+		if v := got[tt.entry][tt.key]; v != tt.want {
+			t.Errorf("entry %d %q\nwant: %v\n got: %v\n", tt.entry, tt.key, tt.want, v)
+		}
+	}
+	if _, ok := got[0]["file"]; !ok {
+		t.Error("want file in entry")
+	}
+
+	root.SetLevel(logger.LevelError)
+	if root.Named("input").Enabled(logger.LevelWarn) {
+		t.Error("want warn disabled")
+	}
+	if !root.Named("render").Enabled(logger.LevelDebug) {
+		t.Error("want render debug enabled")
+	}
+}
@@ -218,6 +218,9 @@ func (s *statSystem) Update() string {
 		float64(time.Second)/float64(dt),
 	)
 	fmt.Fprintf(buf, "Drawcalls:       %10v\n", s.rendererStat.DrawCalls)
-	fmt.Fprintf(buf, "Transforms:      %10v, Saved: %v", gorge.TransformBuilds, gorge.TransformBuildSave)
+	qs := s.gorge.QueueStats()
+	fmt.Fprintf(buf, "Queue:           %10v, Max: %v, Last flush: %v\n", qs.Len, qs.MaxLen, qs.LastFlush)
+	ts := s.gorge.TransformStats()
+	fmt.Fprintf(buf, "Transforms:      %10v, Saved: %v", ts.Builds, ts.Saved)
 	return buf.String()
 }
@@ -8,6 +8,13 @@ type gorger interface {
 	Gorge() *Context
 }
 
+// Post queues an event to be triggered on the main loop, it doesn't block and
+// it is safe to be called from any goroutine.
+func Post[T any](g Contexter, e T) {
+	gg := g.G()
+	event.Post(&gg.queue, gg, e)
+}
+
 func TriggerInMain[T any](g gorger, e T) {
 	g.Gorge().RunInMain(func() {
 		event.Trigger(g.Gorge(), e)
@@ -20,6 +27,13 @@ type EventPreUpdate float32
 // DeltaTime returns the float32 delta time for the event.
 func (e EventPreUpdate) DeltaTime() float32 { return float32(e) }
 
+// EventFixedUpdate is triggered zero or more times per frame after
+// EventPreUpdate with a constant step.
+type EventFixedUpdate float32
+
+// DeltaTime returns the float32 fixed step for the event.
+func (e EventFixedUpdate) DeltaTime() float32 { return float32(e) }
+
 // EventUpdate type
 type EventUpdate float32
 
@@ -51,7 +65,8 @@ type EventStart struct{}
 // EventAfterStart to attach stuff (wasm request animation frame workaround)
 type EventAfterStart struct{}
 
-// EventDestroy is called when system is shutting down
+// EventDestroy is triggered when gorge is closing, before systems registered
+// with OnDestroy are released.
 type EventDestroy struct{}
 
 // EventError contains an error
@@ -65,3 +80,9 @@ type EventWarn string
 type EventResourceUpdate struct {
 	Resource any
 }
+
+// EventResourceRelease asks systems to release the data bound to a resource
+// i.e: deleting gpu buffers
+type EventResourceRelease struct {
+	Resource any
+}
@@ -3,8 +3,9 @@ package gorge
 
 import (
 	"errors"
-	"log"
+	"time"
 
+	"github.com/stdiopt/gorge/anim"
 	"github.com/stdiopt/gorge/core/event"
 	"github.com/stdiopt/gorge/core/logger"
 	"github.com/stdiopt/gorge/math/gm"
@@ -17,6 +18,14 @@ func init() {
 	logger.Global()
 }
 
+const (
+	// DefaultFixedStep is the default fixed update step in seconds.
+	DefaultFixedStep = float32(1) / 60
+	// DefaultMaxSubsteps is the default maximum number of fixed updates per
+	// frame.
+	DefaultMaxSubsteps = 5
+)
+
 // InitFunc type of function to initialize gorge.
 type InitFunc func(*Context)
 
@@ -31,17 +40,45 @@ type Gorge struct {
 	// Maybe create Device/Display so we can even use multiple displays
 	screenSize gm.Vec2
 	inits      []InitFunc
+	systems    SystemDefs
+	loaded     []string
+
+	queue       event.Queue
+	queueBudget time.Duration
+
+	timing Time
+	tasks  scheduler
+	world  world
+
+	transformStats TransformStats
+
+	// fixed timestep
+	fixedStep   float32
+	maxSubsteps int
+	accumulator float64
+	fixedAlpha  float32
+
+	logger *logger.Logger
+	// logDispatch is set while events posted by the log sink are triggered.
+	logDispatch int32
 
-	fnch chan syncFunc
-	done chan error
+	destroyers []func() error
+	closed     bool
+	err        error
+	done       chan struct{}
 }
 
 // New create a new manager with default systems
 func New(inits ...InitFunc) *Gorge {
-	return &Gorge{
-		inits: inits,
-		fnch:  make(chan syncFunc, 64),
+	g := &Gorge{
+		inits:       inits,
+		timing:      newTime(),
+		fixedStep:   DefaultFixedStep,
+		maxSubsteps: DefaultMaxSubsteps,
+		logger:      logger.Default(),
 	}
+	g.world.stats = &g.transformStats
+	return g
 }
 
 // SetScreenSize used by the gorgeapp to set the current screensize
@@ -55,15 +92,26 @@ func (g *Gorge) ScreenSize() gm.Vec2 {
 	return g.screenSize
 }
 
-// Start the systems
+// Start the systems, declared systems are initialized first ordered by
+// dependencies followed by every init func.
 // nolint: errcheck
 func (g *Gorge) Start() error {
 	if g.done != nil {
 		return ErrAlreadyStarted
 	}
-	g.done = make(chan error)
-	// Call every init func
+	systems, err := sortSystems(g.systems)
+	if err != nil {
+		return err
+	}
+	g.done = make(chan struct{})
 	c := &Context{gorge: g}
+	for _, s := range systems {
+		if s.Init != nil {
+			s.Init(c)
+		}
+		g.loaded = append(g.loaded, s.Name)
+	}
+	// Call every init func
 	for _, fn := range g.inits {
 		fn(c)
 	}
@@ -73,23 +121,55 @@ func (g *Gorge) Start() error {
 	return nil
 }
 
-// Wait waits for execution to finish.
+// Wait waits for execution to finish, it returns the error passed to
+// CloseWithError and any error returned while releasing systems.
 func (g *Gorge) Wait() error {
 	if g.done == nil {
 		return nil
 	}
-	return <-g.done
+	<-g.done
+	return g.err
+}
+
+// OnDestroy registers fn to be called when gorge closes, funcs are called in
+// the reverse order they were registered, after EventDestroy, so systems
+// registering on init are released in reverse init order.
+func (g *Gorge) OnDestroy(fn func() error) {
+	g.destroyers = append(g.destroyers, fn)
 }
 
-// Close closes the running instance.
+// Close triggers EventDestroy, releases systems and finishes the running
+// instance, it should be called from the main loop.
 func (g *Gorge) Close() {
-	close(g.done)
-	g.done = nil
+	g.shutdown(nil)
 }
 
-// CloseWithError closes with an error which will be sent on Wait() call
+// CloseWithError closes like Close, err will be returned on Wait() call.
 func (g *Gorge) CloseWithError(err error) {
-	g.done <- err
+	g.shutdown(err)
+}
+
+// nolint: errcheck
+func (g *Gorge) shutdown(err error) {
+	if g.done == nil || g.closed {
+		return
+	}
+	g.closed = true
+
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	g.tasks.cancelAll()
+	event.Trigger(g, EventDestroy{})
+	for i := len(g.destroyers) - 1; i >= 0; i-- {
+		if err := g.destroyers[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	g.destroyers = nil
+
+	g.err = JoinErrors(errs...)
 	close(g.done)
 }
 
@@ -101,36 +181,109 @@ func (g *Gorge) Run() error {
 	return g.Wait()
 }
 
-type syncFunc struct {
-	fn   func()
-	done chan struct{}
-}
-
 // RunInMain schedule a func to be run on main loop
 // It will wait for the function to return
 func (g *Gorge) RunInMain(fn func()) {
-	sf := syncFunc{
-		fn:   fn,
-		done: make(chan struct{}),
-	}
-	g.fnch <- sf
+	done := make(chan struct{})
+	g.queue.Post(func() {
+		defer close(done)
+		fn()
+	})
 	// Wait for func to finish
-	<-sf.done
+	<-done
 }
 
-// Update just updates stuff right away
+// PostFunc schedules a func to be run on main loop without waiting for it, it
+// is safe to be called from any goroutine.
+func (g *Gorge) PostFunc(fn func()) {
+	g.queue.Post(fn)
+}
+
+// SetQueueBudget sets the maximum time spent per frame calling posted funcs
+// and events, the remaining will be called on the next frames, 0 means no
+// limit.
+func (g *Gorge) SetQueueBudget(d time.Duration) {
+	g.queueBudget = d
+}
+
+// QueueStats returns metrics of the main loop queue.
+func (g *Gorge) QueueStats() event.QueueStats {
+	return g.queue.Stats()
+}
+
+// Time returns the frame timing state.
+func (g *Gorge) Time() *Time {
+	return &g.timing
+}
+
+// NewAnimation returns an animation clocked by this instance Time so it
+// follows the time scale and pause.
+func (g *Gorge) NewAnimation() *anim.Animation {
+	a := anim.New()
+	a.SetClock(&g.timing)
+	return a
+}
+
+// SetFixedStep sets the step in seconds used by EventFixedUpdate, 0 disables
+// fixed updates.
+func (g *Gorge) SetFixedStep(step float32) {
+	g.fixedStep = step
+	g.accumulator = 0
+}
+
+// FixedStep returns the fixed update step in seconds.
+func (g *Gorge) FixedStep() float32 {
+	return g.fixedStep
+}
+
+// SetMaxSubsteps sets the maximum number of fixed updates per frame, time
+// exceeding it is dropped to avoid spiraling on slow frames, n <= 0 removes
+// the limit.
+func (g *Gorge) SetMaxSubsteps(n int) {
+	g.maxSubsteps = n
+}
+
+// FixedAlpha returns the remaining fraction of a fixed step after the last
+// fixed updates, it can be used to interpolate simulation states while
+// rendering.
+func (g *Gorge) FixedAlpha() float32 {
+	return g.fixedAlpha
+}
+
+// fixedUpdate accumulates dt and triggers EventFixedUpdate zero or more times.
 // nolint: errcheck
-func (g *Gorge) Update(dt float32) {
-	// How much does this costs?
-	// Calls any schedule func
-	select {
-	case sf := <-g.fnch:
-		sf.fn()
-		close(sf.done)
-	default:
+func (g *Gorge) fixedUpdate(dt float32) {
+	if g.fixedStep <= 0 {
+		g.fixedAlpha = 0
+		return
+	}
+	step := float64(g.fixedStep)
+	g.accumulator += float64(dt)
+	for n := 0; g.accumulator >= step; n++ {
+		if g.maxSubsteps > 0 && n >= g.maxSubsteps {
+			// Drop the remaining whole steps
+			g.accumulator -= step * float64(int(g.accumulator/step))
+			break
+		}
+		event.Trigger(g, EventFixedUpdate(g.fixedStep))
+		g.accumulator -= step
 	}
+	g.fixedAlpha = float32(g.accumulator / step)
+}
+
+// Update just updates stuff right away, dt is the real frame delta and
+// update events will receive the delta scaled by Time.
+// nolint: errcheck
+func (g *Gorge) Update(dt float32) {
+	// Calls posted funcs and events before any update.
+	g.queue.Flush(g.queueBudget)
+
+	dt = g.timing.advance(dt)
+
 	event.Trigger(g, EventPreUpdate(dt))
+	g.fixedUpdate(dt)
 	event.Trigger(g, EventUpdate(dt))
+	g.tasks.step()
 	event.Trigger(g, EventPostUpdate(dt))
 	event.Trigger(g, EventRender(dt))
 }
@@ -140,6 +293,7 @@ func (g *Gorge) Update(dt float32) {
 func (g *Gorge) Add(ents ...Entity) {
 	for _, e := range ents {
 		EachEntity(e, func(e Entity) {
+			g.world.add(e)
 			event.Trigger(g, EventAddEntity{e})
 		})
 	}
@@ -150,7 +304,9 @@ func (g *Gorge) Add(ents ...Entity) {
 func (g *Gorge) Remove(ents ...Entity) {
 	for _, e := range ents {
 		EachEntity(e, func(e Entity) {
+			g.world.remove(e)
 			event.Trigger(g, EventRemoveEntity{e})
+			g.tasks.cancelOwner(e)
 		})
 	}
 }
@@ -158,7 +314,7 @@ func (g *Gorge) Remove(ents ...Entity) {
 // Error persists an error in the event system
 // nolint: errcheck
 func (g *Gorge) Error(err error) {
-	log.Printf("[error] %v", err)
+	g.logger.Named("gorge").Error(err.Error())
 	event.Trigger(g, EventError{err})
 }
 
@@ -166,16 +322,16 @@ func (g *Gorge) Error(err error) {
 
 // HandleUpdate adds a listener that filters events and calls fn if it is the
 // EventUpdate.
-func (g *Gorge) HandleUpdate(fn func(float32)) {
-	event.Handle(g, func(e EventUpdate) {
+func (g *Gorge) HandleUpdate(fn func(float32)) *event.Subscription {
+	return event.Handle(g, func(e EventUpdate) {
 		fn(float32(e))
 	})
 }
 
 // HandleError registers a function that filters events and calls fn if event
 // is the EventError.
-func (g *Gorge) HandleError(fn func(err error)) {
-	event.Handle(g, func(e EventError) {
+func (g *Gorge) HandleError(fn func(err error)) *event.Subscription {
+	return event.Handle(g, func(e EventError) {
 		fn(e.Err)
 	})
 }
@@ -0,0 +1,141 @@
+package gorge_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+)
+
+func TestClose(t *testing.T) {
+	var order []string
+	errA := errors.New("a")
+	errB := errors.New("b")
+	gg := gorge.New(
+		func(g *gorge.Context) {
+			g.OnDestroy(func() error {
+				order = append(order, "first")
+				return errA
+			})
+		},
+		func(g *gorge.Context) {
+			event.Handle(g, func(gorge.EventDestroy) {
+				order = append(order, "event")
+			})
+			g.OnDestroy(func() error {
+				order = append(order, "second")
+				return nil
+			})
+		},
+	)
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	gg.CloseWithError(errB)
+	gg.Close()
+
+	err := gg.Wait()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("want both errors, got %v", err)
+	}
+	want := []string{"event", "second", "first"}
+	if len(order) != len(want) {
+		t.Fatalf("\nwant: %v\n got: %v\n", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("\nwant: %v\n got: %v\n", want, order)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFixedUpdate(t *testing.T) {
+	type want struct {
+		fixed int
+		alpha float32
+	}
+	tests := []struct {
+		name     string
+		step     float32
+		substeps int
+		frames   []float32
+		want     want
+	}{
+		{
+			name:     "accumulates small frames",
+			step:     0.1,
+			substeps: 5,
+			frames:   []float32{0.04, 0.04, 0.04},
+			want:     want{fixed: 1, alpha: 0.2},
+		},
+		{
+			name:     "multiple steps per frame",
+			step:     0.1,
+			substeps: 5,
+			frames:   []float32{0.35},
+			want:     want{fixed: 3, alpha: 0.5},
+		},
+		{
+			name:     "clamps substeps and drops whole steps",
+			step:     0.1,
+			substeps: 2,
+			frames:   []float32{0.55},
+			want:     want{fixed: 2, alpha: 0.5},
+		},
+		{
+			name:     "no limit",
+			step:     0.1,
+			substeps: 0,
+			frames:   []float32{1.05},
+			want:     want{fixed: 10, alpha: 0.5},
+		},
+		{
+			name:     "disabled",
+			step:     0,
+			substeps: 5,
+			frames:   []float32{1, 1},
+			want:     want{fixed: 0, alpha: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gg := gorge.New()
+			if err := gg.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer gg.Close()
+			gg.SetFixedStep(tt.step)
+			gg.SetMaxSubsteps(tt.substeps)
+
+			fixed := 0
+			event.Handle(gg, func(e gorge.EventFixedUpdate) {
+				if e.DeltaTime() != tt.step {
+					t.Errorf("want step %v, got %v", tt.step, e.DeltaTime())
+				}
+				fixed++
+			})
+			for _, dt := range tt.frames {
+				gg.Update(dt)
+			}
+			if fixed != tt.want.fixed {
+				t.Errorf("want %d fixed updates, got %d", tt.want.fixed, fixed)
+			}
+			if d := gg.FixedAlpha() - tt.want.alpha; d > 1e-4 || d < -1e-4 {
+				t.Errorf("want alpha %v, got %v", tt.want.alpha, gg.FixedAlpha())
+			}
+		})
+	}
+}
@@ -11,10 +11,13 @@ import (
 // Type shows the plataform it is being run on
 const Type = "glfw"
 
+// platformSystems are the default systems specific to the platform.
+var platformSystems = []gorge.SystemDef{audio.SystemDef}
+
 // Run the glfw app
 func (a *App) Run() error {
-	inits := append([]gorge.InitFunc{audio.System},
-		a.inits...,
-	)
-	return glfw.Run(a.glfwOptions, inits...)
+	opt := a.glfwOptions
+	opt.Systems = append([]gorge.SystemDef{}, a.systems...)
+	opt.Systems = append(opt.Systems, a.glfwOptions.Systems...)
+	return glfw.Run(opt, a.inits...)
 }
@@ -43,6 +43,7 @@ func Run(opt Options, systems ...gorge.InitFunc) error {
 	if err != nil {
 		return err
 	}
+	defer window.Destroy()
 	window.MakeContextCurrent()
 
 	// When running opengl "github.com/go-gl/gl/v4.6-core/gl"
@@ -72,16 +73,17 @@ func Run(opt Options, systems ...gorge.InitFunc) error {
 		resourceFS = RootFS{}
 	}
 
-	ggArgs := []gorge.InitFunc{
-		func(g *gorge.Context) {
+	g := gorge.New(systems...)
+	// Platform is declared first so it initializes before other systems.
+	g.AddSystem(gorge.SystemDef{
+		Name: gorge.PlatformSystem,
+		Init: func(g *gorge.Context) {
 			res := resource.FromContext(g)
 			res.AddFS("/", resourceFS)
+			s.System(g)
 		},
-		s.System,
-	}
-	ggArgs = append(ggArgs, systems...)
-
-	g := gorge.New(ggArgs...)
+	})
+	g.AddSystem(opt.Systems...)
 
 	// bind stuff together
 	if err := g.Start(); err != nil {
@@ -110,8 +112,9 @@ func Run(opt Options, systems ...gorge.InitFunc) error {
 			lastFrame = now
 		}
 	}
+	// Release systems while the gl context is still current.
 	g.Close()
-	return nil
+	return g.Wait()
 }
 
 type glfwSystem struct {
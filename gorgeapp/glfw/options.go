@@ -1,9 +1,15 @@
 package glfw
 
-import "io/fs"
+import (
+	"io/fs"
+
+	"github.com/stdiopt/gorge"
+)
 
 // Options options for glfw
 type Options struct {
 	FS fs.FS
+	// Systems are declared systems initialized by dependency order.
+	Systems []gorge.SystemDef
 	// FAA
 }
@@ -28,7 +28,8 @@ type AppFunc func(p *App)
 
 // App the bootstrapper.
 type App struct {
-	inits []gorge.InitFunc
+	systems gorge.SystemDefs
+	inits   []gorge.InitFunc
 
 	wasmOptions wasm.Options
 	glfwOptions glfw.Options
@@ -49,15 +50,18 @@ func New(inits ...gorge.InitFunc) *App {
 		},
 	}
 
-	// define default rendering pipeline
-	defInits := []gorge.InitFunc{
-		resource.System,
-		input.System,
-		render.System,
-		renderpl.System,
-		gorgeui.System,
-		particle.System,
-		/*func(g *gorge.Context) error {
+	// default systems, platform specific ones first
+	a.systems = append(gorge.SystemDefs{}, platformSystems...)
+	a.systems = append(a.systems,
+		resource.SystemDef,
+		input.SystemDef,
+		render.SystemDef,
+		renderpl.SystemDef,
+		gorgeui.SystemDef,
+		particle.SystemDef,
+	)
+	/*defInits := []gorge.InitFunc{
+		func(g *gorge.Context) error {
 			ic := input.FromContext(g)
 			res := resource.FromContext(g)
 			appCtx := &Context{
@@ -69,13 +73,29 @@ func New(inits ...gorge.InitFunc) *App {
 				fn(appCtx)
 			}
 			return nil
-		},*/
-	}
-	a.inits = append(defInits, inits...) // nolint
-	// a.inits = defInits
+		},
+	}*/
+	a.inits = inits
 	return a
 }
 
+// AddSystem declares systems, a system with an existing name is replaced.
+func (a *App) AddSystem(defs ...gorge.SystemDef) {
+	a.systems.Add(defs...)
+}
+
+// ReplaceSystem replaces the init func of a default system, if deps are
+// passed they replace the existing ones, it returns false if the system is not
+// declared.
+func (a *App) ReplaceSystem(name string, fn gorge.InitFunc, deps ...string) bool {
+	return a.systems.Replace(name, fn, deps...)
+}
+
+// DisableSystem removes a default system.
+func (a *App) DisableSystem(name string) {
+	a.systems.Disable(name)
+}
+
 // Options calls the params appfuncs.
 func (a *App) Options(opt ...AppFunc) {
 	for _, ofn := range opt {
@@ -99,6 +119,22 @@ func GLFWSourcer(s fs.FS) AppFunc {
 	}
 }
 
+// WithSystem declares systems replacing the defaults with the same name.
+func WithSystem(defs ...gorge.SystemDef) AppFunc {
+	return func(a *App) {
+		a.AddSystem(defs...)
+	}
+}
+
+// WithoutSystem disables the named default systems.
+func WithoutSystem(names ...string) AppFunc {
+	return func(a *App) {
+		for _, n := range names {
+			a.DisableSystem(n)
+		}
+	}
+}
+
 // WasmOpt sets the wasm options.
 func WasmOpt(o WasmOptions) AppFunc {
 	return func(a *App) {
@@ -0,0 +1,201 @@
+// Package headless provides a platform without window or gpu, gorge is
+// driven by a simulated clock which makes it suitable for tests and
+// simulation only servers.
+package headless
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+	"github.com/stdiopt/gorge/systems/input"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+// Headless drives a gorge instance manually.
+type Headless struct {
+	opt Options
+
+	gorge *gorge.Gorge
+	ctx   *gorge.Context
+	input *input.Context
+
+	frame   uint64
+	elapsed time.Duration
+	script  map[uint64][]Action
+	stopped int32
+
+	mu      sync.Mutex
+	closed  bool
+	running chan struct{}
+}
+
+// New creates a headless platform with the systems, the instance is not
+// started until Start is called.
+func New(opt Options, systems ...gorge.InitFunc) *Headless {
+	if opt.ScreenSize == (gm.Vec2{}) {
+		opt.ScreenSize = gm.Vec2{800, 600}
+	}
+	if opt.Step <= 0 {
+		opt.Step = float32(1) / 60
+	}
+	h := &Headless{
+		opt:    opt,
+		script: map[uint64][]Action{},
+	}
+
+	h.gorge = gorge.New(systems...)
+	// Platform is declared first so it initializes before other systems.
+	h.gorge.AddSystem(gorge.SystemDef{
+		Name: gorge.PlatformSystem,
+		Init: func(g *gorge.Context) {
+			if opt.FS != nil {
+				res := resource.FromContext(g)
+				res.AddFS("/", opt.FS)
+			}
+			h.System(g)
+		},
+	})
+	h.gorge.AddSystem(opt.Systems...)
+	h.gorge.SetScreenSize(opt.ScreenSize)
+	return h
+}
+
+// System binds the headless platform to gorge context.
+func (h *Headless) System(g *gorge.Context) {
+	h.ctx = g
+	h.input = input.FromContext(g)
+}
+
+// Start starts the gorge instance.
+func (h *Headless) Start() error {
+	return h.gorge.Start()
+}
+
+// Close stops Run and waits for it to close the gorge instance, if Run is not
+// being used it closes the instance right away and must be called from the
+// goroutine stepping frames. It returns any error from releasing systems.
+func (h *Headless) Close() error {
+	h.Stop()
+	h.mu.Lock()
+	h.closed = true
+	running := h.running
+	h.mu.Unlock()
+	if running != nil {
+		<-running
+	} else {
+		h.gorge.Close()
+	}
+	return h.gorge.Wait()
+}
+
+// Stop makes Run return after the current frame, it is safe to be called from
+// any goroutine.
+func (h *Headless) Stop() {
+	atomic.StoreInt32(&h.stopped, 1)
+}
+
+// Gorge returns the gorge context, it is only available after Start.
+func (h *Headless) Gorge() *gorge.Context {
+	return h.ctx
+}
+
+// Input returns the input context, it is only available after Start.
+func (h *Headless) Input() *input.Context {
+	return h.input
+}
+
+// SetScreenSize sets the virtual screen size.
+func (h *Headless) SetScreenSize(s gm.Vec2) {
+	h.gorge.SetScreenSize(s)
+}
+
+// Frame returns the number of frames stepped so far.
+func (h *Headless) Frame() uint64 {
+	return h.frame
+}
+
+// Elapsed returns the simulated time stepped so far.
+func (h *Headless) Elapsed() time.Duration {
+	return h.elapsed
+}
+
+// Schedule schedules input actions to be applied before the update of the
+// frame number, frames starts at 1.
+func (h *Headless) Schedule(frame uint64, acts ...Action) {
+	h.script[frame] = append(h.script[frame], acts...)
+}
+
+// Apply applies input actions right away.
+func (h *Headless) Apply(acts ...Action) {
+	for _, a := range acts {
+		a(h.input)
+	}
+}
+
+// Step updates a single frame with dt in seconds.
+func (h *Headless) Step(dt float32) {
+	h.frame++
+	if acts, ok := h.script[h.frame]; ok {
+		delete(h.script, h.frame)
+		h.Apply(acts...)
+	}
+	h.elapsed += time.Duration(float64(dt) * float64(time.Second))
+	h.gorge.Update(dt)
+}
+
+// StepN updates n frames with the options step.
+func (h *Headless) StepN(n int) {
+	for i := 0; i < n; i++ {
+		h.Step(h.opt.Step)
+	}
+}
+
+// Advance steps frames with the options step until d is simulated.
+func (h *Headless) Advance(d time.Duration) {
+	target := h.elapsed + d
+	for h.elapsed < target {
+		h.Step(h.opt.Step)
+	}
+}
+
+// Run starts and steps frames until the options frame count is reached or
+// Stop is called, then closes the gorge instance.
+func (h *Headless) Run() error {
+	h.mu.Lock()
+	if h.closed || h.running != nil {
+		h.mu.Unlock()
+		return h.gorge.Wait()
+	}
+	running := make(chan struct{})
+	h.running = running
+	h.mu.Unlock()
+	defer close(running)
+
+	if err := h.Start(); err != nil {
+		return err
+	}
+	step := time.Duration(float64(h.opt.Step) * float64(time.Second))
+	mark := time.Now()
+	for atomic.LoadInt32(&h.stopped) == 0 {
+		if h.opt.Frames > 0 && h.frame >= uint64(h.opt.Frames) {
+			break
+		}
+		h.Step(h.opt.Step)
+		if h.opt.Realtime {
+			mark = mark.Add(step)
+			time.Sleep(time.Until(mark))
+		}
+	}
+	h.gorge.Close()
+	return h.gorge.Wait()
+}
+
+// Run creates and runs a headless platform.
+func Run(opt Options, systems ...gorge.InitFunc) error {
+	log.Println("Init headless")
+	return New(opt, systems...).Run()
+}
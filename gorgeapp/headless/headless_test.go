@@ -0,0 +1,119 @@
+package headless_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/gorgeapp/headless"
+	"github.com/stdiopt/gorge/math/gm"
+	"github.com/stdiopt/gorge/systems/input"
+)
+
+func TestHeadless(t *testing.T) {
+	var (
+		updates  int
+		total    float32
+		keyFrame uint64
+		screen   gm.Vec2
+	)
+	h := headless.New(headless.Options{
+		ScreenSize: gm.Vec2{320, 240},
+	}, func(g *gorge.Context) {
+		screen = g.ScreenSize()
+		event.Handle(g, func(e gorge.EventUpdate) {
+			updates++
+			total += e.DeltaTime()
+		})
+		event.Handle(g, func(e input.EventKeyDown) {
+			if e.Key == input.KeySpace {
+				keyFrame = g.Time().Frame()
+			}
+		})
+	})
+	h.Schedule(3, headless.KeyDown(input.KeySpace))
+
+	if err := h.Start(); err != nil {
+		t.Fatal(err)
+	}
+	h.StepN(5)
+	h.Advance(time.Second)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if screen != (gm.Vec2{320, 240}) {
+		t.Errorf("want screen size %v, got %v", gm.Vec2{320, 240}, screen)
+	}
+	if updates != 65 || h.Frame() != 65 {
+		t.Errorf("want 65 updates, got %d, frame %d", updates, h.Frame())
+	}
+	if d := total - float32(65)/60; d < -1e-4 || d > 1e-4 {
+		t.Errorf("want total time %v, got %v", float32(65)/60, total)
+	}
+	// Input is applied before the update of frame 3, which advances the
+	// gorge frame counter.
+	if keyFrame != 2 {
+		t.Errorf("want key down before frame 3 update, got %d", keyFrame)
+	}
+}
+
+func TestHeadlessRun(t *testing.T) {
+	frames := 0
+	err := headless.Run(headless.Options{Frames: 10}, func(g *gorge.Context) {
+		g.HandleUpdate(func(float32) { frames++ })
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames != 10 {
+		t.Errorf("want 10 frames, got %d", frames)
+	}
+}
+
+func TestHeadlessCloseRun(t *testing.T) {
+	destroyed := 0
+	started := make(chan struct{})
+	h := headless.New(headless.Options{Realtime: true}, func(g *gorge.Context) {
+		g.OnDestroy(func() error {
+			destroyed++
+			return nil
+		})
+		close(started)
+	})
+	runErr := make(chan error, 1)
+	go func() { runErr <- h.Run() }()
+
+	<-started
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatal(err)
+	}
+	if destroyed != 1 {
+		t.Errorf("want gorge closed once, got %d", destroyed)
+	}
+}
+
+func TestHeadlessPlatformFirst(t *testing.T) {
+	var h *headless.Headless
+	bound := false
+	h = headless.New(headless.Options{
+		Systems: []gorge.SystemDef{{
+			Name: "custom",
+			Init: func(*gorge.Context) { bound = h.Gorge() != nil },
+		}},
+	})
+	if err := h.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	if !bound {
+		t.Error("platform should initialize before declared systems")
+	}
+	if want, got := gorge.PlatformSystem, h.Gorge().Systems()[0]; got != want {
+		t.Errorf("want first system %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,44 @@
+package headless
+
+import (
+	"github.com/stdiopt/gorge/math/gm"
+	"github.com/stdiopt/gorge/systems/input"
+)
+
+// Action is a scripted input action.
+type Action func(ic *input.Context)
+
+// KeyDown presses a key.
+func KeyDown(k input.Key) Action {
+	return func(ic *input.Context) { ic.SetKeyState(k, input.ActionDown) }
+}
+
+// KeyUp releases a key.
+func KeyUp(k input.Key) Action {
+	return func(ic *input.Context) { ic.SetKeyState(k, input.ActionUp) }
+}
+
+// Char types a char.
+func Char(c rune) Action {
+	return func(ic *input.Context) { ic.SetKeyChar(c) }
+}
+
+// MouseDown presses a mouse button.
+func MouseDown(b input.MouseButton) Action {
+	return func(ic *input.Context) { ic.SetMouseButtonState(b, input.ActionDown) }
+}
+
+// MouseUp releases a mouse button.
+func MouseUp(b input.MouseButton) Action {
+	return func(ic *input.Context) { ic.SetMouseButtonState(b, input.ActionUp) }
+}
+
+// CursorPosition moves the cursor to p in screen coordinates.
+func CursorPosition(p gm.Vec2) Action {
+	return func(ic *input.Context) { ic.SetCursorPosition(p) }
+}
+
+// Scroll scrolls the mouse wheel by delta.
+func Scroll(delta gm.Vec2) Action {
+	return func(ic *input.Context) { ic.SetScrollDelta(delta) }
+}
@@ -0,0 +1,23 @@
+package headless
+
+import (
+	"io/fs"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+)
+
+// Options options for headless
+type Options struct {
+	FS fs.FS
+	// Systems are declared systems initialized by dependency order.
+	Systems []gorge.SystemDef
+	// ScreenSize is the virtual screen size, defaults to 800x600.
+	ScreenSize gm.Vec2
+	// Step is the simulated frame delta in seconds, defaults to 1/60.
+	Step float32
+	// Frames is the number of frames Run will step, 0 runs until Close.
+	Frames int
+	// Realtime makes Run wait between frames to match the wall clock.
+	Realtime bool
+}
@@ -10,7 +10,12 @@ import (
 
 const Type = "wasm"
 
+// platformSystems are the default systems specific to the platform.
+var platformSystems = []gorge.SystemDef{audio.SystemDef}
+
 func (a *App) Run() error {
-	inits := append([]gorge.InitFunc{audio.System}, a.inits...)
-	return wasm.Run(a.wasmOptions, inits...)
+	opt := a.wasmOptions
+	opt.Systems = append([]gorge.SystemDef{}, a.systems...)
+	opt.Systems = append(opt.Systems, a.wasmOptions.Systems...)
+	return wasm.Run(opt, a.inits...)
 }
@@ -1,9 +1,15 @@
 package wasm
 
-import "io/fs"
+import (
+	"io/fs"
+
+	"github.com/stdiopt/gorge"
+)
 
 // Options options for Wasm
 // TODO: Might change android and ios
 type Options struct {
 	FS fs.FS
+	// Systems are declared systems initialized by dependency order.
+	Systems []gorge.SystemDef
 }
@@ -62,18 +62,19 @@ func Run(opt Options, systems ...gorge.InitFunc) error {
 	// Default asset loader to .
 	resourceFS := opt.FS
 	if resourceFS == nil {
-		resourceFS = resource.HTTPFS{""}
+		resourceFS = resource.HTTPFS{BaseURL: ""}
 	}
-	ggArgs := []gorge.InitFunc{
-		func(g *gorge.Context) {
+	g := gorge.New(systems...)
+	// Platform is declared first so it initializes before other systems.
+	g.AddSystem(gorge.SystemDef{
+		Name: gorge.PlatformSystem,
+		Init: func(g *gorge.Context) {
 			res := resource.FromContext(g)
 			res.AddFS("/", resourceFS)
+			s.System(g)
 		},
-		s.System,
-	}
-	ggArgs = append(ggArgs, systems...)
-
-	g := gorge.New(ggArgs...)
+	})
+	g.AddSystem(opt.Systems...)
 	// Handle platform specific events here.
 
 	return g.Run()
@@ -278,7 +279,7 @@ func (s *wasmSystem) handleTouchEvents(t js.Value, args []js.Value) any {
 			},
 		}
 	}
-	event.Trigger(s.gorge, input.EventPointer{
+	event.Trigger(s.gorge, &input.EventPointer{
 		Type:     gtyp,
 		Pointers: pts,
 	})
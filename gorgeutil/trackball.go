@@ -4,7 +4,6 @@ import (
 	"github.com/stdiopt/gorge"
 	"github.com/stdiopt/gorge/core/event"
 	"github.com/stdiopt/gorge/math/gm"
-	"github.com/stdiopt/gorge/systems/gorgeui"
 	"github.com/stdiopt/gorge/systems/input"
 )
 
@@ -16,8 +15,7 @@ type CameraRig struct {
 
 	lastP *gm.Vec2
 
-	dragging      bool
-	disableEvents bool
+	dragging bool
 }
 
 func (s *CameraRig) String() string {
@@ -32,13 +30,7 @@ func (r *CameraRig) GetEntities() []gorge.Entity {
 // HandleEvent implements the event handler interface.
 func (r *CameraRig) HandleEvent(e event.Event) {
 	switch e := e.(type) {
-	case gorgeui.EventDragging:
-		r.disableEvents = e.Entity != nil
-	case input.EventPointer:
-		if r.disableEvents {
-			return
-		}
-
+	case *input.EventPointer:
 		if r.lastP == nil {
 			r.lastP = &gm.Vec2{}
 			*r.lastP = e.Pointers[0].Pos
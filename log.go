@@ -0,0 +1,88 @@
+package gorge
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/core/logger"
+)
+
+// SetLogger sets the logger used by gorge and systems, defaults to
+// logger.Default.
+func (g *Gorge) SetLogger(l *logger.Logger) {
+	g.logger = l
+}
+
+// Logger returns the gorge logger.
+func (g *Gorge) Logger() *logger.Logger {
+	return g.logger
+}
+
+// Log returns a logger named after a system, warnings and errors logged
+// with it are also posted as EventWarn and EventError.
+func (g *Gorge) Log(name string) *logger.Logger {
+	return g.logger.Named(name).WithSink(eventSink{g})
+}
+
+// Warn logs a warning and triggers EventWarn.
+// nolint: errcheck
+func (g *Gorge) Warn(msg string) {
+	g.logger.Named("gorge").Warn(msg)
+	event.Trigger(g, EventWarn(msg))
+}
+
+// eventSink posts warning and error entries as gorge events so they can be
+// handled from the main loop, entries logged while a posted entry is being
+// dispatched are not posted so handlers logging errors don't loop.
+type eventSink struct {
+	g *Gorge
+}
+
+func (s eventSink) Log(e logger.Entry) error {
+	if atomic.LoadInt32(&s.g.logDispatch) > 0 {
+		return nil
+	}
+	switch e.Level {
+	case logger.LevelWarn:
+		postEntry(s.g, EventWarn(entryString(e)))
+	case logger.LevelError:
+		var err error
+		for _, f := range e.Fields {
+			if v, ok := f.Value.(error); ok {
+				err = fmt.Errorf("%s: %w", e.Message, v)
+				break
+			}
+		}
+		if err == nil {
+			err = errors.New(entryString(e))
+		}
+		postEntry(s.g, EventError{err})
+	}
+	return nil
+}
+
+func entryString(e logger.Entry) string {
+	b := &strings.Builder{}
+	if e.Name != "" {
+		fmt.Fprintf(b, "%s: ", e.Name)
+	}
+	b.WriteString(e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(b, " %s=%v", f.Key, f.Value)
+	}
+	return b.String()
+}
+
+// postEntry posts ev marking the dispatch so the sink skips entries logged by
+// its handlers.
+// nolint: errcheck
+func postEntry[T any](g *Gorge, ev T) {
+	g.queue.Post(func() {
+		atomic.AddInt32(&g.logDispatch, 1)
+		defer atomic.AddInt32(&g.logDispatch, -1)
+		event.Trigger(g, ev)
+	})
+}
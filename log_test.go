@@ -0,0 +1,63 @@
+package gorge_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/core/logger"
+)
+
+func TestLogEvents(t *testing.T) {
+	var ctx *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { ctx = c })
+	gg.SetLogger(logger.NewLogger())
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	var warns []string
+	var errs []error
+	event.Handle(ctx, func(e gorge.EventWarn) { warns = append(warns, string(e)) })
+	event.Handle(ctx, func(e gorge.EventError) { errs = append(errs, e.Err) })
+
+	sentinel := errors.New("sentinel")
+	log := ctx.Log("sys")
+	log.Info("ignored")
+	log.Warn("careful", "n", 1)
+	log.Error("failed", "err", sentinel)
+	gg.Update(1)
+
+	if want := []string{"sys: careful n=1"}; !equalStrings(warns, want) {
+		t.Errorf("\nwant: %v\n got: %v\n", want, warns)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], sentinel) {
+		t.Errorf("want sentinel error, got %v", errs)
+	}
+}
+
+func TestLogEventsReentrant(t *testing.T) {
+	var ctx *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { ctx = c })
+	gg.SetLogger(logger.NewLogger())
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	log := ctx.Log("sys")
+	errs := 0
+	ctx.HandleError(func(err error) {
+		errs++
+		log.Error("handling", "err", err)
+	})
+	log.Error("failed")
+	for i := 0; i < 3; i++ {
+		gg.Update(1)
+	}
+	if errs != 1 {
+		t.Errorf("want 1 error event, got %d", errs)
+	}
+}
@@ -58,6 +58,21 @@ func (q Quat) Normalize() Quat {
 	}
 }
 
+// Conjugate returns the conjugate of the quaternion.
+func (q Quat) Conjugate() Quat {
+	return Quat{-q[0], -q[1], -q[2], q[3]}
+}
+
+// Inv returns the inverse of the quaternion.
+func (q Quat) Inv() Quat {
+	l := q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3]
+	if l == 0 {
+		return QIdent()
+	}
+	c := q.Conjugate()
+	return Quat{c[0] / l, c[1] / l, c[2] / l, c[3] / l}
+}
+
 // W returns the W part of the quaternion.
 func (q Quat) W() Float {
 	return q[3]
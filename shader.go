@@ -25,6 +25,8 @@ type ShaderData struct {
 	GPU
 	Name string
 	Src  []byte
+	// Updates is incremented when the source changes, i.e: on hot reload.
+	Updates int
 }
 
 // Resource implements a resourcer.
@@ -171,6 +173,11 @@ func (s *shaderProps) Props() map[string]any {
 	return s.props
 }
 
+// Samplers returns the textures of this material
+func (s *shaderProps) Samplers() map[string]*Texture {
+	return s.samplers
+}
+
 // SetFloat32 XXX testing sets a float32
 func (s *shaderProps) SetFloat32(name string, v float32) {
 	s.Set(name, v)
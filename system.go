@@ -0,0 +1,171 @@
+package gorge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SystemDef declares a named system and the systems it depends on, declared
+// systems are initialized on Start ordered by their dependencies and before
+// any plain InitFunc.
+type SystemDef struct {
+	Name string
+	Deps []string
+	Init InitFunc
+}
+
+// PlatformSystem is the name of the system declared by platforms, it is
+// declared first so it initializes before the other systems.
+const PlatformSystem = "platform"
+
+// SystemError is returned by Start when the declared systems can't be
+// ordered.
+type SystemError struct {
+	Name string
+	// Missing is set when a dependency is not declared or disabled.
+	Missing string
+	// Cycle is set with the systems names when a dependency cycle is found.
+	Cycle []string
+}
+
+func (e *SystemError) Error() string {
+	if len(e.Cycle) > 0 {
+		return fmt.Sprintf("system %q dependency cycle: %s", e.Name, strings.Join(e.Cycle, " -> "))
+	}
+	return fmt.Sprintf("system %q depends on missing system %q", e.Name, e.Missing)
+}
+
+// SystemDefs is a list of declared systems, names are unique.
+type SystemDefs []SystemDef
+
+// Add declares systems, a system with an existing name is replaced.
+func (s *SystemDefs) Add(defs ...SystemDef) {
+	for _, d := range defs {
+		if i := s.index(d.Name); i != -1 {
+			(*s)[i] = d
+			continue
+		}
+		*s = append(*s, d)
+	}
+}
+
+// Replace replaces the init func of the named system keeping its name and
+// dependencies, if deps are passed they replace the existing ones.
+// It returns false if the system is not declared.
+func (s SystemDefs) Replace(name string, fn InitFunc, deps ...string) bool {
+	i := s.index(name)
+	if i == -1 {
+		return false
+	}
+	s[i].Init = fn
+	if len(deps) > 0 {
+		s[i].Deps = deps
+	}
+	return true
+}
+
+// Disable removes the named system.
+func (s *SystemDefs) Disable(name string) {
+	if i := s.index(name); i != -1 {
+		*s = append((*s)[:i], (*s)[i+1:]...)
+	}
+}
+
+func (s SystemDefs) index(name string) int {
+	for i, d := range s {
+		if d.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddSystem declares systems to be initialized on Start, a system with an
+// existing name is replaced.
+func (g *Gorge) AddSystem(defs ...SystemDef) {
+	g.systems.Add(defs...)
+}
+
+// ReplaceSystem replaces the init func of the named system keeping its name
+// and dependencies, if deps are passed they replace the existing ones.
+// It returns false if the system is not declared.
+func (g *Gorge) ReplaceSystem(name string, fn InitFunc, deps ...string) bool {
+	return g.systems.Replace(name, fn, deps...)
+}
+
+// DisableSystem removes a declared system so it won't be initialized, systems
+// depending on it will fail to start.
+func (g *Gorge) DisableSystem(name string) {
+	g.systems.Disable(name)
+}
+
+// Systems returns the names of the initialized systems in init order.
+func (g *Gorge) Systems() []string {
+	return append([]string(nil), g.loaded...)
+}
+
+// HasSystem returns true if the named system was initialized.
+func (g *Gorge) HasSystem(name string) bool {
+	for _, n := range g.loaded {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sortSystems orders the declared systems by dependencies keeping the
+// declaration order when possible.
+func sortSystems(defs []SystemDef) ([]SystemDef, error) {
+	byName := map[string]SystemDef{}
+	for _, d := range defs {
+		byName[d.Name] = d
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	sorted := make([]SystemDef, 0, len(defs))
+	var path []string
+
+	var visit func(d SystemDef) error
+	visit = func(d SystemDef) error {
+		switch state[d.Name] {
+		case visited:
+			return nil
+		case visiting:
+			n := 0
+			for i, p := range path {
+				if p == d.Name {
+					n = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[n:]...), d.Name)
+			return &SystemError{Name: d.Name, Cycle: cycle}
+		}
+		state[d.Name] = visiting
+		path = append(path, d.Name)
+		for _, dep := range d.Deps {
+			dd, ok := byName[dep]
+			if !ok {
+				return &SystemError{Name: d.Name, Missing: dep}
+			}
+			if err := visit(dd); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[d.Name] = visited
+		sorted = append(sorted, d)
+		return nil
+	}
+	for _, d := range defs {
+		if err := visit(d); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,97 @@
+package gorge_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+)
+
+func TestSystems(t *testing.T) {
+	type want struct {
+		order []string
+		err   string
+	}
+	sys := func(name string, deps ...string) gorge.SystemDef {
+		return gorge.SystemDef{Name: name, Deps: deps}
+	}
+	tests := []struct {
+		name    string
+		defs    []gorge.SystemDef
+		disable string
+		want    want
+	}{
+		{
+			name: "declaration order",
+			defs: []gorge.SystemDef{sys("a"), sys("b"), sys("c")},
+			want: want{order: []string{"a", "b", "c"}},
+		},
+		{
+			name: "dependencies first",
+			defs: []gorge.SystemDef{sys("ui", "render", "input"), sys("render"), sys("input")},
+			want: want{order: []string{"render", "input", "ui"}},
+		},
+		{
+			name: "missing",
+			defs: []gorge.SystemDef{sys("ui", "render")},
+			want: want{err: `system "ui" depends on missing system "render"`},
+		},
+		{
+			name:    "disabled",
+			defs:    []gorge.SystemDef{sys("render"), sys("ui", "render")},
+			disable: "render",
+			want:    want{err: `system "ui" depends on missing system "render"`},
+		},
+		{
+			name: "cycle",
+			defs: []gorge.SystemDef{sys("a", "b"), sys("b", "c"), sys("c", "a")},
+			want: want{err: `system "a" dependency cycle: a -> b -> c -> a`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gg := gorge.New()
+			gg.AddSystem(tt.defs...)
+			if tt.disable != "" {
+				gg.DisableSystem(tt.disable)
+			}
+			err := gg.Start()
+			if tt.want.err != "" {
+				var serr *gorge.SystemError
+				if !errors.As(err, &serr) || err.Error() != tt.want.err {
+					t.Errorf("\nwant: %v\n got: %v\n", tt.want.err, err)
+				}
+				return
+			}
+			defer gg.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := gg.Systems(); !equalStrings(got, tt.want.order) {
+				t.Errorf("\nwant: %v\n got: %v\n", tt.want.order, got)
+			}
+		})
+	}
+}
+
+func TestReplaceSystem(t *testing.T) {
+	var called []string
+	gg := gorge.New()
+	gg.AddSystem(gorge.SystemDef{
+		Name: "render",
+		Init: func(*gorge.Context) { called = append(called, "default") },
+	})
+	if !gg.ReplaceSystem("render", func(*gorge.Context) { called = append(called, "custom") }) {
+		t.Fatal("want render system to be replaced")
+	}
+	if gg.ReplaceSystem("missing", nil) {
+		t.Error("want false replacing a missing system")
+	}
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	if !equalStrings(called, []string{"custom"}) || !gg.HasSystem("render") {
+		t.Errorf("\nwant: %v\n got: %v\n", []string{"custom"}, called)
+	}
+}
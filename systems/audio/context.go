@@ -1,7 +1,7 @@
 package audio
 
 import (
-	"log"
+	"math"
 
 	"github.com/stdiopt/gorge"
 )
@@ -21,14 +21,17 @@ func FromContext(g *gorge.Context) *Context {
 		return ctx
 	}
 
-	log.Println("Initializing system")
+	g.Log("audio").Info("initializing system")
 	audio := &Audio{
+		gorge:   g,
 		sources: map[*gorge.AudioSource]*Processor{},
+		scale:   math.Float32bits(1),
 	}
 	ctx := &Context{audio}
 	gorge.SetContext(g, ctx)
 	// g.PutProp(&Context{audio})
 	g.AddHandler(audio)
+	g.OnDestroy(audio.Destroy)
 
 	return ctx
 }
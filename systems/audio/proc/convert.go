@@ -0,0 +1,68 @@
+package proc
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/stdiopt/gorge"
+)
+
+// Convert returns the clip data as 16 bit samples with the given rate and
+// channels, mono clips are duplicated and extra channels are dropped.
+// Rates are converted with linear interpolation.
+func Convert(clip *gorge.AudioClipData, rate, channels int) []byte {
+	inCh := clip.NumChannels()
+	if clip.Format == gorge.AudioFormatS16 && clip.Rate() == rate && inCh == channels {
+		return clip.Data
+	}
+	frames := clip.Frames()
+	size := clip.Format.Size()
+	sample := func(frame, ch int) float64 {
+		if ch >= inCh {
+			ch = 0
+			if inCh > 1 {
+				return 0
+			}
+		}
+		return decodeSample(clip.Format, clip.Data[(frame*inCh+ch)*size:])
+	}
+
+	ratio := float64(clip.Rate()) / float64(rate)
+	outFrames := int(float64(frames) / ratio)
+	out := make([]byte, outFrames*channels*2)
+	for i := 0; i < outFrames; i++ {
+		pos := float64(i) * ratio
+		f := int(pos)
+		t := pos - float64(f)
+		next := f + 1
+		if next >= frames {
+			next = f
+		}
+		for ch := 0; ch < channels; ch++ {
+			v := sample(f, ch)
+			if t > 0 {
+				v += (sample(next, ch) - v) * t
+			}
+			v = math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(v*(1<<15))))
+			binary.LittleEndian.PutUint16(out[(i*channels+ch)*2:], uint16(int16(v)))
+		}
+	}
+	return out
+}
+
+// decodeSample returns a sample in the [-1, 1] range.
+func decodeSample(f gorge.AudioFormat, b []byte) float64 {
+	switch f {
+	case gorge.AudioFormatU8:
+		return (float64(b[0]) - 128) / 128
+	case gorge.AudioFormatS24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(v) / (1 << 23)
+	case gorge.AudioFormatS32:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	case gorge.AudioFormatF32:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	default:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	}
+}
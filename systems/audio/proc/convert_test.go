@@ -0,0 +1,39 @@
+package proc_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/systems/audio/proc"
+)
+
+func TestConvert(t *testing.T) {
+	t.Run("passthrough", func(t *testing.T) {
+		clip := &gorge.AudioClipData{Data: []byte{1, 2, 3, 4}}
+		if got := proc.Convert(clip, 44100, 2); !bytes.Equal(got, clip.Data) {
+			t.Errorf("\nwant: %v\n got: %v\n", clip.Data, got)
+		}
+	})
+	t.Run("u8 mono upsample", func(t *testing.T) {
+		clip := &gorge.AudioClipData{
+			Format:     gorge.AudioFormatU8,
+			SampleRate: 22050,
+			Channels:   1,
+			Data:       []byte{128, 192},
+		}
+		got := proc.Convert(clip, 44100, 2)
+		want := []int16{0, 0, 8192, 8192, 16384, 16384, 16384, 16384}
+		samples := make([]int16, len(got)/2)
+		binary.Read(bytes.NewReader(got), binary.LittleEndian, samples) // nolint: errcheck
+		if len(samples) != len(want) {
+			t.Fatalf("\nwant: %v\n got: %v\n", want, samples)
+		}
+		for i := range want {
+			if samples[i] != want[i] {
+				t.Fatalf("\nwant: %v\n got: %v\n", want, samples)
+			}
+		}
+	})
+}
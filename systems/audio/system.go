@@ -2,7 +2,8 @@
 package audio
 
 import (
-	"log"
+	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/hajimehoshi/oto"
@@ -12,6 +13,17 @@ import (
 	"github.com/stdiopt/gorge/systems/audio/proc"
 )
 
+// Output device format, clips are converted to 16 bit samples.
+const (
+	sampleRate   = 44100
+	channelCount = 2
+	frameSize    = 2 * channelCount
+	chunkSize    = 100
+)
+
+// SystemDef declares the audio system.
+var SystemDef = gorge.SystemDef{Name: "audio", Init: System}
+
 // System initializes the audio system.
 func System(g *gorge.Context) {
 	FromContext(g)
@@ -38,15 +50,55 @@ type Audio struct {
 	// Player per Source
 	sources  map[*gorge.AudioSource]*Processor
 	entities []rSourceEntity
+
+	// paused and scale are set from gorge.Time and read by processors, scale
+	// holds the float32 bits.
+	paused int32
+	scale  uint32
+	// closed stops processors.
+	closed int32
 }
 
 // HandleEvent implements the eventhandler.
 func (s *Audio) HandleEvent(ee event.Event) {
-	e, ok := ee.(gorge.EventAddEntity)
-	if !ok {
-		return
+	switch e := ee.(type) {
+	case gorge.EventUpdate:
+		var paused int32
+		if s.gorge.Time().Paused() {
+			paused = 1
+		}
+		atomic.StoreInt32(&s.paused, paused)
+		atomic.StoreUint32(&s.scale, math.Float32bits(s.gorge.Time().Scale()))
+	case gorge.EventAddEntity:
+		s.addEntity(e)
+	}
+}
+
+// Destroy stops every processor and closes the audio device.
+func (s *Audio) Destroy() error {
+	atomic.StoreInt32(&s.closed, 1)
+	if s.oto == nil {
+		return nil
+	}
+	var errs []error
+	for _, p := range s.sources {
+		<-p.done
+		if err := p.player.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := s.oto.Close(); err != nil {
+		errs = append(errs, err)
 	}
+	s.oto = nil
+	return gorge.JoinErrors(errs...)
+}
+
+func (s *Audio) timeScale() float32 {
+	return math.Float32frombits(atomic.LoadUint32(&s.scale))
+}
 
+func (s *Audio) addEntity(e gorge.EventAddEntity) {
 	if ae, ok := e.Entity.(rListenerEntity); ok {
 		s.listener = ae
 	}
@@ -54,7 +106,7 @@ func (s *Audio) HandleEvent(ee event.Event) {
 		if s.oto == nil {
 			// Lazy start
 			// Need options here
-			o, err := oto.NewContext(44100, 2, 2, 2048)
+			o, err := oto.NewContext(sampleRate, channelCount, 2, 2048)
 			if err != nil {
 				s.gorge.Error(err)
 				return
@@ -72,8 +124,9 @@ func (s *Audio) HandleEvent(ee event.Event) {
 			positional: proc.NewPositional(player),
 			source:     ae.AudioSourceComponent(),
 			entity:     ae,
+			done:       make(chan struct{}),
 		}
-		log.Println("Adding audio source", proc)
+		s.gorge.Log("audio").Debug("adding audio source")
 		// Create a processor
 		s.sources[comp] = proc
 		proc.Run()
@@ -89,10 +142,24 @@ type Processor struct {
 	entity rSourceEntity
 	source *gorge.AudioSource
 	cur    int
+	// frac is the fractional frame position while playing scaled.
+	frac    float64
+	scratch []byte
+	done    chan struct{}
+
+	// converted clip data for the device format.
+	clip        *gorge.AudioClipData
+	clipUpdates int
+	data        []byte
+}
+
+func (p *Processor) closed() bool {
+	return atomic.LoadInt32(&p.audio.closed) == 1
 }
 
 func (p *Processor) run() {
-	for {
+	defer close(p.done)
+	for !p.closed() {
 		time.Sleep(1000 / 60 * time.Millisecond)
 
 		if p.source.Clip == nil {
@@ -102,13 +169,14 @@ func (p *Processor) run() {
 		if !ok {
 			panic("panic loading audio clip")
 		}
-		p.cur = 0
+		data := p.convert(clip)
+		p.cur, p.frac = 0, 0
 		updates := p.source.Updates
-		for p.source.Playing && p.source.Clip != nil {
+		for p.source.Playing && p.source.Clip != nil && !p.closed() {
 			// There should some kind of lock for this
 			// or we handle changes via Update and update it here
 
-			if p.cur >= len(clip.Data) {
+			if p.cur >= len(data) {
 				if !p.source.Loop {
 					p.source.Playing = false
 					break
@@ -117,9 +185,19 @@ func (p *Processor) run() {
 			if updates != p.source.Updates {
 				break
 			}
-			buf := clip.Data[p.cur:]
-			if len(buf) > 100 {
-				buf = buf[:100]
+			// Hold the source while gorge time is paused.
+			scale := p.audio.timeScale()
+			if atomic.LoadInt32(&p.audio.paused) == 1 || scale <= 0 {
+				time.Sleep(1000 / 60 * time.Millisecond)
+				continue
+			}
+			buf := data[p.cur:]
+			if len(buf) > chunkSize {
+				buf = buf[:chunkSize]
+			}
+			consumed := len(buf)
+			if scale != 1 {
+				buf, consumed = p.resample(data, scale)
 			}
 
 			pos := gm.Vec3{}
@@ -131,15 +209,44 @@ func (p *Processor) run() {
 					MulV4(pos.Vec4(1)).Vec3()
 			}
 			p.positional.Position = pos
-			n, err := p.positional.Write(buf)
-			if err != nil {
+			if _, err := p.positional.Write(buf); err != nil {
 				break
 			}
-			p.cur += n
+			p.cur += consumed
 		}
 	}
 }
 
+// resample picks frames from data starting at p.cur stepping by the time
+// scale, it returns the chunk to be played and the number of bytes consumed
+// from data.
+func (p *Processor) resample(data []byte, scale float32) ([]byte, int) {
+	p.scratch = p.scratch[:0]
+	pos := p.frac
+	for len(p.scratch) < chunkSize {
+		i := p.cur + int(pos)*frameSize
+		if i+frameSize > len(data) {
+			p.frac = 0
+			return p.scratch, len(data) - p.cur
+		}
+		p.scratch = append(p.scratch, data[i:i+frameSize]...)
+		pos += float64(scale)
+	}
+	whole := int(pos)
+	p.frac = pos - float64(whole)
+	return p.scratch, whole * frameSize
+}
+
+// convert returns the clip data in the device format, the result is kept
+// until the clip or its Updates changes.
+func (p *Processor) convert(clip *gorge.AudioClipData) []byte {
+	if p.clip != clip || p.clipUpdates != clip.Updates {
+		p.clip, p.clipUpdates = clip, clip.Updates
+		p.data = proc.Convert(clip, sampleRate, channelCount)
+	}
+	return p.data
+}
+
 // Run the audio processor in the background.
 func (p *Processor) Run() {
 	go p.run()
@@ -1,8 +1,6 @@
 package gorgeui
 
 import (
-	"log"
-
 	"github.com/stdiopt/gorge"
 	"github.com/stdiopt/gorge/systems/resource"
 	"github.com/stdiopt/gorge/text"
@@ -23,7 +21,7 @@ func FromContext(g *gorge.Context) *Context {
 
 	rc := resource.FromContext(g)
 
-	log.Println("Initializing system")
+	g.Log("gorgeui").Info("initializing system")
 	dbg := newDebugLines()
 	dbg.SetQueue(200)
 	dbg.SetCullMask(gorge.CullMaskUIDebug)
@@ -31,8 +29,7 @@ func FromContext(g *gorge.Context) *Context {
 
 	DefaultFont = &text.Font{}
 	if err := rc.Load(DefaultFont, "_gorge/fonts/font.ttf"); err != nil {
-		// what to do here, send error to gorge??!
-		log.Println("error loading font:", err)
+		g.Log("gorgeui").Error("loading font", "err", err)
 		return nil
 	}
 
@@ -51,9 +51,10 @@ func NewRectComponent() *RectComponent {
 // RectTransform implements the RectTransform and returns self
 func (c *RectComponent) RectTransform() *RectComponent { return c }
 
-// SetParent experiment to relativize anchor.
-func (c *RectComponent) SetParent(t gorge.Matrixer) {
+// SetParent experiment to relativize anchor, it never fails.
+func (c *RectComponent) SetParent(t gorge.Matrixer) error {
 	c.parent = t
+	return nil
 }
 
 // Parent returns parent sub transform.
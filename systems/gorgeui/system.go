@@ -14,6 +14,17 @@ import (
 	"github.com/stdiopt/gorge/text"
 )
 
+// EventPriority is the priority used to handle input events, it is higher
+// than the default so the UI can consume pointer events before other handlers.
+const EventPriority = 100
+
+// SystemDef declares the gorgeui system.
+var SystemDef = gorge.SystemDef{
+	Name: "gorgeui",
+	Deps: []string{"resource", "input", "render"},
+	Init: System,
+}
+
 func System(g *gorge.Context) {
 	FromContext(g)
 }
@@ -42,7 +53,7 @@ type system struct {
 }
 
 func (s *system) setupEvents(g *gorge.Context) {
-	event.Handle(g, s.handlePointer)
+	event.HandlePriority(g, EventPriority, s.handlePointer)
 
 	event.Handle(g, func(gorge.EventPreUpdate) {
 		if s.Debug != 0 {
@@ -115,7 +126,7 @@ func (s *system) removeEntity(e Entity) {
 	}
 }
 
-func (s *system) handlePointer(e input.EventPointer) {
+func (s *system) handlePointer(e *input.EventPointer) {
 	s.deltaMouse = e.Pointers[0].Pos.Sub(s.curMouse)
 	s.curMouse = e.Pointers[0].Pos
 
@@ -183,6 +194,15 @@ func (s *system) handlePointer(e input.EventPointer) {
 	} else if s.dragging != nil {
 		triggerOn(s.dragging, EventDrag{pd})
 	}
+
+	// Consume the event if the UI owns the pointer.
+	owned := curDown != nil || s.pointDown != nil || s.dragging != nil
+	if hit != nil && (e.Type == input.MouseDown || e.Type == input.MouseWheel) {
+		owned = true
+	}
+	if owned {
+		e.StopPropagation()
+	}
 }
 
 // NewPick, it might be slower but can overcome masked entities
@@ -153,7 +153,7 @@ func (w *UI) GetEntities() []gorge.Entity {
 }
 
 type parenter interface {
-	SetParent(gorge.Matrixer)
+	SetParent(gorge.Matrixer) error
 	Parent() gorge.Matrixer
 }
 
@@ -1,8 +1,6 @@
 package input
 
 import (
-	"log"
-
 	"github.com/stdiopt/gorge"
 	"github.com/stdiopt/gorge/core/event"
 )
@@ -20,7 +18,7 @@ func FromContext(g *gorge.Context) *Context {
 		return ctx
 	}
 
-	log.Println("Initializing system")
+	g.Log("input").Info("initializing system")
 	s := &Input{
 		keyManager:   keyManager{gorge: g},
 		mouseManager: mouseManager{gorge: g},
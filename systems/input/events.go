@@ -1,6 +1,9 @@
 package input
 
-import "github.com/stdiopt/gorge/math/gm"
+import (
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/math/gm"
+)
 
 // PointerData common
 type PointerData struct {
@@ -8,8 +11,10 @@ type PointerData struct {
 	Pos         gm.Vec2
 }
 
-// EventPointer on canvas
+// EventPointer on canvas, it is triggered as a pointer so handlers can stop
+// its propagation.
 type EventPointer struct {
+	event.Propagation
 	Type     PointerType
 	Button   int // number of button or -1 for touch?
 	Pointers map[int]PointerData
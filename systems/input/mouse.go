@@ -35,7 +35,7 @@ func (m *mouseManager) SetScrollDelta(delta gm.Vec2) {
 	m.deltaScroll = delta
 
 	// Legacy
-	evt := EventPointer{
+	evt := &EventPointer{
 		Type: MouseWheel,
 		Pointers: map[int]PointerData{
 			0: {ScrollDelta: delta, Pos: m.mpos},
@@ -49,7 +49,7 @@ func (m *mouseManager) SetCursorPosition(p gm.Vec2) {
 	m.mpos = p
 
 	// Legacy
-	evt := EventPointer{
+	evt := &EventPointer{
 		Type: MouseMove,
 		Pointers: map[int]PointerData{
 			0: {Pos: m.mpos},
@@ -79,7 +79,7 @@ func (m *mouseManager) SetMouseButtonState(b MouseButton, s ActionState) {
 	case ActionUp:
 		event.Trigger(m.gorge, EventMouseButtonUp{b, pd})
 		// legacy
-		event.Trigger(m.gorge, EventPointer{
+		event.Trigger(m.gorge, &EventPointer{
 			Type: MouseUp,
 			Pointers: map[int]PointerData{
 				0: {Pos: m.mpos},
@@ -87,7 +87,7 @@ func (m *mouseManager) SetMouseButtonState(b MouseButton, s ActionState) {
 		})
 	case ActionDown:
 		event.Trigger(m.gorge, EventMouseButtonDown{b, pd})
-		event.Trigger(m.gorge, EventPointer{
+		event.Trigger(m.gorge, &EventPointer{
 			Type: MouseDown,
 			Pointers: map[int]PointerData{
 				0: {Pos: m.mpos},
@@ -2,6 +2,9 @@ package input
 
 import "github.com/stdiopt/gorge"
 
+// SystemDef declares the input system.
+var SystemDef = gorge.SystemDef{Name: "input", Init: System}
+
 func System(g *gorge.Context) {
 	FromContext(g)
 }
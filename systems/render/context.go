@@ -1,7 +1,7 @@
 package render
 
 import (
-	"log"
+	"runtime"
 	"time"
 
 	"github.com/stdiopt/gorge"
@@ -24,7 +24,7 @@ func FromContext(g *gorge.Context) *Context {
 		return ctx
 	}
 
-	log.Println("initializing system")
+	g.Log("render").Info("initializing system")
 	r := newRenderer(g)
 	ctx := &Context{render: r}
 	gorge.SetContext(g, ctx)
@@ -37,6 +37,10 @@ func FromContext(g *gorge.Context) *Context {
 	event.Handle(g, func(gorge.EventStart) {
 		r.Init()
 	})
+	g.OnDestroy(func() error {
+		r.Destroy()
+		return nil
+	})
 	event.Handle(g, func(e gorge.EventAddEntity) {
 		// Not a switch since the entity can be both a Camera and a Light.
 		if v, ok := e.Entity.(Camera); ok {
@@ -92,7 +96,26 @@ func FromContext(g *gorge.Context) *Context {
 			r.textures.Update(rr)
 		case *gorge.MeshData:
 			r.vbos.Update(rr)
+		case *gorge.ShaderData:
+			r.shaders.invalidate(rr)
+		}
+	})
+	event.Handle(g, func(e gorge.EventResourceRelease) {
+		if sd, ok := e.Resource.(*gorge.ShaderData); ok {
+			r.shaders.invalidate(sd)
+			return
+		}
+		switch v := gorge.GetGPU(e.Resource).(type) {
+		case *Texture:
+			runtime.SetFinalizer(v, nil)
+			r.textures.destroy(v)
+		case *VBO:
+			runtime.SetFinalizer(v, nil)
+			r.vbos.destroy(v)
+		default:
+			return
 		}
+		gorge.SetGPU(e.Resource, nil)
 	})
 	return ctx
 }
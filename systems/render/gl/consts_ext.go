@@ -7,4 +7,25 @@ const (
 
 	// From glfw
 	PROGRAM_POINT_SIZE = 0x8642
+
+	// EXT_texture_compression_s3tc
+	COMPRESSED_RGB_S3TC_DXT1_EXT  = 0x83F0
+	COMPRESSED_RGBA_S3TC_DXT1_EXT = 0x83F1
+	COMPRESSED_RGBA_S3TC_DXT3_EXT = 0x83F2
+	COMPRESSED_RGBA_S3TC_DXT5_EXT = 0x83F3
+
+	// EXT_texture_sRGB, EXT_texture_compression_s3tc_srgb
+	COMPRESSED_SRGB_S3TC_DXT1_EXT       = 0x8C4C
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT = 0x8C4D
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT = 0x8C4E
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT = 0x8C4F
+
+	// EXT_texture_compression_rgtc
+	COMPRESSED_RED_RGTC1_EXT       = 0x8DBB
+	COMPRESSED_RED_GREEN_RGTC2_EXT = 0x8DBD
+
+	// EXT_texture_compression_bptc
+	COMPRESSED_RGBA_BPTC_UNORM_EXT         = 0x8E8C
+	COMPRESSED_SRGB_ALPHA_BPTC_UNORM_EXT   = 0x8E8D
+	COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT_EXT = 0x8E8F
 )
@@ -55,6 +55,18 @@ func (g Wrapper) String() string {
 }
 func (g Wrapper) Impl() string { return "glfw" }
 
+// Extension returns true if the named extension is supported.
+func (g *Wrapper) Extension(name string) bool {
+	var n int32
+	gl.GetIntegerv(NUM_EXTENSIONS, &n)
+	for i := uint32(0); i < uint32(n); i++ {
+		if gl.GoStr(gl.GetStringi(EXTENSIONS, i)) == name {
+			return true
+		}
+	}
+	return false
+}
+
 var _ Context3 = &Wrapper{}
 
 // ActiveTexture sets the active texture unit.
@@ -230,7 +242,15 @@ func (g *Wrapper) CompileShader(s Shader) {
 //
 // http://www.khronos.org/opengles/sdk/docs/man3/html/glCompressedTexImage2D.xhtml
 func (g *Wrapper) CompressedTexImage2D(target Enum, level int, internalformat Enum, width int, height int, border int, data []byte) {
-	panic("not implemented") // TODO: Implement
+	gl.CompressedTexImage2D(
+		target,
+		int32(level),
+		internalformat,
+		int32(width), int32(height),
+		int32(border),
+		int32(len(data)),
+		unsafe.Pointer(&data[0]),
+	)
 }
 
 // CompressedTexSubImage2D writes a subregion of a compressed 2D texture.
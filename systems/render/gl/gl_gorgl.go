@@ -52,6 +52,7 @@ import "C"
 
 import (
 	"fmt"
+	"strings"
 	"unsafe"
 )
 
@@ -92,6 +93,16 @@ func (Wrapper) String() string {
 }
 func (Wrapper) Impl() string { return "gorgl" }
 
+// Extension returns true if the named extension is supported.
+func (glw Wrapper) Extension(name string) bool {
+	for _, e := range strings.Fields(glw.GetString(EXTENSIONS)) {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
 var _ Context3 = &Wrapper{}
 
 // ActiveTexture sets the active texture unit.
@@ -267,7 +278,15 @@ func (glw Wrapper) CompileShader(s Shader) {
 //
 // http://www.khronos.org/opengles/sdk/docs/man3/html/glCompressedTexImage2D.xhtml
 func (glw Wrapper) CompressedTexImage2D(target Enum, level int, internalformat Enum, width int, height int, border int, data []byte) {
-	panic("not implemented") // TODO: Implement
+	C.glCompressedTexImage2D(
+		target,
+		C.GLint(level),
+		internalformat,
+		C.GLsizei(width), C.GLsizei(height),
+		C.GLint(border),
+		C.GLsizei(len(data)),
+		unsafe.Pointer(&data[0]),
+	)
 }
 
 // CompressedTexSubImage2D writes a subregion of a compressed 2D texture.
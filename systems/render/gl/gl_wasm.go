@@ -64,6 +64,12 @@ func (Wrapper) String() string {
 }
 func (Wrapper) Impl() string { return "wasm" }
 
+// Extension returns true if the named extension is supported, the extension
+// is enabled as a side effect.
+func (g Wrapper) Extension(name string) bool {
+	return !g.Call("getExtension", name).IsNull()
+}
+
 var _ Context3 = &Wrapper{}
 
 // GetWebGL Return a js.Value Wrapper gl context
@@ -233,7 +239,13 @@ func (g Wrapper) CompileShader(s Shader) {
 }
 
 func (g Wrapper) CompressedTexImage2D(target Enum, level int, internalformat Enum, width, height, border int, data []byte) {
-	panic("not implemented")
+	jsBuf := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(jsBuf, data)
+	g.Call("compressedTexImage2D",
+		target, level, internalformat,
+		width, height, border,
+		jsBuf,
+	)
 }
 
 func (g Wrapper) CompressedTexSubImage2D(target Enum, level, xoffset, yoffset, width, height int, format Enum, data []byte) {
@@ -764,8 +776,11 @@ func (g Wrapper) TexImage2D(target Enum, level int, internalFormat int, width, h
 		jsBuf = js.Global().Get("Uint8Array").New(len(data))
 		js.CopyBytesToJS(jsBuf, data)
 
-		if ty == FLOAT {
+		switch ty {
+		case FLOAT:
 			jsBuf = js.Global().Get("Float32Array").New(jsBuf.Get("buffer"))
+		case HALF_FLOAT, UNSIGNED_SHORT:
+			jsBuf = js.Global().Get("Uint16Array").New(jsBuf.Get("buffer"))
 		}
 	}
 	g.Call("texImage2D",
@@ -1075,8 +1090,11 @@ func (g *Wrapper) TexImage3D(
 		jsBuf = js.Global().Get("Uint8Array").New(len(data))
 		js.CopyBytesToJS(jsBuf, data)
 
-		if ty == FLOAT {
+		switch ty {
+		case FLOAT:
 			jsBuf = js.Global().Get("Float32Array").New(jsBuf.Get("buffer"))
+		case HALF_FLOAT, UNSIGNED_SHORT:
+			jsBuf = js.Global().Get("Uint16Array").New(jsBuf.Get("buffer"))
 		}
 	}
 	g.Call("texImage3D",
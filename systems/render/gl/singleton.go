@@ -6,6 +6,7 @@ import "log"
 type wrapperi interface {
 	Context3
 	Impl() string
+	Extension(name string) bool
 }
 
 // var glw *Wrapper
@@ -81,6 +82,10 @@ func CompressedTexImage2D(target Enum, level int, internalformat Enum, width, he
 	glw.CompressedTexImage2D(target, level, internalformat, width, height, border, data)
 }
 
+// Extension returns true if the named extension is supported, on webgl the
+// extension is also enabled.
+func Extension(name string) bool { return glw.Extension(name) }
+
 func CompressedTexSubImage2D(target Enum, level, xoffset, yoffset, width, height int, format Enum, data []byte) {
 	glw.CompressedTexSubImage2D(target, level, xoffset, yoffset, width, height, format, data)
 }
@@ -19,19 +19,20 @@ type Bufferer interface {
 }
 
 type bufferManager struct {
-	gorge *gorge.Context
-	count int
+	gorge   *gorge.Context
+	release *releaser
+	count   int
 }
 
-func newBufferManager(g *gorge.Context) *bufferManager {
-	return &bufferManager{gorge: g}
+func newBufferManager(g *gorge.Context, rel *releaser) *bufferManager {
+	return &bufferManager{gorge: g, release: rel}
 }
 
 func (m *bufferManager) New(target, usage gl.Enum) *buffer {
 	b := newBuffer(m, target, usage)
 
 	runtime.SetFinalizer(b, func(b *buffer) {
-		m.gorge.RunInMain(func() {
+		m.gorge.PostFunc(func() {
 			b.Destroy()
 		})
 	})
@@ -51,6 +52,7 @@ type buffer struct {
 	usage  gl.Enum
 
 	size int
+	rid  uint64
 }
 
 func newBuffer(manager *bufferManager, target, usage gl.Enum) *buffer {
@@ -63,7 +65,9 @@ func newBuffer(manager *bufferManager, target, usage gl.Enum) *buffer {
 
 func (b *buffer) Init(size int) {
 	if !gl.IsValid(b.buf) {
-		b.buf = gl.CreateBuffer()
+		buf := gl.CreateBuffer()
+		b.buf = buf
+		b.rid = b.manager.release.track(func() { gl.DeleteBuffer(buf) })
 		b.manager.count++
 	}
 
@@ -78,6 +82,7 @@ func (b *buffer) Init(size int) {
 func (b *buffer) Destroy() {
 	if gl.IsValid(b.buf) {
 		gl.DeleteBuffer(b.buf)
+		b.manager.release.untrack(b.rid)
 		b.manager.count--
 	}
 	runtime.SetFinalizer(b, nil)
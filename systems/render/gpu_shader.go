@@ -49,8 +49,9 @@ func shaderAttrib(s *Shader, k string) (gl.Attrib, bool) {
 }
 
 type shaderManager struct {
-	gorge *gorge.Context
-	vbos  *vboManager
+	gorge   *gorge.Context
+	release *releaser
+	vbos    *vboManager
 	// def    *Shader
 	defRaw *gorge.ShaderData
 
@@ -60,9 +61,10 @@ type shaderManager struct {
 	count int
 }
 
-func newShaderManager(g *gorge.Context, vbos *vboManager) *shaderManager {
+func newShaderManager(g *gorge.Context, rel *releaser, vbos *vboManager) *shaderManager {
 	m := &shaderManager{
 		gorge:         g,
+		release:       rel,
 		vbos:          vbos,
 		hashedShaders: map[*gorge.ShaderData]map[uint]*Shader{},
 		defRaw:        static.Shaders.Default,
@@ -82,7 +84,7 @@ func (m *shaderManager) New(r gorge.ShaderResource) *Shader {
 
 	// This is mostly when calling New only
 	runtime.SetFinalizer(s, func(s *Shader) {
-		m.gorge.RunInMain(func() {
+		m.gorge.PostFunc(func() {
 			s.destroy()
 		})
 	})
@@ -159,6 +161,16 @@ func (m *shaderManager) Setup(sd *gorge.ShaderData, defines []string) *Shader {
 	return s
 }
 
+// invalidate destroys the compiled variants of sd so they are compiled
+// again from the updated source.
+func (m *shaderManager) invalidate(sd *gorge.ShaderData) {
+	for _, s := range m.hashedShaders[sd] {
+		s.stale = true
+		s.destroy()
+	}
+	delete(m.hashedShaders, sd)
+}
+
 type uniform struct {
 	loc     gl.Uniform
 	ty      gl.Enum
@@ -178,10 +190,15 @@ type Shader struct {
 	attribs     map[string]gl.Attrib
 	uniforms    map[string]*uniform
 	samplers    []string
+
+	rid uint64
+	// stale is set when the source changed and the program was destroyed.
+	stale bool
 }
 
 func (s *Shader) destroy() {
 	s.manager.count--
+	s.manager.release.untrack(s.rid)
 	gl.DeleteProgram(s.program)
 }
 
@@ -239,7 +256,9 @@ func (s *Shader) upload(data *gorge.ShaderData, defines []string) {
 
 	if !gl.IsValid(s.program) {
 		s.manager.count++
-		s.program = gl.CreateProgram()
+		program := gl.CreateProgram()
+		s.program = program
+		s.rid = s.manager.release.track(func() { gl.DeleteProgram(program) })
 	}
 
 	gl.AttachShader(s.program, vertShader)
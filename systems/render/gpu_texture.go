@@ -5,20 +5,71 @@ import (
 
 	"github.com/stdiopt/gorge"
 	"github.com/stdiopt/gorge/systems/render/gl"
+	"github.com/stdiopt/gorge/x/texdec"
 )
 
+// compressedExtensions lists the webgl, desktop gl and gles extensions
+// enabling a family of compressed formats, srgb lists the extensions enabling
+// their sRGB variants when these aren't part of the family extension.
+var compressedExtensions = []struct {
+	formats    []gorge.TextureFormat
+	extensions []string
+	srgb       []string
+}{
+	{
+		[]gorge.TextureFormat{gorge.TextureFormatBC1, gorge.TextureFormatBC2, gorge.TextureFormatBC3},
+		[]string{"WEBGL_compressed_texture_s3tc", "GL_EXT_texture_compression_s3tc"},
+		[]string{"WEBGL_compressed_texture_s3tc_srgb", "GL_EXT_texture_sRGB", "GL_EXT_texture_compression_s3tc_srgb"},
+	},
+	{
+		[]gorge.TextureFormat{gorge.TextureFormatBC4, gorge.TextureFormatBC5},
+		[]string{"EXT_texture_compression_rgtc", "GL_ARB_texture_compression_rgtc", "GL_EXT_texture_compression_rgtc"},
+		nil,
+	},
+	{
+		[]gorge.TextureFormat{gorge.TextureFormatBC6H, gorge.TextureFormatBC7},
+		[]string{"EXT_texture_compression_bptc", "GL_ARB_texture_compression_bptc", "GL_EXT_texture_compression_bptc"},
+		nil,
+	},
+	{
+		[]gorge.TextureFormat{gorge.TextureFormatETC2RGB, gorge.TextureFormatETC2RGBA},
+		[]string{"WEBGL_compressed_texture_etc", "GL_ARB_ES3_compatibility"},
+		nil,
+	},
+	{
+		[]gorge.TextureFormat{gorge.TextureFormatASTC4x4, gorge.TextureFormatASTC6x6, gorge.TextureFormatASTC8x8},
+		[]string{"WEBGL_compressed_texture_astc", "GL_KHR_texture_compression_astc_ldr"},
+		nil,
+	},
+}
+
 type textureManager struct {
 	gorge      *gorge.Context
+	release    *releaser
 	texInvalid *Texture
 	texWhite   *Texture
+	// compressed holds the compressed formats supported by the gpu, the
+	// others are decoded on the cpu.
+	compressed map[gorge.TextureFormat]bool
+	// compressedSRGB holds the compressed formats that can also be uploaded
+	// with their sRGB internal format.
+	compressedSRGB map[gorge.TextureFormat]bool
+	// floatLinear is set if 32 bit float textures can be linearly filtered,
+	// floatRender if float textures are color renderable so their mip levels
+	// can be generated.
+	floatLinear bool
+	floatRender bool
 
 	count int
 }
 
-func newTextureManager(g *gorge.Context) *textureManager {
+func newTextureManager(g *gorge.Context, rel *releaser) *textureManager {
 	m := &textureManager{
-		gorge: g,
+		gorge:   g,
+		release: rel,
 	}
+	m.compressed, m.compressedSRGB = compressedFormats()
+	m.floatLinear, m.floatRender = floatSupport()
 
 	m.texInvalid = m.New(&gorge.TextureData{
 		Source: "texturemanager.invalid",
@@ -41,7 +92,7 @@ func (m *textureManager) New(r gorge.TextureResource) *Texture {
 		updates: -1,
 	}
 	runtime.SetFinalizer(t, func(t *Texture) {
-		m.gorge.RunInMain(func() {
+		m.gorge.PostFunc(func() {
 			m.destroy(t)
 		})
 	})
@@ -61,6 +112,68 @@ func (m *textureManager) New(r gorge.TextureResource) *Texture {
 	return t
 }
 
+func compressedFormats() (formats, srgb map[gorge.TextureFormat]bool) {
+	formats = map[gorge.TextureFormat]bool{}
+	srgb = map[gorge.TextureFormat]bool{}
+	for _, c := range compressedExtensions {
+		if !anyExtension(c.extensions) {
+			continue
+		}
+		hasSRGB := c.srgb == nil || anyExtension(c.srgb)
+		for _, f := range c.formats {
+			formats[f] = true
+			srgb[f] = hasSRGB
+		}
+	}
+	// ETC2 is core in gles 3.
+	if gl.Global().Impl() == "gorgl" {
+		for _, f := range []gorge.TextureFormat{gorge.TextureFormatETC2RGB, gorge.TextureFormatETC2RGBA} {
+			formats[f] = true
+			srgb[f] = true
+		}
+	}
+	return formats, srgb
+}
+
+// anyExtension returns true if any of the extensions is supported.
+func anyExtension(exts []string) bool {
+	for _, e := range exts {
+		if gl.Extension(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// floatSupport returns the float texture capabilities, desktop gl supports
+// them all while gles and webgl need extensions.
+func floatSupport() (linear, render bool) {
+	if gl.Global().Impl() == "glfw" {
+		return true, true
+	}
+	linear = gl.Extension("OES_texture_float_linear") || gl.Extension("GL_OES_texture_float_linear")
+	render = gl.Extension("EXT_color_buffer_float") || gl.Extension("GL_EXT_color_buffer_float")
+	return linear, render
+}
+
+// filterable returns false for formats that can only be sampled with nearest
+// filtering.
+func (m *textureManager) filterable(f gorge.TextureFormat) bool {
+	switch f {
+	case gorge.TextureFormatR32F, gorge.TextureFormatRGB32F, gorge.TextureFormatRGBA32F:
+		return m.floatLinear
+	}
+	return true
+}
+
+// canGenerateMipmaps returns true if gl.GenerateMipmap supports the format.
+func (m *textureManager) canGenerateMipmaps(f gorge.TextureFormat) bool {
+	if !f.Float() {
+		return true
+	}
+	return m.floatRender && m.filterable(f)
+}
+
 func (m *textureManager) destroy(t *Texture) {
 	t.destroy()
 }
@@ -113,21 +226,29 @@ type Texture struct {
 	// width, height int
 	// Might not be needed?
 	mipmap bool
+	// nearest forces nearest filtering for formats that aren't filterable.
+	nearest bool
 	// updates indicates updates for dynamic TextureData
 	updates int
+	rid     uint64
 }
 
 func (t *Texture) destroy() {
 	gl.DeleteTexture(t.ID)
+	t.manager.release.untrack(t.rid)
 	t.manager.count--
 }
 
 func (t *Texture) upload(data *gorge.TextureData) {
 	if !gl.IsValid(t.ID) { // should assume valid?
-		t.ID = gl.CreateTexture()
+		id := gl.CreateTexture()
+		t.ID = id
+		t.rid = t.manager.release.track(func() { gl.DeleteTexture(id) })
 		t.manager.count++
 	}
 	t.Type = gl.TEXTURE_2D
+	t.mipmap = false
+	t.nearest = false
 	gl.BindTexture(gl.TEXTURE_2D, t.ID)
 	if data == nil || len(data.PixelData) == 0 {
 		// Upload a pink image
@@ -138,22 +259,122 @@ func (t *Texture) upload(data *gorge.TextureData) {
 		return
 	}
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	t.updates = data.Updates
+
+	levels := append([][]byte{data.PixelData}, data.Mips...)
+	if data.NoMipmaps {
+		levels = levels[:1]
+	}
+	if data.Format.Compressed() {
+		t.uploadCompressed(data, levels)
+		return
+	}
 
 	iformat, format, dt := TextureFormat(data.Format)
+	if data.SRGB {
+		iformat = srgbFormat(iformat)
+	}
+	t.nearest = !t.manager.filterable(data.Format)
 	// Set the rest
-	gl.TexImage2D(gl.TEXTURE_2D, 0,
-		iformat, data.Width, data.Height,
-		format, dt, data.PixelData,
-	)
+	w, h := data.Width, data.Height
+	for i, pix := range levels {
+		gl.TexImage2D(gl.TEXTURE_2D, i,
+			iformat, w, h,
+			format, dt, pix,
+		)
+		w, h = mipSize(w), mipSize(h)
+	}
+	t.mipmaps(data, data.Format, len(levels))
+}
+
+// uploadCompressed uploads the compressed levels if the format is supported
+// or decodes them on the cpu, every compressed format has a cpu fallback.
+// sRGB textures are also decoded if the gpu lacks the sRGB variant.
+func (t *Texture) uploadCompressed(data *gorge.TextureData, levels [][]byte) {
+	native := t.manager.compressed[data.Format] &&
+		(!data.SRGB || t.manager.compressedSRGB[data.Format])
+	if iformat, ok := CompressedTextureFormat(data.Format); ok && native {
+		if data.SRGB {
+			iformat = srgbCompressedFormat(iformat)
+		}
+		w, h := data.Width, data.Height
+		for i, pix := range levels {
+			gl.CompressedTexImage2D(gl.TEXTURE_2D, i, iformat, w, h, 0, pix)
+			w, h = mipSize(w), mipSize(h)
+		}
+		// Compressed levels can't be generated, sample the ones we have.
+		t.mipmap = len(levels) > 1
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, len(levels)-1)
+		return
+	}
+
+	log := t.manager.gorge.Log("render")
+	decoded := make([][]byte, len(levels))
+	w, h := data.Width, data.Height
+	for i, block := range levels {
+		pix, err := texdec.Decode(data.Format, w, h, block)
+		if err != nil {
+			log.Warn("unable to decode texture", "source", data.Source, "format", data.Format, "error", err)
+			gl.TexImage2D(gl.TEXTURE_2D, 0,
+				gl.RGBA, 1, 1,
+				gl.RGBA, gl.UNSIGNED_BYTE, []byte{255, 0, 255, 255},
+			)
+			gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, 0)
+			return
+		}
+		decoded[i] = pix
+		w, h = mipSize(w), mipSize(h)
+	}
+	log.Debug("decoded compressed texture", "source", data.Source, "format", data.Format)
+
+	df := texdec.Format(data.Format)
+	iformat, format, dt := TextureFormat(df)
+	if data.SRGB {
+		iformat = srgbFormat(iformat)
+	}
+	t.nearest = !t.manager.filterable(df)
+	w, h = data.Width, data.Height
+	for i, pix := range decoded {
+		gl.TexImage2D(gl.TEXTURE_2D, i,
+			iformat, w, h,
+			format, dt, pix,
+		)
+		w, h = mipSize(w), mipSize(h)
+	}
+	t.mipmaps(data, df, len(decoded))
+}
 
+// mipmaps limits sampling to the uploaded levels or generates the mip chain
+// if only the base level of format was uploaded.
+func (t *Texture) mipmaps(data *gorge.TextureData, format gorge.TextureFormat, levels int) {
+	if data.NoMipmaps {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, 0)
+		return
+	}
+	if levels > 1 {
+		t.mipmap = true
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, levels-1)
+		return
+	}
+	if !t.manager.canGenerateMipmaps(format) {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, 0)
+		return
+	}
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, 1000)
 	// Might need to recheck this for dynamic textures
 	// Check if power of 2
 	if /*data.Width == data.Height && */ data.Width > 1 {
 		t.mipmap = true
 		gl.GenerateMipmap(gl.TEXTURE_2D)
 	}
+}
 
-	t.updates = data.Updates
+// mipSize returns the size of the next mip level.
+func mipSize(n int) int {
+	if n > 1 {
+		return n / 2
+	}
+	return 1
 }
 
 // We should only update right on Get
@@ -184,17 +405,24 @@ func (t *Texture) bind(gt *gorge.Texture) {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_R, TextureWrap(ww))
 
 	fm := gt.GetFilterMode()
+	if t.nearest {
+		fm = gorge.TextureFilterPoint
+	}
 	switch fm {
 	case gorge.TextureFilterPoint:
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST_MIPMAP_NEAREST)
+		minFilter := gl.NEAREST_MIPMAP_NEAREST
+		if !t.mipmap {
+			minFilter = gl.NEAREST
+		}
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, minFilter)
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
 	// case gorge.TextureFilterLinear:
 	default:
-		// if t.mipmap {
-		// 	t.gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		// } else {
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		//}
+		minFilter := gl.LINEAR_MIPMAP_LINEAR
+		if !t.mipmap {
+			minFilter = gl.LINEAR
+		}
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, minFilter)
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 	}
 	// Not all systems supports this
@@ -37,7 +37,7 @@ func (m *vboManager) New(r gorge.MeshResource) *VBO {
 	m.count++
 
 	runtime.SetFinalizer(v, func(v *VBO) {
-		m.gorge.RunInMain(func() {
+		m.gorge.PostFunc(func() {
 			m.destroy(v)
 		})
 	})
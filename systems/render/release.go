@@ -0,0 +1,28 @@
+package render
+
+// releaser tracks gpu handles so they can be released on shutdown, it keeps
+// only the delete funcs so the owners can still be garbage collected.
+type releaser struct {
+	next  uint64
+	items map[uint64]func()
+}
+
+func (r *releaser) track(fn func()) uint64 {
+	if r.items == nil {
+		r.items = map[uint64]func(){}
+	}
+	r.next++
+	r.items[r.next] = fn
+	return r.next
+}
+
+func (r *releaser) untrack(id uint64) {
+	delete(r.items, id)
+}
+
+func (r *releaser) releaseAll() {
+	for id, fn := range r.items {
+		fn()
+		delete(r.items, id)
+	}
+}
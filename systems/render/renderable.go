@@ -18,8 +18,11 @@ var vaoCount int
 type renderable struct {
 	// VAO per shader attrib hash
 	shaderVAO map[uint]gl.VertexArray
-	shader    *Shader
-	vbo       *VBO
+	// release ids per shader attrib hash
+	vaoRID  map[uint]uint64
+	release *releaser
+	shader  *Shader
+	vbo     *VBO
 	// multiple instances transform
 	tro       *bufutil.Cached[float32]
 	troResize bool
@@ -34,13 +37,25 @@ type renderable struct {
 }
 
 func (r *renderable) clearVAOS() {
-	for k, vao := range r.shaderVAO {
-		gl.DeleteVertexArray(vao)
-		vaoCount--
-		delete(r.shaderVAO, k)
+	for k := range r.shaderVAO {
+		r.deleteVAO(k)
 	}
 }
 
+func (r *renderable) deleteVAO(k uint) {
+	vao, ok := r.shaderVAO[k]
+	if !ok {
+		return
+	}
+	gl.DeleteVertexArray(vao)
+	vaoCount--
+	delete(r.shaderVAO, k)
+	if r.release != nil {
+		r.release.untrack(r.vaoRID[k])
+	}
+	delete(r.vaoRID, k)
+}
+
 func (r *renderable) destroy() {
 	r.clearVAOS()
 	r.tro.Destroy()
@@ -73,12 +88,14 @@ func (rg *RenderableGroup) init() bool {
 
 	rr := &renderable{
 		shaderVAO: map[uint]gl.VertexArray{},
+		vaoRID:    map[uint]uint64{},
+		release:   rg.renderer.release,
 		vbo:       vbo,
 		tro:       tro,
 	}
 
 	runtime.SetFinalizer(rr, func(r *renderable) {
-		rg.renderer.gorge.RunInMain(func() {
+		rg.renderer.gorge.PostFunc(func() {
 			r.destroy()
 		})
 	})
@@ -127,7 +144,11 @@ func (rg *RenderableGroup) Front() Renderable {
 func (rg *RenderableGroup) Update(s *Step) {
 	rr, ok := gorge.GetGPU(rg.renderable).(*renderable)
 	if !ok {
-		rr = &renderable{}
+		rr = &renderable{
+			shaderVAO: map[uint]gl.VertexArray{},
+			vaoRID:    map[uint]uint64{},
+			release:   rg.renderer.release,
+		}
 		gorge.SetGPU(rg.renderable, rr)
 	}
 	// No need to update for this render as it was already updated
@@ -146,13 +167,13 @@ func (rg *RenderableGroup) Update(s *Step) {
 	}
 
 	hash := rr.material.DefinesHash() ^ rg.renderable.Mesh.DefinesHash()
-	if rr.material != rg.renderable.Material || hash != rr.hash {
+	if rr.material != rg.renderable.Material || hash != rr.hash ||
+		(rr.shader != nil && rr.shader.stale) {
 		shdr := rg.renderer.shaders.GetX(rg.renderable)
 		// Rebuild VAO since material or mesh changed and we need to update
 		// VertexAttribs
 		if rr.shader != nil && rr.shader.attribsHash != shdr.attribsHash {
-			gl.DeleteVertexArray(rr.shaderVAO[rr.shader.attribsHash])
-			delete(rr.shaderVAO, rr.shader.attribsHash)
+			rr.deleteVAO(rr.shader.attribsHash)
 		}
 
 		// Recache stuff
@@ -215,7 +236,11 @@ func (rg *RenderableGroup) VAO(shader *Shader) gl.VertexArray {
 	rr, ok := gorge.GetGPU(rg.renderable).(*renderable)
 	if !ok {
 		log.Println("[WARN] Creating empty renderable")
-		rr = &renderable{}
+		rr = &renderable{
+			shaderVAO: map[uint]gl.VertexArray{},
+			vaoRID:    map[uint]uint64{},
+			release:   rg.renderer.release,
+		}
 		gorge.SetGPU(rg.renderable, rr)
 	}
 	if shader == nil {
@@ -229,6 +254,11 @@ func (rg *RenderableGroup) VAO(shader *Shader) gl.VertexArray {
 	vao := gl.CreateVertexArray()
 	rg.bindAttribs(vao, shader)
 	rr.shaderVAO[shader.attribsHash] = vao
+	if rr.release != nil {
+		rr.vaoRID[shader.attribsHash] = rr.release.track(func() {
+			gl.DeleteVertexArray(vao)
+		})
+	}
 
 	return vao
 }
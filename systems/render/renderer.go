@@ -27,6 +27,7 @@ type Render struct {
 	vbos     *vboManager
 	textures *textureManager
 	shaders  *shaderManager
+	release  *releaser
 
 	DrawCalls     int
 	DisableRender bool
@@ -52,10 +53,11 @@ func newRenderer(g *gorge.Context) *Render {
 	gl.Disable(gl.BLEND)
 	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
 
-	bm := newBufferManager(g)
+	rel := &releaser{}
+	bm := newBufferManager(g, rel)
 	vbos := newVBOManager(g, bm)
-	shaders := newShaderManager(g, vbos)
-	textures := newTextureManager(g)
+	shaders := newShaderManager(g, rel, vbos)
+	textures := newTextureManager(g, rel)
 
 	cameraUBO := bufutil.NewNamedOffset(
 		bufutil.NewCached[byte](bm.New(gl.UNIFORM_BUFFER, gl.DYNAMIC_DRAW)),
@@ -83,6 +85,7 @@ func newRenderer(g *gorge.Context) *Render {
 			Props:     map[string]any{},
 			Samplers:  map[string]*Texture{},
 		},
+		release: rel,
 	}
 }
 
@@ -101,6 +104,12 @@ func (r *Render) Render() {
 	r.RenderStage(&r.renderInfo)
 }
 
+// Destroy releases every gpu buffer, vertex array, texture and shader program
+// created by the renderer, it is called when gorge closes.
+func (r *Render) Destroy() {
+	r.release.releaseAll()
+}
+
 // Gorge returns the gorge context.
 func (r *Render) Gorge() *gorge.Context {
 	return r.gorge
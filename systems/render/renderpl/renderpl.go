@@ -20,6 +20,14 @@ func Pipeline(r *render.Context, fns ...PipelineFunc) render.StepFunc {
 	return fns[0](r, Pipeline(r, next...))
 }
 
+// SystemDef declares the default rendering pipeline, it can be replaced by
+// declaring another system named "renderpl".
+var SystemDef = gorge.SystemDef{
+	Name: "renderpl",
+	Deps: []string{"render"},
+	Init: System,
+}
+
 // Default detault rendering pipeline
 func System(g *gorge.Context) {
 	r := render.FromContext(g)
@@ -4,6 +4,9 @@ import (
 	"github.com/stdiopt/gorge"
 )
 
+// SystemDef declares the render system.
+var SystemDef = gorge.SystemDef{Name: "render", Init: System}
+
 func System(g *gorge.Context) {
 	FromContext(g) // lazy init
 }
@@ -93,11 +93,93 @@ func TextureFormat(n gorge.TextureFormat) (int, gl.Enum, gl.Enum) {
 		return gl.RGB, gl.RGB, gl.UNSIGNED_BYTE
 	case gorge.TextureFormatRGBA:
 		return gl.RGBA, gl.RGBA, gl.UNSIGNED_BYTE
+	case gorge.TextureFormatR16F:
+		return gl.R16F, gl.RED, gl.HALF_FLOAT
+	case gorge.TextureFormatRG16F:
+		return gl.RG16F, gl.RG, gl.HALF_FLOAT
+	case gorge.TextureFormatRGBA16F:
+		return gl.RGBA16F, gl.RGBA, gl.HALF_FLOAT
+	case gorge.TextureFormatR32F:
+		return gl.R32F, gl.RED, gl.FLOAT
+	case gorge.TextureFormatRGB32F:
+		return gl.RGB32F, gl.RGB, gl.FLOAT
+	case gorge.TextureFormatRGBA32F:
+		return gl.RGBA32F, gl.RGBA, gl.FLOAT
 	}
 	// default
 	return gl.RGBA, gl.RGBA, gl.UNSIGNED_BYTE
 }
 
+// srgbFormat returns the sRGB variant of an internal format, formats without
+// one are returned as is.
+func srgbFormat(iformat int) int {
+	switch iformat {
+	case gl.RGBA:
+		return gl.SRGB8_ALPHA8
+	case gl.RGB:
+		return gl.SRGB8
+	}
+	return iformat
+}
+
+// srgbCompressedFormat returns the sRGB variant of a compressed internal
+// format, formats without one are returned as is.
+func srgbCompressedFormat(iformat gl.Enum) gl.Enum {
+	switch iformat {
+	case gl.COMPRESSED_RGBA_S3TC_DXT1_EXT:
+		return gl.COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT
+	case gl.COMPRESSED_RGBA_S3TC_DXT3_EXT:
+		return gl.COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT
+	case gl.COMPRESSED_RGBA_S3TC_DXT5_EXT:
+		return gl.COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT
+	case gl.COMPRESSED_RGBA_BPTC_UNORM_EXT:
+		return gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_EXT
+	case gl.COMPRESSED_RGB8_ETC2:
+		return gl.COMPRESSED_SRGB8_ETC2
+	case gl.COMPRESSED_RGBA8_ETC2_EAC:
+		return gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC
+	case gl.COMPRESSED_RGBA_ASTC_4x4:
+		return gl.COMPRESSED_SRGB8_ALPHA8_ASTC_4x4
+	case gl.COMPRESSED_RGBA_ASTC_6x6:
+		return gl.COMPRESSED_SRGB8_ALPHA8_ASTC_6x6
+	case gl.COMPRESSED_RGBA_ASTC_8x8:
+		return gl.COMPRESSED_SRGB8_ALPHA8_ASTC_8x8
+	}
+	return iformat
+}
+
+// CompressedTextureFormat returns the internal format for
+// gl.CompressedTexImage2D.
+func CompressedTextureFormat(n gorge.TextureFormat) (gl.Enum, bool) {
+	switch n {
+	case gorge.TextureFormatBC1:
+		return gl.COMPRESSED_RGBA_S3TC_DXT1_EXT, true
+	case gorge.TextureFormatBC2:
+		return gl.COMPRESSED_RGBA_S3TC_DXT3_EXT, true
+	case gorge.TextureFormatBC3:
+		return gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, true
+	case gorge.TextureFormatBC4:
+		return gl.COMPRESSED_RED_RGTC1_EXT, true
+	case gorge.TextureFormatBC5:
+		return gl.COMPRESSED_RED_GREEN_RGTC2_EXT, true
+	case gorge.TextureFormatBC6H:
+		return gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT_EXT, true
+	case gorge.TextureFormatBC7:
+		return gl.COMPRESSED_RGBA_BPTC_UNORM_EXT, true
+	case gorge.TextureFormatETC2RGB:
+		return gl.COMPRESSED_RGB8_ETC2, true
+	case gorge.TextureFormatETC2RGBA:
+		return gl.COMPRESSED_RGBA8_ETC2_EAC, true
+	case gorge.TextureFormatASTC4x4:
+		return gl.COMPRESSED_RGBA_ASTC_4x4, true
+	case gorge.TextureFormatASTC6x6:
+		return gl.COMPRESSED_RGBA_ASTC_6x6, true
+	case gorge.TextureFormatASTC8x8:
+		return gl.COMPRESSED_RGBA_ASTC_8x8, true
+	}
+	return 0, false
+}
+
 // CullMask returns a bit CullMask if it's 0 it will return the default mask 0xFF
 func CullMask(n gorge.CullMaskFlags) gorge.CullMaskFlags {
 	if n == 0 {
@@ -0,0 +1,366 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+)
+
+// ErrCanceled is the error of a future cancelled before the load completed.
+var ErrCanceled = errors.New("load canceled")
+
+// Future is a handle to a resource loading in the background, its state is
+// updated on the main loop so it can be awaited by a gorge.Task.
+type Future struct {
+	res  *Resource
+	load *asyncLoad
+
+	name     string
+	v        any
+	err      error
+	done     bool
+	canceled bool
+	then     []func(v any, err error)
+}
+
+// Name returns the name of the resource.
+func (f *Future) Name() string { return f.name }
+
+// Value returns the value passed to LoadAsync, it is only filled when the
+// future is done without errors.
+func (f *Future) Value() any { return f.v }
+
+// Done returns true when the load completed, failed or was cancelled.
+func (f *Future) Done() bool { return f.done }
+
+// Err returns the load error, ErrCanceled if the future was cancelled.
+func (f *Future) Err() error { return f.err }
+
+// Then adds a func to be called on the main loop when the future is done, if
+// it is already done fn is called right away.
+func (f *Future) Then(fn func(v any, err error)) *Future {
+	if f.done {
+		fn(f.v, f.err)
+		return f
+	}
+	f.then = append(f.then, fn)
+	return f
+}
+
+// Cancel cancels the future, the load is stopped if no other future shares
+// it, it must be called from the main loop.
+func (f *Future) Cancel() {
+	if f.done {
+		return
+	}
+	f.res.pool.cancel(f)
+	f.complete(ErrCanceled)
+}
+
+// Wait suspends the task until the future is done and returns its error.
+func (f *Future) Wait(t *gorge.Task) error {
+	t.Await(f)
+	return f.err
+}
+
+func (f *Future) complete(err error) {
+	f.done = true
+	f.err = err
+	event.Trigger(f.res.gorge, EventLoadComplete{
+		Name:     f.name,
+		Resource: f.v,
+		Err:      err,
+	})
+	then := f.then
+	f.then = nil
+	for _, fn := range then {
+		fn(f.v, err)
+	}
+}
+
+// LoadAsync loads v in the background and returns a future that completes on
+// the main loop, v must be a pointer and it is only written on the main loop.
+// Concurrent requests with the same type, name and options share a single
+// load.
+func (r *Resource) LoadAsync(v any, name string, opts ...any) *Future {
+	f := &Future{res: r, name: name, v: v}
+	event.Trigger(r.gorge, EventLoadStart{
+		Name:     name,
+		Resource: v,
+	})
+	typ := reflect.TypeOf(v)
+	if typ == nil || typ.Kind() != reflect.Pointer || reflect.ValueOf(v).IsNil() {
+		err := fmt.Errorf("load async: %q needs a non nil pointer, got %T", name, v)
+		r.gorge.PostFunc(func() { f.complete(err) })
+		return f
+	}
+
+	p := r.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if l := p.find(typ, name, opts); l != nil {
+		f.load = l
+		l.futures = append(l.futures, f)
+		return f
+	}
+	l := &asyncLoad{
+		typ:     typ,
+		name:    name,
+		opts:    opts,
+		futures: []*Future{f},
+		cancel:  make(chan struct{}),
+	}
+	f.load = l
+	p.inflight[name] = append(p.inflight[name], l)
+	p.do(l.cancel, func() { r.runAsync(l) })
+	return f
+}
+
+// LoadAll loads each name into the matching value in the background and
+// returns a batch with the futures.
+func (r *Resource) LoadAll(loads map[string]any, opts ...any) *Batch {
+	b := NewBatch()
+	for name, v := range loads {
+		b.Add(r.LoadAsync(v, name, opts...))
+	}
+	return b
+}
+
+// SetMaxLoads sets the maximum number of resources loading in the background
+// at the same time, it defaults to the number of CPUs.
+func (r *Resource) SetMaxLoads(n int) {
+	if n < 1 {
+		n = 1
+	}
+	p := r.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.max = n
+	p.spawn()
+}
+
+// runAsync loads into a new value and copies it to every future that wasn't
+// cancelled on the main loop.
+func (r *Resource) runAsync(l *asyncLoad) {
+	tmp := reflect.New(l.typ.Elem())
+	w := r.watcher()
+	ctx := &Context{resource: r, done: l.cancel}
+	if w != nil {
+		ctx.opened = map[string]struct{}{}
+	}
+	files, err := r.loadContext(ctx, tmp.Interface(), l.name, l.opts...)
+	r.pool.remove(l)
+
+	r.gorge.PostFunc(func() {
+		for _, f := range l.futures {
+			if f.canceled {
+				continue
+			}
+			if err == nil {
+				reflect.ValueOf(f.v).Elem().Set(tmp.Elem())
+				r.watchFiles(w, f.v, l.name, f.v, l.opts, files)
+			}
+			f.complete(err)
+		}
+	})
+}
+
+// asyncLoad is a background load shared by futures.
+type asyncLoad struct {
+	typ     reflect.Type
+	name    string
+	opts    []any
+	futures []*Future
+	// cancel is closed when every future was cancelled.
+	cancel chan struct{}
+}
+
+// loadPool runs background loads on a bounded set of workers and tracks the
+// ones in flight to share them.
+type loadPool struct {
+	mu       sync.Mutex
+	max      int
+	workers  int
+	jobs     []poolJob
+	inflight map[string][]*asyncLoad
+}
+
+// poolJob is a queued load, fn is skipped if cancel is closed before it runs.
+type poolJob struct {
+	cancel <-chan struct{}
+	fn     func()
+}
+
+func newLoadPool() *loadPool {
+	return &loadPool{
+		max:      runtime.NumCPU(),
+		inflight: map[string][]*asyncLoad{},
+	}
+}
+
+// run queues fn to be called by a worker.
+func (p *loadPool) run(fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.do(nil, fn)
+}
+
+// do queues fn to be called by a worker, fn is not called if cancel is
+// closed before that, the caller must hold the lock.
+func (p *loadPool) do(cancel <-chan struct{}, fn func()) {
+	p.jobs = append(p.jobs, poolJob{cancel: cancel, fn: fn})
+	p.spawn()
+}
+
+// spawn starts workers for the queued jobs up to max, the caller must hold
+// the lock.
+func (p *loadPool) spawn() {
+	for p.workers < p.max && p.workers < len(p.jobs) {
+		p.workers++
+		go p.work()
+	}
+}
+
+// work runs queued jobs until the queue is empty or the pool shrinks.
+func (p *loadPool) work() {
+	for {
+		p.mu.Lock()
+		if len(p.jobs) == 0 || p.workers > p.max {
+			p.workers--
+			p.mu.Unlock()
+			return
+		}
+		job := p.jobs[0]
+		p.jobs[0] = poolJob{}
+		p.jobs = p.jobs[1:]
+		p.mu.Unlock()
+
+		select {
+		case <-job.cancel:
+			continue
+		default:
+		}
+		job.fn()
+	}
+}
+
+func (p *loadPool) find(typ reflect.Type, name string, opts []any) *asyncLoad {
+	for _, l := range p.inflight[name] {
+		if l.typ == typ && reflect.DeepEqual(l.opts, opts) {
+			return l
+		}
+	}
+	return nil
+}
+
+func (p *loadPool) remove(l *asyncLoad) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	list := p.inflight[l.name]
+	for i, ll := range list {
+		if ll == l {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(p.inflight, l.name)
+		return
+	}
+	p.inflight[l.name] = list
+}
+
+// cancel marks the future as cancelled and stops the load if every future
+// sharing it was cancelled.
+func (p *loadPool) cancel(f *Future) {
+	p.mu.Lock()
+	f.canceled = true
+	l := f.load
+	if l == nil {
+		p.mu.Unlock()
+		return
+	}
+	for _, ff := range l.futures {
+		if !ff.canceled {
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.mu.Unlock()
+	p.remove(l)
+	close(l.cancel)
+}
+
+// Batch tracks the progress of a group of futures.
+type Batch struct {
+	futures    []*Future
+	completed  int
+	err        error
+	onProgress []func(p float32)
+}
+
+// NewBatch returns a batch tracking the futures.
+func NewBatch(futures ...*Future) *Batch {
+	b := &Batch{}
+	b.Add(futures...)
+	return b
+}
+
+// Add adds futures to the batch.
+func (b *Batch) Add(futures ...*Future) {
+	for _, f := range futures {
+		b.futures = append(b.futures, f)
+		f.Then(func(_ any, err error) {
+			b.completed++
+			if err != nil && b.err == nil {
+				b.err = err
+			}
+			p := b.Progress()
+			for _, fn := range b.onProgress {
+				fn(p)
+			}
+		})
+	}
+}
+
+// Futures returns the futures in the batch.
+func (b *Batch) Futures() []*Future { return b.futures }
+
+// Progress returns the completed ratio from 0 to 1.
+func (b *Batch) Progress() float32 {
+	if len(b.futures) == 0 {
+		return 1
+	}
+	return float32(b.completed) / float32(len(b.futures))
+}
+
+// OnProgress adds a func called on the main loop each time a future of the
+// batch is done.
+func (b *Batch) OnProgress(fn func(p float32)) {
+	b.onProgress = append(b.onProgress, fn)
+}
+
+// Done returns true when every future is done.
+func (b *Batch) Done() bool { return b.completed == len(b.futures) }
+
+// Err returns the first error of the completed futures.
+func (b *Batch) Err() error { return b.err }
+
+// Cancel cancels the futures that aren't done.
+func (b *Batch) Cancel() {
+	for _, f := range b.futures {
+		f.Cancel()
+	}
+}
+
+// Wait suspends the task until every future is done and returns the first
+// error.
+func (b *Batch) Wait(t *gorge.Task) error {
+	t.Await(b)
+	return b.err
+}
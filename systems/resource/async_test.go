@@ -0,0 +1,182 @@
+package resource_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+type asyncData struct {
+	Name string
+}
+
+// asyncLoader counts loads and blocks them until gate is closed.
+var asyncLoader = struct {
+	sync.Mutex
+	gate    chan struct{}
+	loads   map[string]int
+	running int
+	max     int
+}{}
+
+func init() {
+	resource.Register((*asyncData)(nil), ".async", func(res *resource.Context, v any, name string, _ ...any) error {
+		l := &asyncLoader
+		l.Lock()
+		l.loads[name]++
+		l.running++
+		if l.running > l.max {
+			l.max = l.running
+		}
+		gate := l.gate
+		l.Unlock()
+
+		<-gate
+
+		l.Lock()
+		l.running--
+		l.Unlock()
+		if name == "fail.async" {
+			return errors.New("fail")
+		}
+		v.(*asyncData).Name = name
+		return nil
+	})
+}
+
+func resetAsyncLoader() chan struct{} {
+	l := &asyncLoader
+	l.Lock()
+	defer l.Unlock()
+	l.gate = make(chan struct{})
+	l.loads = map[string]int{}
+	l.running, l.max = 0, 0
+	return l.gate
+}
+
+func TestLoadAsync(t *testing.T) {
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	res := resource.FromContext(g)
+
+	wait := func(a gorge.Awaiter) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !a.Done() && time.Now().Before(deadline) {
+			gg.Update(1.0 / 60)
+			time.Sleep(time.Millisecond)
+		}
+		if !a.Done() {
+			t.Fatal("timeout waiting load")
+		}
+	}
+
+	t.Run("dedup", func(t *testing.T) {
+		gate := resetAsyncLoader()
+		a, b := &asyncData{}, &asyncData{}
+		fa := res.LoadAsync(a, "a.async")
+		fb := res.LoadAsync(b, "a.async")
+		fc := res.LoadAsync(&asyncData{}, "fail.async")
+
+		var progress []float32
+		batch := resource.NewBatch(fa, fb, fc)
+		batch.OnProgress(func(p float32) { progress = append(progress, p) })
+
+		var then []string
+		fa.Then(func(v any, err error) { then = append(then, v.(*asyncData).Name) })
+
+		close(gate)
+		if fa.Done() {
+			t.Fatal("want future done on the main loop")
+		}
+		wait(batch)
+
+		if got := asyncLoader.loads["a.async"]; got != 1 {
+			t.Errorf("\nwant: %v\n got: %v\n", 1, got)
+		}
+		if a.Name != "a.async" || b.Name != "a.async" {
+			t.Errorf("want both values loaded, got %q %q", a.Name, b.Name)
+		}
+		if len(then) != 1 || then[0] != "a.async" {
+			t.Errorf("want then called once, got %v", then)
+		}
+		if fc.Err() == nil || batch.Err() != fc.Err() {
+			t.Errorf("want batch error from fail.async, got %v", batch.Err())
+		}
+		want := []float32{1.0 / 3, 2.0 / 3, 1}
+		if len(progress) != len(want) || progress[2] != 1 {
+			t.Errorf("\nwant: %v\n got: %v\n", want, progress)
+		}
+	})
+	t.Run("max loads", func(t *testing.T) {
+		gate := resetAsyncLoader()
+		res.SetMaxLoads(1)
+		defer res.SetMaxLoads(4)
+
+		batch := resource.NewBatch(
+			res.LoadAsync(&asyncData{}, "1.async"),
+			res.LoadAsync(&asyncData{}, "2.async"),
+			res.LoadAsync(&asyncData{}, "3.async"),
+		)
+		close(gate)
+		wait(batch)
+		if asyncLoader.max != 1 {
+			t.Errorf("\nwant: %v\n got: %v\n", 1, asyncLoader.max)
+		}
+	})
+	t.Run("bounded workers", func(t *testing.T) {
+		gate := resetAsyncLoader()
+		res.SetMaxLoads(2)
+		defer res.SetMaxLoads(4)
+
+		before := runtime.NumGoroutine()
+		batch := resource.NewBatch()
+		for i := 0; i < 1000; i++ {
+			batch.Add(res.LoadAsync(&asyncData{}, fmt.Sprintf("%d.async", i)))
+		}
+		if n := runtime.NumGoroutine() - before; n > 2 {
+			t.Errorf("want at most 2 workers, got %d goroutines", n)
+		}
+		close(gate)
+		wait(batch)
+	})
+	t.Run("cancel", func(t *testing.T) {
+		gate := resetAsyncLoader()
+		res.SetMaxLoads(1)
+		defer res.SetMaxLoads(4)
+
+		slow := res.LoadAsync(&asyncData{}, "slow.async")
+		v := &asyncData{}
+		queued := res.LoadAsync(v, "queued.async")
+		queued.Cancel()
+		if !queued.Done() || !errors.Is(queued.Err(), resource.ErrCanceled) {
+			t.Fatalf("want cancelled future, got %v", queued.Err())
+		}
+		close(gate)
+		wait(slow)
+		gg.Update(1.0 / 60)
+
+		asyncLoader.Lock()
+		defer asyncLoader.Unlock()
+		if n := asyncLoader.loads["queued.async"]; n != 0 || v.Name != "" {
+			t.Errorf("want queued load skipped, got %d loads, name %q", n, v.Name)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		f := res.LoadAsync(asyncData{}, "x.async")
+		wait(f)
+		if f.Err() == nil {
+			t.Error("want error for non pointer value")
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package resource
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+)
+
+// cacheEntry is a loaded resource shared by references with the same type,
+// name and options.
+type cacheEntry struct {
+	typ   reflect.Type
+	name  string
+	opts  []any
+	value any
+	refs  map[*cacheRef]struct{}
+	// evicted entries are no longer shared, references are no-ops.
+	evicted bool
+	// loading is set while the first load of the entry runs, futures of the
+	// references sharing it are completed once it finishes.
+	loading bool
+	waiting []*Future
+}
+
+// cacheRef is a single reference to a cache entry.
+type cacheRef struct {
+	entry *cacheEntry
+	owner string
+}
+
+// cache tracks loaded resources by type, name and options, options are
+// compared with reflect.DeepEqual so func options are never shared.
+type cache struct {
+	mu      sync.Mutex
+	entries map[string][]*cacheEntry
+}
+
+// get returns a new reference to the entry matching the key.
+func (c *cache) get(typ reflect.Type, name string, opts []any, owner string) (*cacheRef, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.lookup(typ, name, opts); e != nil {
+		return e.ref(owner), true
+	}
+	return nil, false
+}
+
+// add adds an entry with value and returns a reference to it, if an entry
+// with the same key was added meanwhile the reference is to that entry.
+func (c *cache) add(typ reflect.Type, name string, opts []any, owner string, value any) *cacheRef {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.lookup(typ, name, opts); e != nil {
+		return e.ref(owner)
+	}
+	return c.insert(typ, name, opts, value).ref(owner)
+}
+
+// lookup returns the entry matching the key, the caller must hold the lock.
+func (c *cache) lookup(typ reflect.Type, name string, opts []any) *cacheEntry {
+	for _, e := range c.entries[name] {
+		if e.typ == typ && reflect.DeepEqual(e.opts, opts) {
+			return e
+		}
+	}
+	return nil
+}
+
+// insert adds a new entry, the caller must hold the lock.
+func (c *cache) insert(typ reflect.Type, name string, opts []any, value any) *cacheEntry {
+	if c.entries == nil {
+		c.entries = map[string][]*cacheEntry{}
+	}
+	e := &cacheEntry{
+		typ:   typ,
+		name:  name,
+		opts:  opts,
+		value: value,
+		refs:  map[*cacheRef]struct{}{},
+	}
+	c.entries[name] = append(c.entries[name], e)
+	return e
+}
+
+// loaded finishes the entry load and returns the futures waiting for it and
+// whether the entry was evicted, the entry is evicted if the load failed.
+func (c *cache) loaded(e *cacheEntry, failed bool) ([]*Future, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.loading = false
+	waiting := e.waiting
+	e.waiting = nil
+	if failed && !e.evicted {
+		c.evict(e)
+	}
+	return waiting, e.evicted
+}
+
+// unref removes a reference and returns the entry if it was the last one,
+// the entry is evicted.
+func (c *cache) unref(ref *cacheRef) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := ref.entry
+	if _, ok := e.refs[ref]; !ok {
+		return nil
+	}
+	delete(e.refs, ref)
+	if len(e.refs) > 0 || e.evicted {
+		return nil
+	}
+	c.evict(e)
+	return e
+}
+
+// remove evicts every entry with name and returns them.
+func (c *cache) remove(name string) []*cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := c.entries[name]
+	for _, e := range list {
+		e.evicted = true
+	}
+	delete(c.entries, name)
+	return list
+}
+
+// evict removes the entry from the cache, the caller must hold the lock.
+func (c *cache) evict(e *cacheEntry) {
+	e.evicted = true
+	list := c.entries[e.name]
+	for i, ee := range list {
+		if ee == e {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(c.entries, e.name)
+		return
+	}
+	c.entries[e.name] = list
+}
+
+// find returns the name of the entry holding value.
+func (c *cache) find(value any) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, list := range c.entries {
+		for _, e := range list {
+			if e.value == value {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (e *cacheEntry) ref(owner string) *cacheRef {
+	r := &cacheRef{entry: e, owner: owner}
+	if !e.evicted {
+		e.refs[r] = struct{}{}
+	}
+	return r
+}
+
+// CacheEntry describes a cached resource.
+type CacheEntry struct {
+	Name  string
+	Type  reflect.Type
+	Opts  []any
+	Value any
+	// Owners are the call sites holding a reference, a site can be listed
+	// more than once.
+	Owners []string
+}
+
+// Refs returns the number of references to the entry.
+func (e CacheEntry) Refs() int { return len(e.Owners) }
+
+// Cached returns the resources in the cache sorted by name and type.
+func (r *Resource) Cached() []CacheEntry {
+	c := &r.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ret []CacheEntry
+	for _, list := range c.entries {
+		for _, e := range list {
+			owners := make([]string, 0, len(e.refs))
+			for ref := range e.refs {
+				owners = append(owners, ref.owner)
+			}
+			sort.Strings(owners)
+			ret = append(ret, CacheEntry{
+				Name:   e.name,
+				Type:   e.typ,
+				Opts:   e.opts,
+				Value:  e.value,
+				Owners: owners,
+			})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Name != ret[j].Name {
+			return ret[i].Name < ret[j].Name
+		}
+		return ret[i].Type.String() < ret[j].Type.String()
+	})
+	return ret
+}
+
+// Handle is a reference to a cached resource acquired with Acquire, it must
+// be released with Release, if it is garbage collected first the reference is
+// released on the main loop.
+type Handle[T any] struct {
+	res   *Resource
+	ref   *cacheRef
+	name  string
+	value *T
+}
+
+// Acquire returns a handle to the resource of type T loaded with name and
+// options, the resource is loaded on the first acquire and shared until every
+// handle is released.
+func Acquire[T any](res *Context, name string, opts ...any) (*Handle[T], error) {
+	r := res.resource
+	ref, err := r.acquire(reflect.TypeOf((*T)(nil)), name, opts, caller(2))
+	if err != nil {
+		return nil, err
+	}
+	v, ok := ref.entry.value.(*T)
+	if !ok {
+		r.cache.unref(ref)
+		return nil, fmt.Errorf("acquire: %q is cached as %T", name, ref.entry.value)
+	}
+	h := &Handle[T]{
+		res:   r,
+		ref:   ref,
+		name:  name,
+		value: v,
+	}
+	runtime.SetFinalizer(h, func(h *Handle[T]) {
+		r.gorge.PostFunc(h.Release)
+	})
+	return h, nil
+}
+
+// Value returns the shared resource.
+func (h *Handle[T]) Value() *T { return h.value }
+
+// Name returns the name the resource was loaded with.
+func (h *Handle[T]) Name() string { return h.name }
+
+// Release releases the handle reference, when no references are left the
+// resource is removed from the cache and gorge.EventResourceRelease is
+// triggered, it must be called on the main loop.
+func (h *Handle[T]) Release() {
+	if h.ref == nil {
+		return
+	}
+	runtime.SetFinalizer(h, nil)
+	h.res.unref(h.ref)
+	h.ref = nil
+}
+
+// refLoad returns a reference to a cached value or adds value and returns
+// false, in that case the caller must load it and finish with loadDone. f is
+// completed on the main loop when the value is loaded or the load fails.
+func (r *Resource) refLoad(typ reflect.Type, name string, opts []any, owner string, value any, f *Future) (*cacheRef, bool) {
+	c := &r.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.lookup(typ, name, opts); e != nil {
+		if e.loading {
+			e.waiting = append(e.waiting, f)
+		} else {
+			r.gorge.PostFunc(func() { f.complete(nil) })
+		}
+		return e.ref(owner), true
+	}
+	e := c.insert(typ, name, opts, value)
+	e.loading = true
+	e.waiting = []*Future{f}
+	return e.ref(owner), false
+}
+
+// loadDone completes the futures waiting for the entry of ref, the entry is
+// evicted if err is not nil, it must be called on the main loop. Entries
+// released while loading stop the watch started by the load.
+func (r *Resource) loadDone(ref *cacheRef, err error) {
+	waiting, evicted := r.cache.loaded(ref.entry, err != nil)
+	if evicted {
+		r.unwatch(ref.entry.value)
+	}
+	for _, f := range waiting {
+		f.complete(err)
+	}
+}
+
+// acquire returns a reference to a cached value of typ, loading it with Load
+// if it is not cached, load events are triggered in both cases so trackers
+// know the resource is in use.
+func (r *Resource) acquire(typ reflect.Type, name string, opts []any, owner string) (*cacheRef, error) {
+	if ref, ok := r.cache.get(typ, name, opts, owner); ok {
+		event.Trigger(r.gorge, EventLoadStart{Name: name, Resource: ref.entry.value})
+		event.Trigger(r.gorge, EventLoadComplete{Name: name, Resource: ref.entry.value})
+		return ref, nil
+	}
+	v := reflect.New(typ.Elem()).Interface()
+	if err := r.Load(v, name, opts...); err != nil {
+		return nil, err
+	}
+	return r.cache.add(typ, name, opts, owner, v), nil
+}
+
+// refGC releases ref on the main loop once v is garbage collected.
+func (r *Resource) refGC(v any, ref *cacheRef) {
+	runtime.SetFinalizer(v, func(any) {
+		r.gorge.PostFunc(func() { r.unref(ref) })
+	})
+}
+
+// unref releases a reference triggering the release of the entry value if it
+// was the last one.
+func (r *Resource) unref(ref *cacheRef) {
+	e := r.cache.unref(ref)
+	if e == nil {
+		return
+	}
+	r.gorge.Log("resource").Debug("releasing", "name", e.name, "type", e.typ)
+	r.unwatch(e.value)
+	event.Trigger(r.gorge, gorge.EventResourceRelease{Resource: e.value})
+}
+
+// caller returns the file:line of the caller skip frames above.
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
@@ -0,0 +1,169 @@
+package resource_test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+type cacheData struct {
+	Name string
+	Opts []any
+}
+
+var cacheLoads = map[string]int{}
+
+func init() {
+	resource.Register((*cacheData)(nil), ".cache", func(res *resource.Context, v any, name string, opts ...any) error {
+		cacheLoads[name]++
+		*v.(*cacheData) = cacheData{Name: name, Opts: opts}
+		return nil
+	})
+}
+
+func TestAcquire(t *testing.T) {
+	cacheLoads = map[string]int{}
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	res := resource.FromContext(g)
+
+	var released []any
+	event.Handle(g, func(e gorge.EventResourceRelease) {
+		released = append(released, e.Resource)
+	})
+
+	h1, err := resource.Acquire[cacheData](res, "a.cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := resource.Acquire[cacheData](res, "a.cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h3, err := resource.Acquire[cacheData](res, "a.cache", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1.Value() != h2.Value() || h1.Value() == h3.Value() {
+		t.Error("want values shared by name and options")
+	}
+	if cacheLoads["a.cache"] != 2 {
+		t.Errorf("\nwant: %v\n got: %v\n", 2, cacheLoads["a.cache"])
+	}
+
+	cached := res.Cached()
+	if len(cached) != 2 || cached[0].Refs()+cached[1].Refs() != 3 {
+		t.Fatalf("want 2 entries with 3 refs, got %v", cached)
+	}
+	for _, o := range cached[0].Owners {
+		if !strings.HasPrefix(o, "cache_test.go:") {
+			t.Errorf("want owner in cache_test.go, got %q", o)
+		}
+	}
+	if name, ok := res.Path(h1.Value()); !ok || name != "a.cache" {
+		t.Errorf("\nwant: %v\n got: %v\n", "a.cache", name)
+	}
+
+	h1.Release()
+	h1.Release()
+	if len(released) != 0 {
+		t.Fatal("want value kept while referenced")
+	}
+	h2.Release()
+	if len(released) != 1 || released[0] != h2.Value() {
+		t.Fatalf("want value released, got %v", released)
+	}
+
+	// Force release by name.
+	res.Release("a.cache")
+	if len(released) != 2 || released[1] != h3.Value() || len(res.Cached()) != 0 {
+		t.Fatalf("want release by name, got %v", released)
+	}
+	h3.Release()
+	if len(released) != 2 {
+		t.Error("want no release after evicted")
+	}
+
+	// Garbage collected handles.
+	func() {
+		if _, err := resource.Acquire[cacheData](res, "gc.cache"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for i := 0; i < 100 && len(released) == 2; i++ {
+		runtime.GC()
+		gg.Update(1.0 / 60)
+	}
+	if len(released) != 3 || len(res.Cached()) != 0 {
+		t.Errorf("want gc release, got %v", released)
+	}
+}
+
+func TestTextureShared(t *testing.T) {
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	res := resource.FromContext(g)
+	res.AddFS("", fstest.MapFS{"a.png": pngFile(t, 2, 2)})
+
+	var order []string
+	errs := map[any]error{}
+	event.Handle(g, func(e resource.EventLoadStart) {
+		order = append(order, "start "+e.Name)
+	})
+	event.Handle(g, func(e resource.EventLoadComplete) {
+		order = append(order, "complete "+e.Name)
+		errs[e.Resource] = e.Err
+	})
+	g.HandleError(func(error) {})
+
+	t1 := res.Texture("a.png")
+	t2 := res.Texture("a.png")
+	m1 := res.Texture("missing.png")
+	m2 := res.Texture("missing.png")
+	for i := 0; i < 1000 && len(errs) < 4; i++ {
+		time.Sleep(time.Millisecond)
+		gg.Update(1.0 / 60)
+	}
+	if len(errs) != 4 {
+		t.Fatalf("want 4 completed loads, got %v", order)
+	}
+	if errs[t1] != nil || errs[t2] != nil {
+		t.Errorf("want shared texture loaded, got %v, %v", errs[t1], errs[t2])
+	}
+	if errs[m1] == nil || errs[m2] == nil {
+		t.Errorf("want failed load reported to every sharer, got %v, %v", errs[m1], errs[m2])
+	}
+	if t1.Resource().(*gorge.TextureRef).GPU != t2.Resource().(*gorge.TextureRef).GPU {
+		t.Error("want gpu ref shared")
+	}
+	for i, s := range order[:4] {
+		if !strings.HasPrefix(s, "start ") {
+			t.Errorf("want start events first, got %v at %d", order, i)
+		}
+	}
+
+	// Cached after the load completes right away on the next frame.
+	t3 := res.Texture("a.png")
+	delete(errs, t3)
+	gg.Update(1.0 / 60)
+	if err, ok := errs[t3]; !ok || err != nil {
+		t.Errorf("want cached texture completed, got %v", order)
+	}
+	if len(res.Cached()) != 1 {
+		t.Errorf("want failed load evicted, got %v", res.Cached())
+	}
+}
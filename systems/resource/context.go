@@ -2,8 +2,9 @@ package resource
 
 import (
 	"fmt"
+	"io"
 	"io/fs"
-	"log"
+	"reflect"
 
 	"github.com/stdiopt/gorge"
 	"github.com/stdiopt/gorge/core/event"
@@ -18,6 +19,62 @@ type resource = Resource
 // Context to be used in gorge systems
 type Context struct {
 	*resource
+
+	// opened records the files opened by a loader to be watched.
+	opened map[string]struct{}
+	// done is closed when the async load using this context is cancelled.
+	done <-chan struct{}
+}
+
+// Open opens a resource based on the configured sourcer.
+func (r *Context) Open(name string) (io.ReadCloser, error) {
+	if err := r.err(); err != nil {
+		return nil, err
+	}
+	r.record(name)
+	return r.resource.Open(name)
+}
+
+// LoadBytes returns the asset as bytes.
+func (r *Context) LoadBytes(name string) ([]byte, error) {
+	if err := r.err(); err != nil {
+		return nil, err
+	}
+	r.record(name)
+	return r.resource.LoadBytes(name)
+}
+
+// LoadString returns the asset as a string.
+func (r *Context) LoadString(name string) (string, error) {
+	if err := r.err(); err != nil {
+		return "", err
+	}
+	r.record(name)
+	return r.resource.LoadString(name)
+}
+
+// Canceled returns true if the async load using this context was cancelled,
+// long running loaders can check it to stop early.
+func (r *Context) Canceled() bool {
+	return r.err() != nil
+}
+
+func (r *Context) err() error {
+	if r.done == nil {
+		return nil
+	}
+	select {
+	case <-r.done:
+		return ErrCanceled
+	default:
+		return nil
+	}
+}
+
+func (r *Context) record(name string) {
+	if r.opened != nil {
+		r.opened[name] = struct{}{}
+	}
 }
 
 // FromContext returns a Context from a gorge Context
@@ -25,7 +82,7 @@ func FromContext(g *gorge.Context) *Context {
 	if ctx, ok := gorge.GetContext[*Context](g); ok {
 		return ctx
 	}
-	log.Println("Initializing system")
+	g.Log("resource").Info("initializing system")
 
 	lfs := layerfs.FS{}
 	s, err := fs.Sub(static.Assets, "src")
@@ -34,56 +91,52 @@ func FromContext(g *gorge.Context) *Context {
 	}
 	lfs.Mount(gorgeStatic, s)
 
-	m := &Resource{gorge: g, fs: lfs}
+	m := &Resource{gorge: g, fs: lfs, pool: newLoadPool()}
 
 	return gorge.SetContext(g, &Context{resource: m})
 }
 
-// Texture helper that returns a texture with a resourcer ref
+// Texture helper that returns a texture with a resourcer ref, opts accepts
+// TextureOptions.
 func (r *Context) Texture(name string, opts ...any) *gorge.Texture {
 	ref := &gorge.TextureRef{GPU: &gorge.GPU{}}
 	tex := gorge.NewTexture(ref)
+	// Invalid options are reported by the load.
+	if opt, err := textureOptions(opts); err == nil {
+		opt.setup(tex)
+	}
 
-	// Bind the resource, if exists we reuse the resource ref
-	counter, ok := r.track(name, ref, ref.GPU)
+	// Posted before binding so the completion can't be flushed before it.
+	gorge.Post(r.gorge, EventLoadStart{
+		Name:     name,
+		Resource: tex,
+	})
+	// Bind the resource, if exists we reuse the resource gpu ref and complete
+	// with its load.
+	f := &Future{res: r.resource, name: name, v: tex}
+	cref, ok := r.refLoad(textureRefType, name, opts, caller(2), ref.GPU, f)
+	ref.GPU = cref.entry.value.(*gorge.GPU)
+	r.refGC(ref, cref)
 	if ok {
-		ref.GPU = counter.ref.(*gorge.GPU)
 		return tex
 	}
 
 	// Load into a new temporary resourcer and copy the gpu reference
-	go func() {
-		r.gorge.RunInMain(func() {
-			event.Trigger(r.gorge, EventLoadStart{
-				Name:     name,
-				Resource: tex,
-			})
-		})
+	r.pool.run(func() {
 		tmp := &gorge.TextureData{}
-		if err := r.load(tmp, name, opts...); err != nil {
-			r.gorge.RunInMain(func() {
-				event.Trigger(r.gorge, EventLoadComplete{
-					Name:     name,
-					Resource: tex,
-					Err:      err,
+		err := r.loadWatch(cref.entry.value, tmp, name, opts...)
+		r.gorge.PostFunc(func() {
+			if err != nil {
+				r.Error(err)
+			} else {
+				event.Trigger(r.gorge, gorge.EventResourceUpdate{
+					Resource: tmp,
 				})
-			})
-
-			r.Error(err)
-			return
-		}
-		r.gorge.RunInMain(func() {
-			event.Trigger(r.gorge, gorge.EventResourceUpdate{
-				Resource: tmp,
-			})
-			event.Trigger(r.gorge, EventLoadComplete{
-				Name:     name,
-				Resource: tex,
-			})
-			gorge.SetGPU(counter.ref, gorge.GetGPU(tmp))
-			// gorge.ResourceCopyRef(tex.Resourcer, res)
+				gorge.SetGPU(ref.GPU, gorge.GetGPU(tmp))
+			}
+			r.loadDone(cref, err)
 		})
-	}()
+	})
 	return tex
 }
 
@@ -92,56 +145,70 @@ func (r *Context) Mesh(name string, opts ...any) *gorge.Mesh {
 	ref := &gorge.MeshRef{GPU: &gorge.GPU{}}
 	mesh := gorge.NewMesh(ref)
 
-	// Bind the resource, if exists we reuse the resource ref
-	counter, ok := r.track(name, ref, ref.GPU)
+	// Posted before binding so the completion can't be flushed before it.
+	gorge.Post(r.gorge, EventLoadStart{
+		Name:     name,
+		Resource: mesh,
+	})
+	// Bind the resource, if exists we reuse the resource gpu ref and complete
+	// with its load.
+	f := &Future{res: r.resource, name: name, v: mesh}
+	cref, ok := r.refLoad(meshRefType, name, opts, caller(2), ref.GPU, f)
+	ref.GPU = cref.entry.value.(*gorge.GPU)
+	r.refGC(ref, cref)
 	if ok {
-		ref.GPU = counter.ref.(*gorge.GPU)
 		return mesh
 	}
 
 	// Load into a new temporary resourcer and copy the gpu reference
-	go func() {
-		r.gorge.RunInMain(func() {
-			event.Trigger(r.gorge, EventLoadStart{
-				Name:     name,
-				Resource: mesh,
-			})
-		})
+	r.pool.run(func() {
 		tmp := &gorge.MeshData{}
-		if err := r.load(tmp, name, opts...); err != nil {
-			r.gorge.RunInMain(func() {
-				event.Trigger(r.gorge, EventLoadComplete{
-					Name:     name,
-					Resource: mesh,
-					Err:      err,
+		err := r.loadWatch(cref.entry.value, tmp, name, opts...)
+		r.gorge.PostFunc(func() {
+			if err != nil {
+				r.Error(err)
+			} else {
+				event.Trigger(r.gorge, gorge.EventResourceUpdate{
+					Resource: tmp,
 				})
-			})
-
-			r.Error(err)
-			return
-		}
-		r.gorge.RunInMain(func() {
-			event.Trigger(r.gorge, gorge.EventResourceUpdate{
-				Resource: tmp,
-			})
-			event.Trigger(r.gorge, EventLoadComplete{
-				Name:     name,
-				Resource: mesh,
-			})
-			// gorge.SetGPU(mesh.Resourcer.Resource(), gorge.GetGPU(res))
-			gorge.SetGPU(counter.ref, gorge.GetGPU(tmp))
+				gorge.SetGPU(ref.GPU, gorge.GetGPU(tmp))
+			}
+			r.loadDone(cref, err)
 		})
-	}()
+	})
 	return mesh
 }
 
-// Material loads everytime right away
+// Material returns a material with the shader loaded with name, the shader
+// data is cached and shared by materials with the same name.
 func (r *Context) Material(name string, opts ...any) *gorge.Material {
-	var data gorge.ShaderData
-	if err := r.Load(&data, name, opts...); err != nil {
+	ref, err := r.acquire(shaderDataType, name, opts, caller(2))
+	if err != nil {
 		r.Error(err)
 		return gorge.NewMaterial()
 	}
+	mat := gorge.NewShaderMaterial(ref.entry.value.(*gorge.ShaderData))
+	r.refGC(mat, ref)
+	return mat
+}
 
-	return gorge.NewShaderMaterial(&data)
+// AudioClip returns an audio clip with the clip data loaded with name, the
+// data is cached and shared by clips with the same name.
+func (r *Context) AudioClip(name string, opts ...any) *gorge.AudioClip {
+	ref, err := r.acquire(audioClipDataType, name, opts, caller(2))
+	if err != nil {
+		r.Error(err)
+		return gorge.NewAudioClip(&gorge.AudioClipData{})
+	}
+	clip := gorge.NewAudioClip(ref.entry.value.(*gorge.AudioClipData))
+	r.refGC(clip, ref)
+	return clip
 }
+
+// Cache keys for the Context helpers.
+var (
+	textureRefType    = reflect.TypeOf((*gorge.TextureRef)(nil))
+	meshRefType       = reflect.TypeOf((*gorge.MeshRef)(nil))
+	shaderDataType    = reflect.TypeOf((*gorge.ShaderData)(nil))
+	audioClipDataType = reflect.TypeOf((*gorge.AudioClipData)(nil))
+)
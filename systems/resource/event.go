@@ -1,5 +1,7 @@
 package resource
 
+import "github.com/stdiopt/gorge"
+
 // EventLoadStart is trigger in gorge when a resource starts loading.
 type EventLoadStart struct {
 	Name     string
@@ -18,3 +20,19 @@ type EventLoadComplete struct {
 type EventOpen struct {
 	Name string
 }
+
+// WaitLoad suspends the task until the resource returned by a Context helper
+// like Texture or Mesh finishes loading, it must be called right after the
+// helper on the same frame.
+func WaitLoad(t *gorge.Task, res any) error {
+	e := gorge.WaitEvent(t, func(e EventLoadComplete) bool {
+		return e.Resource == res
+	})
+	return e.Err
+}
+
+// EventReload is triggered when a watched resource was reloaded in place.
+type EventReload struct {
+	Name     string
+	Resource any
+}
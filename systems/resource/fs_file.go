@@ -17,3 +17,8 @@ func (l FileFS) Open(p string) (fs.File, error) {
 	path := filepath.Join(l.BasePath, p)
 	return os.Open(path)
 }
+
+// Stat returns the file info of the file prefixed by BasePath.
+func (l FileFS) Stat(p string) (fs.FileInfo, error) {
+	return os.Stat(filepath.Join(l.BasePath, p))
+}
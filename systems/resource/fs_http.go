@@ -1,35 +1,506 @@
 package resource
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
-// HTTPFS asset that loads based on a starting url
+// HTTPFS asset that loads based on a starting url, the zero value does plain
+// requests with http.DefaultClient.
 type HTTPFS struct {
 	BaseURL string
+	// Client used for requests, defaults to http.DefaultClient.
+	Client *http.Client
+	// Header is added to every request.
+	Header http.Header
+	// Timeout for each request attempt, including reading the body.
+	Timeout time.Duration
+	// Retries on network errors, 5xx and 429 responses.
+	Retries int
+	// Backoff is the delay before the first retry, doubled on each retry,
+	// defaults to 100ms.
+	Backoff time.Duration
+	// Cache stores responses revalidated with ETag or Last-Modified, files
+	// are read fully in memory when set.
+	Cache HTTPCache
+	// Manifest is the path of a JSON manifest written by WriteHTTPManifest,
+	// if set it is used by ReadDir and by Stat for directories.
+	Manifest string
 }
 
 // Open the asset
-func (l HTTPFS) Open(p string) (fs.File, error) {
-	url := l.BaseURL + p
-	res, err := http.Get(url)
+func (l HTTPFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return l.openDir(name)
+	}
+
+	u := l.url(name)
+	var cached *HTTPCacheEntry
+	if l.Cache != nil {
+		cached, _ = l.Cache.Get(u)
+	}
+	res, cancel, err := l.do(http.MethodGet, u, func(req *http.Request) {
+		if cached == nil {
+			return
+		}
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if cached != nil && res.StatusCode == http.StatusNotModified {
+		res.Body.Close() // nolint: errcheck
+		cancel()
+		return cached.file(name), nil
+	}
+	if err := statusErr(res); err != nil {
+		res.Body.Close() // nolint: errcheck
+		cancel()
+		if errors.Is(err, fs.ErrNotExist) && l.Manifest != "" && l.isDir(name) {
+			return l.openDir(name)
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	info := responseInfo(name, res)
+	if l.Cache == nil {
+		return &httpFile{
+			ReadCloser: &cancelBody{res.Body, cancel},
+			info:       info,
+		}, nil
+	}
+
+	defer cancel()
+	defer res.Body.Close() // nolint: errcheck
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	e := &HTTPCacheEntry{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Data:         data,
+	}
+	if e.ETag != "" || e.LastModified != "" {
+		if err := l.Cache.Put(u, e); err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+	return e.file(name), nil
+}
+
+// Stat returns the file info from a HEAD request, directories are only found
+// through the manifest.
+func (l HTTPFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return dirHTTPInfo(name), nil
+	}
+	res, cancel, err := l.do(http.MethodHead, l.url(name), nil)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	defer cancel()
+	res.Body.Close() // nolint: errcheck
+	if err := statusErr(res); err != nil {
+		if errors.Is(err, fs.ErrNotExist) && l.Manifest != "" && l.isDir(name) {
+			return dirHTTPInfo(name), nil
+		}
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return responseInfo(name, res), nil
+}
+
+// ReadDir reads the directory from the manifest.
+func (l HTTPFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	m, err := l.manifest()
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	dirs := map[string]struct{}{}
+	var ret []fs.DirEntry
+	for _, f := range m.Files {
+		dir, rest := name+"/", f.Name
+		if name == "." {
+			dir = ""
+		}
+		if !strings.HasPrefix(rest, dir) {
+			continue
+		}
+		rest = rest[len(dir):]
+		if i := strings.Index(rest, "/"); i != -1 {
+			sub := rest[:i]
+			if _, ok := dirs[sub]; !ok {
+				dirs[sub] = struct{}{}
+				ret = append(ret, fs.FileInfoToDirEntry(dirHTTPInfo(sub)))
+			}
+			continue
+		}
+		ret = append(ret, fs.FileInfoToDirEntry(httpInfo{
+			name:    rest,
+			size:    f.Size,
+			modTime: f.ModTime,
+		}))
+	}
+	if ret == nil && name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name() < ret[j].Name() })
+	return ret, nil
+}
+
+func (l HTTPFS) openDir(name string) (fs.File, error) {
+	entries, err := l.ReadDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &httpDir{info: dirHTTPInfo(name), entries: entries}, nil
+}
+
+func (l HTTPFS) isDir(name string) bool {
+	m, err := l.manifest()
+	if err != nil {
+		return false
+	}
+	for _, f := range m.Files {
+		if strings.HasPrefix(f.Name, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (l HTTPFS) manifest() (*httpManifest, error) {
+	if l.Manifest == "" {
+		return nil, errors.New("no manifest")
+	}
+	f, err := l.Open(l.Manifest)
 	if err != nil {
 		return nil, err
 	}
-	if res.StatusCode >= 400 {
-		return nil, fmt.Errorf("err: %q %q", url, res.Status)
+	defer f.Close() // nolint: errcheck
+	m := &httpManifest{}
+	if err := json.NewDecoder(f).Decode(m); err != nil {
+		return nil, fmt.Errorf("manifest %q: %w", l.Manifest, err)
+	}
+	return m, nil
+}
+
+func (l HTTPFS) url(name string) string {
+	base := l.BaseURL
+	if base != "" && !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	parts := strings.Split(name, "/")
+	for i, s := range parts {
+		parts[i] = url.PathEscape(s)
+	}
+	return base + strings.Join(parts, "/")
+}
+
+// do does the request with retries, cancel must be called once the body is
+// consumed.
+func (l HTTPFS) do(method, u string, prepare func(*http.Request)) (*http.Response, context.CancelFunc, error) {
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	backoff := l.Backoff
+	if backoff == 0 {
+		backoff = 100 * time.Millisecond
+	}
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if l.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		for k, v := range l.Header {
+			req.Header[k] = v
+		}
+		if prepare != nil {
+			prepare(req)
+		}
+		res, err := client.Do(req)
+		if err == nil && !retryStatus(res.StatusCode) {
+			return res, cancel, nil
+		}
+		if attempt >= l.Retries {
+			if err != nil {
+				cancel()
+				return nil, nil, err
+			}
+			return res, cancel, nil
+		}
+		if res != nil {
+			res.Body.Close() // nolint: errcheck
+		}
+		cancel()
+		time.Sleep(backoff << attempt)
+	}
+}
+
+func retryStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests
+}
+
+func statusErr(res *http.Response) error {
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return fs.ErrNotExist
+	case res.StatusCode >= 400:
+		return fmt.Errorf("%q %s", res.Request.URL, res.Status)
 	}
-	return httpFile{res.Body}, nil
+	return nil
+}
+
+func responseInfo(name string, res *http.Response) httpInfo {
+	info := httpInfo{name: path.Base(name), size: res.ContentLength}
+	if t, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		info.modTime = t
+	}
+	return info
+}
+
+// cancelBody cancels the request context on Close.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
 }
 
 type httpFile struct {
 	io.ReadCloser
+	info httpInfo
+}
+
+func (h *httpFile) Stat() (fs.FileInfo, error) {
+	return h.info, nil
+}
+
+type httpDir struct {
+	info    httpInfo
+	entries []fs.DirEntry
+}
+
+func (d *httpDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *httpDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *httpDir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *httpDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		ret := d.entries
+		d.entries = nil
+		return ret, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	ret := d.entries[:n]
+	d.entries = d.entries[n:]
+	return ret, nil
+}
+
+type httpInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func dirHTTPInfo(name string) httpInfo {
+	return httpInfo{name: path.Base(name), isDir: true}
+}
+
+func (i httpInfo) Name() string       { return i.name }
+func (i httpInfo) Size() int64        { return i.size }
+func (i httpInfo) ModTime() time.Time { return i.modTime }
+func (i httpInfo) IsDir() bool        { return i.isDir }
+func (i httpInfo) Sys() any           { return nil }
+func (i httpInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// httpManifest lists the files served by an HTTPFS.
+type httpManifest struct {
+	Files []httpManifestFile `json:"files"`
+}
+
+type httpManifestFile struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// WriteHTTPManifest writes a JSON manifest with the regular files of fsys to
+// be served with the files and used as HTTPFS Manifest.
+func WriteHTTPManifest(w io.Writer, fsys fs.FS) error {
+	m := httpManifest{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, httpManifestFile{
+			Name:    name,
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC(),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(m)
+}
+
+// HTTPCacheEntry is a cached HTTP response.
+type HTTPCacheEntry struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Data         []byte `json:"-"`
+}
+
+func (e *HTTPCacheEntry) file(name string) fs.File {
+	info := httpInfo{name: path.Base(name), size: int64(len(e.Data))}
+	if t, err := http.ParseTime(e.LastModified); err == nil {
+		info.modTime = t
+	}
+	return &httpFile{
+		ReadCloser: io.NopCloser(bytes.NewReader(e.Data)),
+		info:       info,
+	}
+}
+
+// HTTPCache stores HTTP responses by url.
+type HTTPCache interface {
+	Get(url string) (*HTTPCacheEntry, bool)
+	Put(url string, e *HTTPCacheEntry) error
+}
+
+// MemCache is an in memory HTTPCache.
+type MemCache struct {
+	mu      sync.Mutex
+	entries map[string]*HTTPCacheEntry
+}
+
+// NewMemCache returns a new in memory cache.
+func NewMemCache() *MemCache {
+	return &MemCache{entries: map[string]*HTTPCacheEntry{}}
+}
+
+// Get implements HTTPCache.
+func (c *MemCache) Get(url string) (*HTTPCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[url]
+	return e, ok
+}
+
+// Put implements HTTPCache.
+func (c *MemCache) Put(url string, e *HTTPCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[url] = e
+	return nil
+}
+
+// DirCache is an HTTPCache that stores responses in a directory, each entry
+// is a data file and a json file with the validators.
+type DirCache struct {
+	Dir string
+}
+
+// Get implements HTTPCache.
+func (c DirCache) Get(url string) (*HTTPCacheEntry, bool) {
+	base := c.path(url)
+	meta, err := os.ReadFile(base + ".json")
+	if err != nil {
+		return nil, false
+	}
+	e := &HTTPCacheEntry{}
+	if err := json.Unmarshal(meta, e); err != nil {
+		return nil, false
+	}
+	if e.Data, err = os.ReadFile(base); err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+// Put implements HTTPCache.
+func (c DirCache) Put(url string, e *HTTPCacheEntry) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	base := c.path(url)
+	// validators are written last so a partial write is never validated.
+	if err := os.Remove(base + ".json"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.WriteFile(base, e.Data, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(base+".json", meta, 0o644)
 }
 
-func (h httpFile) Stat() (fs.FileInfo, error) {
-	return nil, os.ErrInvalid
+func (c DirCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
 }
@@ -0,0 +1,158 @@
+package resource_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+func TestHTTPFS(t *testing.T) {
+	modTime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := fstest.MapFS{
+		"readme.txt":     {Data: []byte("hello"), ModTime: modTime},
+		"dir/a.txt":      {Data: []byte("aaa"), ModTime: modTime},
+		"dir/sub/b.txt":  {Data: []byte("bbbb"), ModTime: modTime},
+		"with space.txt": {Data: []byte("space"), ModTime: modTime},
+	}
+	manifest := &bytes.Buffer{}
+	if err := resource.WriteHTTPManifest(manifest, files); err != nil {
+		t.Fatal(err)
+	}
+	files["manifest.json"] = &fstest.MapFile{Data: manifest.Bytes(), ModTime: modTime}
+
+	var mu sync.Mutex
+	counts := map[string]int{}
+	notModified := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts[r.URL.Path]++
+		n := counts[r.URL.Path]
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/assets/flaky.txt":
+			if n < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			io.WriteString(w, "ok") // nolint: errcheck
+			return
+		case "/assets/slow.txt":
+			time.Sleep(200 * time.Millisecond)
+			return
+		case "/assets/header.txt":
+			io.WriteString(w, r.Header.Get("X-Test")) // nolint: errcheck
+			return
+		}
+		f, ok := files[strings.TrimPrefix(r.URL.Path, "/assets/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			mu.Lock()
+			notModified++
+			mu.Unlock()
+		}
+		http.ServeContent(w, r, r.URL.Path, f.ModTime, bytes.NewReader(f.Data))
+	}))
+	defer srv.Close()
+
+	base := resource.HTTPFS{
+		BaseURL:  srv.URL + "/assets",
+		Manifest: "manifest.json",
+	}
+	readFile := func(t *testing.T, fsys fs.FS, name string) string {
+		t.Helper()
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	t.Run("fs", func(t *testing.T) {
+		if err := fstest.TestFS(base, "readme.txt", "dir/a.txt", "dir/sub/b.txt"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("open", func(t *testing.T) {
+		if got := readFile(t, base, "with space.txt"); got != "space" {
+			t.Errorf("\nwant: %v\n got: %v\n", "space", got)
+		}
+		if _, err := base.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("want not exist error, got %v", err)
+		}
+	})
+	t.Run("stat", func(t *testing.T) {
+		info, err := fs.Stat(base, "dir/a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() != 3 || !info.ModTime().Equal(modTime) || info.IsDir() {
+			t.Errorf("unexpected info: %v %v %v", info.Size(), info.ModTime(), info.IsDir())
+		}
+		if info, err := fs.Stat(base, "dir/sub"); err != nil || !info.IsDir() {
+			t.Errorf("want dir from manifest, got %v", err)
+		}
+	})
+	t.Run("retry", func(t *testing.T) {
+		l := base
+		l.Retries = 1
+		l.Backoff = time.Millisecond
+		if _, err := l.Open("flaky.txt"); err == nil {
+			t.Error("want error after 2 attempts")
+		}
+		l.Retries = 3
+		if got := readFile(t, l, "flaky.txt"); got != "ok" {
+			t.Errorf("\nwant: %v\n got: %v\n", "ok", got)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		l := base
+		l.Timeout = 20 * time.Millisecond
+		if _, err := l.Open("slow.txt"); err == nil {
+			t.Error("want timeout error")
+		}
+	})
+	t.Run("header", func(t *testing.T) {
+		l := base
+		l.Header = http.Header{"X-Test": {"value"}}
+		if got := readFile(t, l, "header.txt"); got != "value" {
+			t.Errorf("\nwant: %v\n got: %v\n", "value", got)
+		}
+	})
+	caches := map[string]resource.HTTPCache{
+		"mem": resource.NewMemCache(),
+		"dir": resource.DirCache{Dir: t.TempDir()},
+	}
+	for name, c := range caches {
+		t.Run("cache "+name, func(t *testing.T) {
+			mu.Lock()
+			notModified = 0
+			mu.Unlock()
+			l := base
+			l.Cache = c
+			for i := 0; i < 3; i++ {
+				if got := readFile(t, l, "readme.txt"); got != "hello" {
+					t.Errorf("\nwant: %v\n got: %v\n", "hello", got)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if notModified != 2 {
+				t.Errorf("want 2 revalidated requests, got %d", notModified)
+			}
+		})
+	}
+}
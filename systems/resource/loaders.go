@@ -15,7 +15,7 @@ type loader struct {
 // Register registers a loader for a type and extension.
 func Register(v any, ext string, fn LoaderFunc) {
 	typ := reflect.TypeOf(v)
-	if typ.Kind() == reflect.Ptr {
+	if typ.Kind() == reflect.Pointer {
 		typ = typ.Elem()
 	}
 	resLoaders[loader{typ, ext}] = fn
@@ -23,7 +23,7 @@ func Register(v any, ext string, fn LoaderFunc) {
 
 func getLoader(v any, ext string) LoaderFunc {
 	typ := reflect.TypeOf(v)
-	if typ.Kind() == reflect.Ptr {
+	if typ.Kind() == reflect.Pointer {
 		typ = typ.Elem()
 	}
 	return resLoaders[loader{typ, ext}]
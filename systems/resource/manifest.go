@@ -0,0 +1,291 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+)
+
+// AssetOptionsFunc decodes the options of a manifest asset into loader
+// options.
+type AssetOptionsFunc func(raw json.RawMessage) ([]any, error)
+
+type assetType struct {
+	typ     reflect.Type
+	options AssetOptionsFunc
+}
+
+var assetTypes = map[string]assetType{}
+
+// RegisterAsset registers a manifest asset type name for values of the type of
+// v, options decodes the asset options and can be nil if the type has none.
+func RegisterAsset(name string, v any, options AssetOptionsFunc) {
+	typ := reflect.TypeOf(v)
+	if typ.Kind() != reflect.Pointer {
+		typ = reflect.PointerTo(typ)
+	}
+	assetTypes[name] = assetType{typ, options}
+}
+
+// Manifest lists assets with their type, loader options and group tags.
+type Manifest struct {
+	Assets []ManifestAsset `json:"assets"`
+}
+
+// ManifestAsset is an asset entry in a manifest.
+type ManifestAsset struct {
+	// Name identifies the asset in a group, defaults to Path.
+	Name string `json:"name,omitempty"`
+	// Type is a name registered with RegisterAsset, i.e: texture, mesh.
+	Type    string          `json:"type"`
+	Path    string          `json:"path"`
+	Options json.RawMessage `json:"options,omitempty"`
+	Groups  []string        `json:"groups,omitempty"`
+}
+
+// ReadManifest decodes a JSON manifest.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	names := map[string]int{}
+	for i := range m.Assets {
+		a := &m.Assets[i]
+		if a.Path == "" || a.Type == "" {
+			return nil, fmt.Errorf("manifest: asset %d: missing path or type", i)
+		}
+		if a.Name == "" {
+			a.Name = a.Path
+		}
+		if j, ok := names[a.Name]; ok {
+			return nil, fmt.Errorf("manifest: asset %q declared at %d and %d", a.Name, j, i)
+		}
+		names[a.Name] = i
+	}
+	return m, nil
+}
+
+// LoadManifest reads a JSON manifest from the resource file systems.
+func (r *Resource) LoadManifest(name string) (*Manifest, error) {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+	return ReadManifest(f)
+}
+
+// Group returns the assets tagged with group.
+func (m *Manifest) Group(group string) []ManifestAsset {
+	var ret []ManifestAsset
+	for _, a := range m.Assets {
+		for _, g := range a.Groups {
+			if g == group {
+				ret = append(ret, a)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// Groups returns the sorted group names.
+func (m *Manifest) Groups() []string {
+	set := map[string]struct{}{}
+	for _, a := range m.Assets {
+		for _, g := range a.Groups {
+			set[g] = struct{}{}
+		}
+	}
+	ret := make([]string, 0, len(set))
+	for g := range set {
+		ret = append(ret, g)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Verify checks that every asset type is registered, has a loader for the
+// file extension, options decode and the file exists in fsys, all problems
+// are reported in the error.
+func (m *Manifest) Verify(fsys fs.FS) error {
+	var errs []error
+	for _, a := range m.Assets {
+		if _, err := fs.Stat(fsys, a.Path); err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", a.Name, err))
+		}
+		t, ok := assetTypes[a.Type]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%q: unknown type %q", a.Name, a.Type))
+			continue
+		}
+		if getLoader(reflect.Zero(t.typ).Interface(), filepath.Ext(a.Path)) == nil {
+			errs = append(errs, fmt.Errorf("%q: no %s loader for %q", a.Name, a.Type, filepath.Ext(a.Path)))
+		}
+		if _, err := a.options(t); err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", a.Name, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("manifest: %w", gorge.JoinErrors(errs...))
+}
+
+func (a ManifestAsset) options(t assetType) ([]any, error) {
+	if len(a.Options) == 0 {
+		return nil, nil
+	}
+	if t.options == nil {
+		return nil, fmt.Errorf("type %q has no options", a.Type)
+	}
+	opts, err := t.options(a.Options)
+	if err != nil {
+		return nil, fmt.Errorf("options: %w", err)
+	}
+	return opts, nil
+}
+
+// EventGroupProgress is triggered each time an asset of a group is loaded.
+type EventGroupProgress struct {
+	Group  *Group
+	Loaded int
+	Total  int
+}
+
+// Progress returns the loaded fraction from 0 to 1.
+func (e EventGroupProgress) Progress() float32 {
+	if e.Total == 0 {
+		return 1
+	}
+	return float32(e.Loaded) / float32(e.Total)
+}
+
+// EventGroupComplete is triggered when every asset of a group is loaded, Err
+// is the first asset error.
+type EventGroupComplete struct {
+	Group *Group
+	Err   error
+}
+
+// Group is a set of manifest assets loaded concurrently and released as a
+// unit, assets are cached so entries with the same type, path and options are
+// shared within and across groups.
+type Group struct {
+	name  string
+	res   *Resource
+	batch *Batch
+	refs  map[string]*cacheRef
+}
+
+// LoadGroup loads the assets of the manifest tagged with group in the
+// background.
+func (r *Resource) LoadGroup(m *Manifest, group string) *Group {
+	g := &Group{
+		name:  group,
+		res:   r,
+		batch: NewBatch(),
+		refs:  map[string]*cacheRef{},
+	}
+	owner := "group:" + group
+	for _, a := range m.Group(group) {
+		a := a
+		f := r.acquireAsync(a, owner, func(ref *cacheRef) {
+			g.refs[a.Name] = ref
+		})
+		g.batch.Add(f)
+	}
+	g.batch.OnProgress(func(float32) {
+		event.Trigger(r.gorge, EventGroupProgress{
+			Group:  g,
+			Loaded: g.batch.completed,
+			Total:  len(g.batch.futures),
+		})
+		if g.batch.Done() {
+			event.Trigger(r.gorge, EventGroupComplete{Group: g, Err: g.batch.Err()})
+		}
+	})
+	if len(g.batch.futures) == 0 {
+		r.gorge.PostFunc(func() {
+			event.Trigger(r.gorge, EventGroupComplete{Group: g})
+		})
+	}
+	return g
+}
+
+// acquireAsync returns a future for a cached asset, set is called on the main
+// loop with the reference once loaded.
+func (r *Resource) acquireAsync(a ManifestAsset, owner string, set func(*cacheRef)) *Future {
+	t, ok := assetTypes[a.Type]
+	if !ok {
+		return r.failed(a.Name, fmt.Errorf("asset %q: unknown type %q", a.Name, a.Type))
+	}
+	opts, err := a.options(t)
+	if err != nil {
+		return r.failed(a.Name, fmt.Errorf("asset %q: %w", a.Name, err))
+	}
+	if ref, ok := r.cache.get(t.typ, a.Path, opts, owner); ok {
+		f := &Future{res: r, name: a.Path, v: ref.entry.value}
+		event.Trigger(r.gorge, EventLoadStart{Name: a.Path, Resource: f.v})
+		set(ref)
+		r.gorge.PostFunc(func() { f.complete(nil) })
+		return f
+	}
+	v := reflect.New(t.typ.Elem()).Interface()
+	return r.LoadAsync(v, a.Path, opts...).Then(func(v any, err error) {
+		if err != nil {
+			return
+		}
+		set(r.cache.add(t.typ, a.Path, opts, owner, v))
+	})
+}
+
+func (r *Resource) failed(name string, err error) *Future {
+	f := &Future{res: r, name: name}
+	r.gorge.PostFunc(func() { f.complete(err) })
+	return f
+}
+
+// Name returns the group name.
+func (g *Group) Name() string { return g.name }
+
+// Done returns true when every asset finished loading.
+func (g *Group) Done() bool { return g.batch.Done() }
+
+// Err returns the first asset error.
+func (g *Group) Err() error { return g.batch.Err() }
+
+// Progress returns the loaded fraction from 0 to 1.
+func (g *Group) Progress() float32 { return g.batch.Progress() }
+
+// Wait suspends the task until the group is loaded and returns the first
+// error.
+func (g *Group) Wait(t *gorge.Task) error { return g.batch.Wait(t) }
+
+// Get returns the loaded value of the asset by manifest name, values are
+// pointers to the registered asset type, i.e: *gorge.TextureData.
+func (g *Group) Get(name string) (any, bool) {
+	ref, ok := g.refs[name]
+	if !ok {
+		return nil, false
+	}
+	return ref.entry.value, true
+}
+
+// Release cancels pending loads and releases the group references, assets not
+// referenced by other groups or handles are released.
+func (g *Group) Release() {
+	g.batch.Cancel()
+	for name, ref := range g.refs {
+		g.res.unref(ref)
+		delete(g.refs, name)
+	}
+}
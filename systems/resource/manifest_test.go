@@ -0,0 +1,130 @@
+package resource_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+func init() {
+	resource.RegisterAsset("cachedata", (*cacheData)(nil), func(raw json.RawMessage) ([]any, error) {
+		var n int
+		err := json.Unmarshal(raw, &n)
+		return []any{n}, err
+	})
+}
+
+const testManifest = `{"assets": [
+	{"name": "x", "type": "cachedata", "path": "x.cache", "groups": ["a", "b"]},
+	{"name": "y", "type": "cachedata", "path": "x.cache", "groups": ["a"]},
+	{"name": "z", "type": "cachedata", "path": "x.cache", "options": 1, "groups": ["a"]},
+	{"type": "cachedata", "path": "w.cache", "groups": ["b"]}
+]}`
+
+func TestManifest(t *testing.T) {
+	cacheLoads = map[string]int{}
+	m, err := resource.ReadManifest(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Groups(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("\nwant: %v\n got: %v\n", []string{"a", "b"}, got)
+	}
+
+	t.Run("verify", func(t *testing.T) {
+		if err := m.Verify(fstest.MapFS{
+			"x.cache": {},
+			"w.cache": {},
+		}); err != nil {
+			t.Error(err)
+		}
+		bad, err := resource.ReadManifest(strings.NewReader(`{"assets": [
+			{"type": "cachedata", "path": "missing.cache"},
+			{"name": "u", "type": "unknown", "path": "x.cache"},
+			{"name": "t", "type": "texture", "path": "x.cache"}
+		]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = bad.Verify(fstest.MapFS{"x.cache": {}})
+		if err == nil {
+			t.Fatal("want verify error")
+		}
+		for _, want := range []string{"missing.cache", "unknown type", "no texture loader"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("want error containing %q, got: %v", want, err)
+			}
+		}
+	})
+
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	res := resource.FromContext(g)
+
+	var progress []float32
+	var completed []string
+	var released []any
+	event.Handle(g, func(e resource.EventGroupProgress) {
+		progress = append(progress, e.Progress())
+	})
+	event.Handle(g, func(e resource.EventGroupComplete) {
+		completed = append(completed, e.Group.Name())
+	})
+	event.Handle(g, func(e gorge.EventResourceRelease) {
+		released = append(released, e.Resource)
+	})
+	wait := func(grp *resource.Group) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !grp.Done() && time.Now().Before(deadline) {
+			gg.Update(1.0 / 60)
+			time.Sleep(time.Millisecond)
+		}
+		if !grp.Done() || grp.Err() != nil {
+			t.Fatalf("want group loaded, err: %v", grp.Err())
+		}
+	}
+
+	a := res.LoadGroup(m, "a")
+	wait(a)
+	if len(progress) != 3 || progress[2] != 1 || len(completed) != 1 {
+		t.Errorf("want 3 progress events and complete, got %v %v", progress, completed)
+	}
+	x, _ := a.Get("x")
+	y, _ := a.Get("y")
+	z, _ := a.Get("z")
+	if x == nil || x != y || x == z {
+		t.Errorf("want x and y shared, z separate: %p %p %p", x, y, z)
+	}
+	if n := cacheLoads["x.cache"]; n != 2 {
+		t.Errorf("\nwant: %v\n got: %v\n", 2, n)
+	}
+
+	b := res.LoadGroup(m, "b")
+	wait(b)
+	if bx, _ := b.Get("x"); bx != x {
+		t.Error("want x shared across groups")
+	}
+	if n := cacheLoads["x.cache"]; n != 2 {
+		t.Errorf("want no reload of x, got %d loads", n)
+	}
+
+	a.Release()
+	if len(released) != 1 || released[0] != z {
+		t.Fatalf("want only z released, got %v", released)
+	}
+	b.Release()
+	if len(released) != 3 {
+		t.Errorf("want x and w released, got %v", released)
+	}
+}
@@ -2,16 +2,30 @@ package resource
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io"
 	"path/filepath"
 
 	"github.com/hajimehoshi/go-mp3"
 	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/flac"
+	"github.com/stdiopt/gorge/x/vorbis"
+	"github.com/stdiopt/gorge/x/wav"
 )
 
+// audioClipDecoders decodes audio files by extension.
+var audioClipDecoders = map[string]func(io.Reader) (*gorge.AudioClipData, error){
+	".mp3":  decodeMP3,
+	".wav":  wav.Decode,
+	".ogg":  vorbis.Decode,
+	".flac": flac.Decode,
+}
+
 func init() {
-	Register((*gorge.AudioClipData)(nil), ".mp3", audioClipDataLoader)
-	Register((*gorge.AudioClip)(nil), ".mp3", audioClipLoader)
+	for ext := range audioClipDecoders {
+		Register((*gorge.AudioClipData)(nil), ext, audioClipDataLoader)
+		Register((*gorge.AudioClip)(nil), ext, audioClipLoader)
+	}
+	RegisterAsset("audio", (*gorge.AudioClipData)(nil), nil)
 }
 
 func audioClipLoader(res *Context, v any, name string, opts ...any) error {
@@ -30,26 +44,40 @@ func audioClipLoader(res *Context, v any, name string, opts ...any) error {
 func audioClipDataLoader(res *Context, v any, name string, _ ...any) error {
 	clipData := v.(*gorge.AudioClipData)
 
+	ext := filepath.Ext(name)
+	decode, ok := audioClipDecoders[ext]
+	if !ok {
+		return fmt.Errorf("unknown audioClip type: %s", ext)
+	}
+
 	rd, err := res.Open(name)
 	if err != nil {
 		return fmt.Errorf("error opening audio clip: %w", err)
 	}
+	defer rd.Close() // nolint: errcheck
 
-	ext := filepath.Ext(name)
-	switch ext {
-	case ".mp3":
-		dec, err := mp3.NewDecoder(rd)
-		if err != nil {
-			return err
-		}
-		data, err := ioutil.ReadAll(dec)
-		if err != nil {
-			return err
-		}
-		// clipData.Format = ....
-		clipData.Data = data
-	default:
-		return fmt.Errorf("unknown audioClip type: %s", ext)
+	data, err := decode(rd)
+	if err != nil {
+		return fmt.Errorf("error decoding audio clip %q: %w", name, err)
 	}
+	*clipData = *data
 	return nil
 }
+
+// decodeMP3 decodes mp3 files, go-mp3 always outputs 16 bit stereo.
+func decodeMP3(rd io.Reader) (*gorge.AudioClipData, error) {
+	dec, err := mp3.NewDecoder(rd)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(dec)
+	if err != nil {
+		return nil, err
+	}
+	return &gorge.AudioClipData{
+		Format:     gorge.AudioFormatS16,
+		SampleRate: dec.SampleRate(),
+		Channels:   2,
+		Data:       data,
+	}, nil
+}
@@ -10,6 +10,7 @@ import (
 func init() {
 	Register((*gorge.MeshData)(nil), ".obj", meshDataLoader)
 	Register((*gorge.Mesh)(nil), ".obj", meshLoader)
+	RegisterAsset("mesh", (*gorge.MeshData)(nil), nil)
 }
 
 func meshDataLoader(res *Context, v any, name string, _ ...any) error {
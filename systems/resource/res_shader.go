@@ -19,6 +19,7 @@ var reInclude = regexp.MustCompile(`#include\s+<(.*)>\s*`)
 func init() {
 	Register((*gorge.ShaderData)(nil), ".glsl", shaderDataLoader)
 	Register((*gorge.Material)(nil), ".glsl", materialLoader)
+	RegisterAsset("shader", (*gorge.ShaderData)(nil), nil)
 }
 
 func shaderDataLoader(res *Context, v any, name string, _ ...any) error {
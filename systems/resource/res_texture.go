@@ -6,6 +6,8 @@ import (
 	"image/draw"
 	"io"
 	"log"
+	"path/filepath"
+	"strings"
 	"unsafe"
 
 	xdraw "golang.org/x/image/draw"
@@ -22,16 +24,30 @@ import (
 	_ "github.com/mdouchement/hdr/codec/rgbe"
 
 	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/dds"
+	"github.com/stdiopt/gorge/x/exr"
+	"github.com/stdiopt/gorge/x/ktx2"
 )
 
 const maxaddr = 0x7FFFFFFF
 
+// textureDecoders decodes GPU texture containers by extension, other
+// extensions are decoded with image.Decode.
+var textureDecoders = map[string]func(io.Reader) (*gorge.TextureData, error){
+	".ktx2": ktx2.Decode,
+	".dds":  dds.Decode,
+	".exr":  exr.Decode,
+}
+
 func init() {
 	exts := []string{
 		".jpg", ".jpeg",
 		".png",
 		".gif",
 		".hdr",
+		".ktx2",
+		".dds",
+		".exr",
 	}
 
 	for _, ext := range exts {
@@ -39,35 +55,53 @@ func init() {
 		Register((*gorge.Texture)(nil), ext, textureLoader)
 		Register((*gorge.TextureData)(nil), ext, textureDataLoader)
 	}
+	RegisterAsset("texture", (*gorge.TextureData)(nil), textureAssetOptions)
 }
 
-func textureLoader(res *Context, v any, name string, _ ...any) error {
+func textureLoader(res *Context, v any, name string, opts ...any) error {
 	tex, ok := v.(*gorge.Texture)
 	if !ok {
 		return fmt.Errorf("unable to load data into: %T", v)
 	}
 
+	opt, err := textureOptions(opts)
+	if err != nil {
+		return err
+	}
 	var texData gorge.TextureData
-	if err := textureDataLoader(res, &texData, name); err != nil {
+	if err := textureDataLoader(res, &texData, name, opts...); err != nil {
 		return err
 	}
 	tex.Resourcer = &texData
+	opt.setup(tex)
 
 	return nil
 }
 
-func textureDataLoader(res *Context, v any, name string, _ ...any) error {
+func textureDataLoader(res *Context, v any, name string, opts ...any) error {
 	texData := v.(*gorge.TextureData)
 
+	opt, err := textureOptions(opts)
+	if err != nil {
+		return err
+	}
+
 	rd, err := res.Open(name)
 	if err != nil {
 		return fmt.Errorf("[resource] error opening image: %w", err)
 	}
 
-	td, err := ReadTexture(rd)
+	decode := func(rd io.Reader) (*gorge.TextureData, error) {
+		return readTexture(rd, opt)
+	}
+	if d, ok := textureDecoders[strings.ToLower(filepath.Ext(name))]; ok {
+		decode = d
+	}
+	td, err := decode(rd)
 	if err != nil {
 		return err
 	}
+	opt.apply(td)
 	*texData = *td
 	texData.Source = name
 
@@ -76,16 +110,26 @@ func textureDataLoader(res *Context, v any, name string, _ ...any) error {
 
 // ReadTexture reads an image from the reader and returns a textureData.
 func ReadTexture(rd io.Reader) (*gorge.TextureData, error) {
+	return readTexture(rd, TextureOptions{})
+}
+
+func readTexture(rd io.Reader, opt TextureOptions) (*gorge.TextureData, error) {
 	img, _, err := image.Decode(rd)
 	if err != nil {
 		return nil, fmt.Errorf("[resource] error decoding image: %w", err)
 	}
 
-	return TextureDataFromImage(img)
+	return textureDataFromImage(img, opt)
 }
 
 // TextureDataFromImage converts a go image.Image to gorge.TextureData.
 func TextureDataFromImage(im image.Image) (*gorge.TextureData, error) {
+	return textureDataFromImage(im, TextureOptions{})
+}
+
+// textureDataFromImage converts an image applying the size and alpha
+// options.
+func textureDataFromImage(im image.Image, opt TextureOptions) (*gorge.TextureData, error) {
 	dim := im.Bounds()
 	var format gorge.TextureFormat
 	var pixData []byte
@@ -97,54 +141,63 @@ func TextureDataFromImage(im image.Image) (*gorge.TextureData, error) {
 		format = gorge.TextureFormatRGB32F
 		pixData = append([]byte{}, byteData...)
 	case *image.NRGBA:
-		if dim = getPowerOf2Dim(dim); dim != im.Bounds() {
+		dim = opt.bounds(dim)
+		if opt.Premultiply {
+			dimg := image.NewRGBA(dim)
+			opt.draw(dimg, im)
+
+			format = gorge.TextureFormatRGBA
+			pixData = dimg.Pix
+			break
+		}
+		if dim != im.Bounds() {
 			orig := im
 			im = image.NewNRGBA(dim)
-			convImg(im, orig)
+			opt.draw(im, orig)
 		}
 
 		format = gorge.TextureFormatRGBA
 		pixData = im.Pix
 	case *image.RGBA:
-		if dim = getPowerOf2Dim(dim); dim != im.Bounds() {
+		if dim = opt.bounds(dim); dim != im.Bounds() {
 			orig := im
 			im = image.NewRGBA(dim)
-			convImg(im, orig)
+			opt.draw(im, orig)
 		}
 
 		format = gorge.TextureFormatRGBA
 		pixData = im.Pix
 	case *image.YCbCr, *image.RGBA64, *image.Paletted: // We convert these for now
-		dim = getPowerOf2Dim(dim)
+		dim = opt.bounds(dim)
 
 		dimg := image.NewRGBA(dim)
-		convImg(dimg, im)
+		opt.draw(dimg, im)
 
 		format = gorge.TextureFormatRGBA
 		pixData = dimg.Pix
 	case *image.Alpha:
-		if dim = getPowerOf2Dim(dim); dim != im.Bounds() {
+		if dim = opt.bounds(dim); dim != im.Bounds() {
 			orig := im
 			im = image.NewAlpha(dim)
-			convImg(im, orig)
+			opt.draw(im, orig)
 		}
 
 		format = gorge.TextureFormatGray
 		pixData = im.Pix
 	case *image.Gray:
-		if dim = getPowerOf2Dim(dim); dim != im.Bounds() {
+		if dim = opt.bounds(dim); dim != im.Bounds() {
 			orig := im
 			im = image.NewGray(dim)
-			convImg(im, orig)
+			opt.draw(im, orig)
 		}
 
 		format = gorge.TextureFormatGray
 		pixData = im.Pix
 	case *image.Gray16:
-		dim = getPowerOf2Dim(dim)
+		dim = opt.bounds(dim)
 
 		dimg := image.NewGray(dim)
-		convImg(dimg, im)
+		opt.draw(dimg, im)
 
 		format = gorge.TextureFormatGray
 		pixData = dimg.Pix
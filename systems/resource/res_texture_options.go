@@ -0,0 +1,287 @@
+package resource
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+
+	"github.com/stdiopt/gorge"
+)
+
+// PowerOf2Mode sets how images without power of 2 sizes are loaded.
+type PowerOf2Mode int
+
+// Power of 2 modes.
+const (
+	// PowerOf2Scale rescales images to the nearest power of 2 size.
+	PowerOf2Scale = PowerOf2Mode(iota)
+	// PowerOf2Pad pads images with transparent pixels to the next power of 2
+	// size, the image stays on the first rows and columns.
+	PowerOf2Pad
+	// PowerOf2None keeps the image size.
+	PowerOf2None
+)
+
+// MipmapMode sets how texture mip levels are built.
+type MipmapMode int
+
+// Mipmap modes.
+const (
+	// MipmapGPU lets the renderer generate the mip levels.
+	MipmapGPU = MipmapMode(iota)
+	// MipmapCPU generates the mip levels on load into TextureData.Mips.
+	MipmapCPU
+	// MipmapNone disables mip levels.
+	MipmapNone
+)
+
+// TextureOptions texture import options for Load, Context.Texture and
+// manifest assets, pass it as a value so loads with the same options share
+// the cache.
+//
+// Premultiply, MaxSize and PowerOf2 apply to decoded images, .ktx2 and .dds
+// textures keep their data.
+type TextureOptions struct {
+	// Filter and Wrap are set on loaded gorge.Texture values.
+	Filter gorge.TextureFilter
+	Wrap   [3]gorge.TextureWrap
+	// SRGB sets gorge.TextureData.SRGB.
+	SRGB bool
+	// Premultiply multiplies the color by alpha on images with straight
+	// alpha.
+	Premultiply bool
+	// FlipY reverses the rows of uncompressed textures.
+	FlipY bool
+	// MaxSize downscales images keeping the aspect ratio so the largest side
+	// fits, padding can grow it up to the next power of 2.
+	MaxSize  int
+	PowerOf2 PowerOf2Mode
+	Mipmaps  MipmapMode
+}
+
+func textureOptions(opts []any) (TextureOptions, error) {
+	opt := TextureOptions{}
+	for _, o := range opts {
+		switch o := o.(type) {
+		case TextureOptions:
+			opt = o
+		default:
+			return opt, fmt.Errorf("wrong options type: %T", o)
+		}
+	}
+	return opt, nil
+}
+
+// textureAssetOptions decodes manifest options, i.e:
+//
+//	{"filter": "point", "wrap": "clamp", "srgb": true, "maxSize": 1024, "powerOf2": "pad", "mipmaps": "cpu"}
+func textureAssetOptions(raw json.RawMessage) ([]any, error) {
+	var o struct {
+		Filter      string `json:"filter"`
+		Wrap        string `json:"wrap"`
+		SRGB        bool   `json:"srgb"`
+		Premultiply bool   `json:"premultiply"`
+		FlipY       bool   `json:"flipY"`
+		MaxSize     int    `json:"maxSize"`
+		PowerOf2    string `json:"powerOf2"`
+		Mipmaps     string `json:"mipmaps"`
+	}
+	if err := json.Unmarshal(raw, &o); err != nil {
+		return nil, err
+	}
+	filters := map[string]gorge.TextureFilter{
+		"": gorge.TextureFilterLinear, "linear": gorge.TextureFilterLinear, "point": gorge.TextureFilterPoint,
+	}
+	wraps := map[string]gorge.TextureWrap{
+		"": gorge.TextureWrapRepeat, "repeat": gorge.TextureWrapRepeat, "clamp": gorge.TextureWrapClamp, "mirror": gorge.TextureWrapMirror,
+	}
+	pow2 := map[string]PowerOf2Mode{
+		"": PowerOf2Scale, "scale": PowerOf2Scale, "pad": PowerOf2Pad, "none": PowerOf2None,
+	}
+	mipmaps := map[string]MipmapMode{
+		"": MipmapGPU, "gpu": MipmapGPU, "cpu": MipmapCPU, "none": MipmapNone,
+	}
+
+	opt := TextureOptions{
+		SRGB:        o.SRGB,
+		Premultiply: o.Premultiply,
+		FlipY:       o.FlipY,
+		MaxSize:     o.MaxSize,
+	}
+	var ok bool
+	if opt.Filter, ok = filters[o.Filter]; !ok {
+		return nil, fmt.Errorf("invalid filter %q", o.Filter)
+	}
+	wrap, ok := wraps[o.Wrap]
+	if !ok {
+		return nil, fmt.Errorf("invalid wrap %q", o.Wrap)
+	}
+	opt.Wrap = [3]gorge.TextureWrap{wrap, wrap, wrap}
+	if opt.PowerOf2, ok = pow2[o.PowerOf2]; !ok {
+		return nil, fmt.Errorf("invalid powerOf2 %q", o.PowerOf2)
+	}
+	if opt.Mipmaps, ok = mipmaps[o.Mipmaps]; !ok {
+		return nil, fmt.Errorf("invalid mipmaps %q", o.Mipmaps)
+	}
+	return []any{opt}, nil
+}
+
+// setup sets the sampling options on a texture.
+func (o TextureOptions) setup(tex *gorge.Texture) {
+	tex.FilterMode = o.Filter
+	tex.Wrap = o.Wrap
+}
+
+// apply applies the options to decoded texture data.
+func (o TextureOptions) apply(td *gorge.TextureData) {
+	td.SRGB = td.SRGB || o.SRGB
+	if o.FlipY && !td.Format.Compressed() {
+		w, h := td.Width, td.Height
+		for _, pix := range append([][]byte{td.PixelData}, td.Mips...) {
+			flipRows(pix, td.Format.DataSize(w, 1), h)
+			w, h = mipSize(w), mipSize(h)
+		}
+	}
+	switch o.Mipmaps {
+	case MipmapCPU:
+		if len(td.Mips) == 0 {
+			td.Mips = mipChain(td)
+		}
+	case MipmapNone:
+		td.Mips = nil
+		td.NoMipmaps = true
+	}
+}
+
+// fit returns the image size limited by MaxSize.
+func (o TextureOptions) fit(r image.Rectangle) (int, int) {
+	w, h := r.Dx(), r.Dy()
+	m := o.MaxSize
+	switch {
+	case m <= 0 || (w <= m && h <= m):
+	case w >= h:
+		w, h = m, h*m/w
+	default:
+		w, h = w*m/h, m
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// bounds returns the texture bounds of an image.
+func (o TextureOptions) bounds(r image.Rectangle) image.Rectangle {
+	w, h := o.fit(r)
+	switch o.PowerOf2 {
+	case PowerOf2Scale:
+		p := getPowerOf2Dim(image.Rect(0, 0, w, h))
+		w, h = p.Dx(), p.Dy()
+		// The nearest power of 2 might round up past MaxSize.
+		for o.MaxSize > 0 && (w > o.MaxSize || h > o.MaxSize) {
+			w, h = mipSize(w), mipSize(h)
+		}
+	case PowerOf2Pad:
+		w, h = nextPowerOf2(w), nextPowerOf2(h)
+	}
+	if w == r.Dx() && h == r.Dy() {
+		return r
+	}
+	return image.Rect(0, 0, w, h)
+}
+
+// draw scales or pads src into dst.
+func (o TextureOptions) draw(dst draw.Image, src image.Image) {
+	if o.PowerOf2 != PowerOf2Pad {
+		convImg(dst, src)
+		return
+	}
+	w, h := o.fit(src.Bounds())
+	r := image.Rect(0, 0, w, h)
+	if r.Size() == src.Bounds().Size() {
+		draw.Draw(dst, r, src, src.Bounds().Min, draw.Src)
+		return
+	}
+	xdraw.ApproxBiLinear.Scale(dst, r, src, src.Bounds(), xdraw.Src, nil)
+}
+
+func flipRows(pix []byte, stride, rows int) {
+	tmp := make([]byte, stride)
+	for y := 0; y < rows/2; y++ {
+		a := pix[y*stride : (y+1)*stride]
+		b := pix[(rows-1-y)*stride : (rows-y)*stride]
+		copy(tmp, a)
+		copy(a, b)
+		copy(b, tmp)
+	}
+}
+
+// mipChain returns box filtered mip levels after the base level, formats that
+// can't be filtered return nil.
+func mipChain(td *gorge.TextureData) [][]byte {
+	_, _, n := td.Format.BlockSize()
+	var avg func(dst, src []byte, off [4]int)
+	switch td.Format {
+	case gorge.TextureFormatGray, gorge.TextureFormatRGB, gorge.TextureFormatRGBA:
+		avg = func(dst, src []byte, off [4]int) {
+			for c := 0; c < n; c++ {
+				s := int(src[off[0]+c]) + int(src[off[1]+c]) + int(src[off[2]+c]) + int(src[off[3]+c])
+				dst[c] = byte((s + 2) / 4)
+			}
+		}
+	case gorge.TextureFormatR32F, gorge.TextureFormatRGB32F, gorge.TextureFormatRGBA32F:
+		avg = func(dst, src []byte, off [4]int) {
+			for c := 0; c < n; c += 4 {
+				var s float32
+				for _, o := range off {
+					s += math.Float32frombits(binary.LittleEndian.Uint32(src[o+c:]))
+				}
+				binary.LittleEndian.PutUint32(dst[c:], math.Float32bits(s/4))
+			}
+		}
+	default:
+		return nil
+	}
+
+	var mips [][]byte
+	pix, w, h := td.PixelData, td.Width, td.Height
+	for w > 1 || h > 1 {
+		nw, nh := mipSize(w), mipSize(h)
+		next := make([]byte, nw*nh*n)
+		for y := 0; y < nh; y++ {
+			y0, y1 := 2*y, 2*y+1
+			if y1 >= h {
+				y1 = h - 1
+			}
+			for x := 0; x < nw; x++ {
+				x0, x1 := 2*x, 2*x+1
+				if x1 >= w {
+					x1 = w - 1
+				}
+				avg(next[(y*nw+x)*n:], pix, [4]int{
+					(y0*w + x0) * n, (y0*w + x1) * n,
+					(y1*w + x0) * n, (y1*w + x1) * n,
+				})
+			}
+		}
+		mips = append(mips, next)
+		pix, w, h = next, nw, nh
+	}
+	return mips
+}
+
+// mipSize returns the size of the next mip level.
+func mipSize(n int) int {
+	if n > 1 {
+		return n / 2
+	}
+	return 1
+}
@@ -0,0 +1,118 @@
+package resource_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+func pngFile(t *testing.T, w, h int, pix ...color.NRGBA) *fstest.MapFile {
+	t.Helper()
+	im := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i, c := range pix {
+		im.SetNRGBA(i%w, i/w, c)
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, im); err != nil {
+		t.Fatal(err)
+	}
+	return &fstest.MapFile{Data: buf.Bytes()}
+}
+
+func TestTextureOptions(t *testing.T) {
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	res := resource.FromContext(g)
+
+	red := color.NRGBA{255, 0, 0, 128}
+	blue := color.NRGBA{10, 20, 30, 255}
+	res.AddFS("", fstest.MapFS{
+		"small.png": pngFile(t, 3, 2,
+			red, color.NRGBA{0, 255, 0, 255}, color.NRGBA{0, 0, 255, 0},
+			blue, blue, blue,
+		),
+		"large.png": pngFile(t, 8, 4),
+	})
+
+	t.Run("pad flip premultiply mips", func(t *testing.T) {
+		var td gorge.TextureData
+		err := res.Load(&td, "small.png", resource.TextureOptions{
+			PowerOf2:    resource.PowerOf2Pad,
+			FlipY:       true,
+			Premultiply: true,
+			Mipmaps:     resource.MipmapCPU,
+			SRGB:        true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if td.Width != 4 || td.Height != 2 || !td.SRGB {
+			t.Fatalf("want 4x2 sRGB texture, got %dx%d sRGB %v", td.Width, td.Height, td.SRGB)
+		}
+		pm := color.RGBAModel.Convert(red).(color.RGBA)
+		tests := []struct {
+			i    int
+			want color.RGBA
+		}{
+			{0, color.RGBA{10, 20, 30, 255}},
+			{3, color.RGBA{}},
+			{4, pm},
+			{6, color.RGBA{}},
+			{7, color.RGBA{}},
+		}
+		for _, tt := range tests {
+			p := td.PixelData[tt.i*4:]
+			got := color.RGBA{p[0], p[1], p[2], p[3]}
+			if got != tt.want {
+				t.Errorf("pixel %d\nwant: %v\n got: %v\n", tt.i, tt.want, got)
+			}
+		}
+		if len(td.Mips) != 2 || len(td.Mips[0]) != 2*4 || len(td.Mips[1]) != 4 {
+			t.Errorf("want 2x1 and 1x1 mips, got %d levels", len(td.Mips))
+		}
+	})
+	t.Run("max size", func(t *testing.T) {
+		var td gorge.TextureData
+		err := res.Load(&td, "large.png", resource.TextureOptions{
+			MaxSize: 3,
+			Mipmaps: resource.MipmapNone,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if td.Width != 2 || td.Height != 1 || !td.NoMipmaps {
+			t.Errorf("want 2x1 without mipmaps, got %dx%d %v", td.Width, td.Height, td.NoMipmaps)
+		}
+	})
+	t.Run("texture sampling", func(t *testing.T) {
+		var tex gorge.Texture
+		opt := resource.TextureOptions{
+			Filter: gorge.TextureFilterPoint,
+			Wrap:   [3]gorge.TextureWrap{gorge.TextureWrapClamp, gorge.TextureWrapMirror},
+		}
+		if err := res.Load(&tex, "small.png", opt); err != nil {
+			t.Fatal(err)
+		}
+		if tex.FilterMode != opt.Filter || tex.Wrap != opt.Wrap {
+			t.Errorf("\nwant: %v %v\n got: %v %v\n", opt.Filter, opt.Wrap, tex.FilterMode, tex.Wrap)
+		}
+	})
+	t.Run("wrong options", func(t *testing.T) {
+		var td gorge.TextureData
+		err := res.Load(&td, "small.png", 1)
+		if err == nil || !strings.Contains(err.Error(), "wrong options type") {
+			t.Errorf("\nwant: %v\n got: %v\n", "wrong options type", err)
+		}
+	})
+}
@@ -7,10 +7,10 @@ import (
 	"io"
 	"io/fs"
 	"io/ioutil"
-	"log"
 	"path/filepath"
-	"runtime"
+	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/stdiopt/gorge"
 	"github.com/stdiopt/gorge/core/event"
@@ -20,16 +20,16 @@ import (
 
 const gorgeStatic = "_gorge/"
 
-type refCounter struct {
-	count int
-	ref   any // gorge.ResourceRef
-}
-
 // Resource the resource manager.
 type Resource struct {
 	gorge *gorge.Context
 	fs    layerfs.FS
-	refs  map[string]*refCounter
+	cache cache
+	pool  *loadPool
+
+	// watchMu guards watch which is read from load goroutines.
+	watchMu sync.Mutex
+	watch   *watcher
 }
 
 // AddFS adds a new file system with the prefix if a path exists it will overlay
@@ -106,41 +106,87 @@ func (r *Resource) MustLoad(v any, path string, opts ...any) {
 	}
 }
 
+// Release removes every resource loaded with name from the cache and
+// triggers gorge.EventResourceRelease for each, resources still in use will
+// render empty and the next load with the name will load it again.
+func (r *Resource) Release(name string) {
+	entries := r.cache.remove(name)
+	if len(entries) == 0 {
+		return
+	}
+	for _, e := range entries {
+		r.unwatch(e.value)
+		event.Trigger(r.gorge, gorge.EventResourceRelease{Resource: e.value})
+	}
+}
+
+// Path returns the name a resource was loaded with through the cache, like
+// Context Mesh, Texture or Acquire.
+func (r *Resource) Path(v any) (string, bool) {
+	switch vv := v.(type) {
+	case *gorge.Mesh:
+		// Cloned meshes refer to the source mesh.
+		res := vv.Resourcer
+		for m, ok := res.(*gorge.Mesh); ok; m, ok = res.(*gorge.Mesh) {
+			res = m.Resourcer
+		}
+		if ref, ok := res.(*gorge.MeshRef); ok {
+			v = ref.GPU
+		}
+	case *gorge.Texture:
+		if ref, ok := vv.Resourcer.(*gorge.TextureRef); ok {
+			v = ref.GPU
+		}
+	}
+	if v == nil || !reflect.TypeOf(v).Comparable() {
+		return "", false
+	}
+	return r.cache.find(v)
+}
+
 func (r *Resource) Error(err error) {
 	r.gorge.Error(err)
 }
 
 func (r *Resource) load(v any, name string, opts ...any) error {
+	return r.loadWatch(v, v, name, opts...)
+}
+
+// loadWatch loads v and watches its files with key, loads for a cache entry
+// use the cached value as key so releasing the entry stops the watch.
+func (r *Resource) loadWatch(key, v any, name string, opts ...any) error {
+	w := r.watcher()
+	files, err := r.loadFiles(w, v, name, opts...)
+	if err != nil {
+		return err
+	}
+	r.watchFiles(w, key, name, v, opts, files)
+	return nil
+}
+
+// loadFiles loads v and returns the files opened by the loader if w is not
+// nil.
+func (r *Resource) loadFiles(w *watcher, v any, name string, opts ...any) ([]string, error) {
+	ctx := &Context{resource: r}
+	if w != nil {
+		ctx.opened = map[string]struct{}{}
+	}
+	return r.loadContext(ctx, v, name, opts...)
+}
+
+// loadContext loads v with a loader context.
+func (r *Resource) loadContext(ctx *Context, v any, name string, opts ...any) ([]string, error) {
 	ext := filepath.Ext(name)
 	loader := getLoader(v, ext)
 	if loader == nil {
-		return fmt.Errorf("no driver for type: %T with ext: %v", v, ext)
+		return nil, fmt.Errorf("no driver for type: %T with ext: %v", v, ext)
 	}
-	return loader(&Context{r}, v, name, opts...)
-}
-
-// Tracks resource reference and overrides ref Resource referrer if already
-// exists
-func (r *Resource) track(name string, ref, v any) (*refCounter, bool) {
-	if r.refs == nil {
-		r.refs = map[string]*refCounter{}
+	if err := loader(ctx, v, name, opts...); err != nil {
+		return nil, err
 	}
-	tracker, ok := r.refs[name]
-	if !ok {
-		tracker = &refCounter{
-			count: 0,
-			ref:   v,
-		}
-		r.refs[name] = tracker
+	files := make([]string, 0, len(ctx.opened))
+	for f := range ctx.opened {
+		files = append(files, f)
 	}
-	tracker.count++
-	// ref.res = tracker.res
-	runtime.SetFinalizer(ref, func(any) {
-		tracker.count--
-		if tracker.count == 0 {
-			log.Println("Releasing finalizer")
-			delete(r.refs, name)
-		}
-	})
-	return tracker, ok
+	return files, nil
 }
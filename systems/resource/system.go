@@ -2,6 +2,9 @@ package resource
 
 import "github.com/stdiopt/gorge"
 
+// SystemDef declares the resource system.
+var SystemDef = gorge.SystemDef{Name: "resource", Init: System}
+
 func System(g *gorge.Context) {
 	FromContext(g)
 }
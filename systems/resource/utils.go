@@ -18,3 +18,11 @@ func isPowerOf2(n int) bool {
 func makeEven(n int) int {
 	return n + n&1
 }
+
+func nextPowerOf2(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
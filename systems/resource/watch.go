@@ -0,0 +1,253 @@
+package resource
+
+import (
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+)
+
+// Reloader is implemented by loaded types that are updated in place when
+// their files change, src is a new value loaded with the same name and
+// options, nested resources can be updated with Replace.
+type Reloader interface {
+	Reload(res *Context, src any) error
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+type watchEntry struct {
+	// key identifies the entry for unwatch, it is the loaded value or the
+	// cached value the load was made for.
+	key   any
+	name  string
+	v     any
+	opts  []any
+	files map[string]fileStamp
+}
+
+type watcher struct {
+	mu      sync.Mutex
+	entries []*watchEntry
+	stop    chan struct{}
+}
+
+// Watch polls the files backing the resources loaded after this call every
+// interval, changed resources are reloaded in place and EventReload is
+// triggered. Only file systems that report modification times are watched,
+// like FileFS, watched resources are kept in memory until StopWatch.
+func (r *Resource) Watch(interval time.Duration) {
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+	if r.watch != nil {
+		return
+	}
+	w := &watcher{stop: make(chan struct{})}
+	r.watch = w
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				r.poll(w)
+			}
+		}
+	}()
+}
+
+// StopWatch stops watching files.
+func (r *Resource) StopWatch() {
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+	if r.watch == nil {
+		return
+	}
+	close(r.watch.stop)
+	r.watch = nil
+}
+
+// watcher returns the current watcher or nil, loads take it once so a
+// concurrent Watch or StopWatch doesn't change it midway.
+func (r *Resource) watcher() *watcher {
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+	return r.watch
+}
+
+// Replace updates dst in place with the loaded data of src, gpu references
+// are kept and Updates is incremented so the data is uploaded again.
+// dst and src must be the same type.
+func (r *Resource) Replace(dst, src any) error {
+	if reflect.TypeOf(dst) != reflect.TypeOf(src) {
+		return fmt.Errorf("replace: type mismatch %T and %T", dst, src)
+	}
+	switch d := dst.(type) {
+	case *gorge.TextureData:
+		s := src.(*gorge.TextureData)
+		gpu, updates := d.GPU, d.Updates
+		*d = *s
+		d.GPU, d.Updates = gpu, updates+1
+		event.Trigger(r.gorge, gorge.EventResourceUpdate{Resource: d})
+	case *gorge.MeshData:
+		s := src.(*gorge.MeshData)
+		gpu, updates := d.GPU, d.Updates
+		*d = *s
+		d.GPU, d.Updates = gpu, updates+1
+		event.Trigger(r.gorge, gorge.EventResourceUpdate{Resource: d})
+	case *gorge.ShaderData:
+		s := src.(*gorge.ShaderData)
+		gpu, updates := d.GPU, d.Updates
+		*d = *s
+		d.GPU, d.Updates = gpu, updates+1
+		event.Trigger(r.gorge, gorge.EventResourceUpdate{Resource: d})
+	case *gorge.Texture:
+		return r.replaceResourcer(&d.Resourcer, src.(*gorge.Texture).Resourcer)
+	case *gorge.Mesh:
+		return r.replaceResourcer(&d.Resourcer, src.(*gorge.Mesh).Resourcer)
+	case *gorge.Material:
+		return r.replaceResourcer(&d.Resourcer, src.(*gorge.Material).Resourcer)
+	case Reloader:
+		return d.Reload(&Context{resource: r}, src)
+	default:
+		return fmt.Errorf("replace: type %T is not reloadable", dst)
+	}
+	return nil
+}
+
+// replaceResourcer replaces the data in place if both resourcers are of the
+// same type, otherwise the resourcer is swapped.
+func (r *Resource) replaceResourcer(dst any, src any) error {
+	d := reflect.ValueOf(dst).Elem()
+	cur := d.Interface()
+	if cur != nil && reflect.TypeOf(cur) == reflect.TypeOf(src) {
+		if err := r.Replace(cur, src); err == nil {
+			return nil
+		}
+	}
+	if src == nil {
+		d.Set(reflect.Zero(d.Type()))
+		return nil
+	}
+	d.Set(reflect.ValueOf(src))
+	return nil
+}
+
+// watchFiles starts watching on w the files opened to load v, the watch is
+// stopped with unwatch(key).
+func (r *Resource) watchFiles(w *watcher, key any, name string, v any, opts []any, files []string) {
+	if w == nil || len(files) == 0 {
+		return
+	}
+	e := &watchEntry{
+		key:   key,
+		name:  name,
+		v:     v,
+		opts:  opts,
+		files: r.stamps(files),
+	}
+	if len(e.files) == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = append(w.entries, e)
+}
+
+// unwatch stops watching the values loaded with key, other values loaded with
+// the same name are still watched.
+func (r *Resource) unwatch(key any) {
+	w := r.watcher()
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	entries := w.entries[:0]
+	for _, e := range w.entries {
+		if e.key != key {
+			entries = append(entries, e)
+		}
+	}
+	for i := len(entries); i < len(w.entries); i++ {
+		w.entries[i] = nil
+	}
+	w.entries = entries
+}
+
+// stamps returns the current stamps of the files that can be watched.
+func (r *Resource) stamps(files []string) map[string]fileStamp {
+	ret := map[string]fileStamp{}
+	for _, f := range files {
+		if strings.HasPrefix(f, gorgeStatic) {
+			continue
+		}
+		info, err := fs.Stat(r.fs, f)
+		if err != nil || info.ModTime().IsZero() {
+			continue
+		}
+		ret[f] = fileStamp{info.ModTime(), info.Size()}
+	}
+	return ret
+}
+
+func (r *Resource) poll(w *watcher) {
+	w.mu.Lock()
+	entries := append([]*watchEntry(nil), w.entries...)
+	w.mu.Unlock()
+
+	for _, e := range entries {
+		changed := false
+		for f, st := range e.files {
+			info, err := fs.Stat(r.fs, f)
+			if err != nil {
+				// Might be in the middle of a save.
+				continue
+			}
+			if info.ModTime() != st.modTime || info.Size() != st.size {
+				changed = true
+				break
+			}
+		}
+		if changed {
+			r.reload(w, e)
+		}
+	}
+}
+
+// reload loads the entry into a new value and replaces the entry value on
+// the main loop.
+func (r *Resource) reload(w *watcher, e *watchEntry) {
+	nv := reflect.New(reflect.TypeOf(e.v).Elem()).Interface()
+	files, err := r.loadFiles(w, nv, e.name, e.opts...)
+	if err != nil {
+		// Keep the previous data, the stamps are updated so the load is
+		// retried on the next change.
+		files = make([]string, 0, len(e.files))
+		for f := range e.files {
+			files = append(files, f)
+		}
+		e.files = r.stamps(files)
+		r.gorge.Log("resource").Error("reload failed", "name", e.name, "err", err)
+		return
+	}
+	e.files = r.stamps(files)
+	r.gorge.PostFunc(func() {
+		if err := r.Replace(e.v, nv); err != nil {
+			r.Error(err)
+			return
+		}
+		r.gorge.Log("resource").Info("reloaded", "name", e.name)
+		event.Trigger(r.gorge, EventReload{Name: e.name, Resource: e.v})
+	})
+}
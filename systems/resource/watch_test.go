@@ -0,0 +1,228 @@
+package resource_test
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.glsl")
+	if err := os.WriteFile(file, []byte("#version 300 es\n// v1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	res := resource.FromContext(g)
+	res.AddFS("", resource.FileFS{BasePath: dir})
+	res.Watch(5 * time.Millisecond)
+	defer res.StopWatch()
+
+	var updates, reloads int
+	event.Handle(g, func(e gorge.EventResourceUpdate) { updates++ })
+	event.Handle(g, func(e resource.EventReload) { reloads++ })
+
+	sd := &gorge.ShaderData{}
+	if err := res.Load(sd, "test.glsl"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "#version 300 es\n// v2 changed\n"
+	if err := os.WriteFile(file, []byte(want), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(time.Second)
+	if err := os.Chtimes(file, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for reloads == 0 && time.Now().Before(deadline) {
+		gg.Update(1.0 / 60)
+		time.Sleep(time.Millisecond)
+	}
+	if string(sd.Src) != want {
+		t.Errorf("\nwant: %q\n got: %q\n", want, sd.Src)
+	}
+	if sd.Updates != 1 || updates != 1 || reloads != 1 {
+		t.Errorf("want 1 update and reload, got updates: %d, events: %d, reloads: %d",
+			sd.Updates, updates, reloads)
+	}
+}
+
+func TestWatchToggleWhileLoading(t *testing.T) {
+	gate := resetAsyncLoader()
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	res := resource.FromContext(g)
+
+	b := resource.NewBatch()
+	for _, name := range []string{"a.async", "b.async", "c.async"} {
+		b.Add(res.LoadAsync(&asyncData{}, name))
+	}
+	res.Watch(time.Millisecond)
+	close(gate)
+	res.StopWatch()
+	res.Watch(time.Millisecond)
+	defer res.StopWatch()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !b.Done() && time.Now().Before(deadline) {
+		gg.Update(1.0 / 60)
+		time.Sleep(time.Millisecond)
+	}
+	if !b.Done() || b.Err() != nil {
+		t.Fatalf("want loads done, got %v", b.Err())
+	}
+}
+
+func TestWatchRelease(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.glsl")
+	if err := os.WriteFile(file, []byte("#version 300 es\n// v1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	res := resource.FromContext(g)
+	res.AddFS("", resource.FileFS{BasePath: dir})
+	res.Watch(5 * time.Millisecond)
+	defer res.StopWatch()
+
+	var reloaded []any
+	event.Handle(g, func(e resource.EventReload) { reloaded = append(reloaded, e.Resource) })
+
+	h, err := resource.Acquire[gorge.ShaderData](res, "test.glsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := &gorge.ShaderData{}
+	if err := res.Load(sd, "test.glsl"); err != nil {
+		t.Fatal(err)
+	}
+	// Releasing the cached entry keeps watching the other value.
+	h.Release()
+
+	if err := os.WriteFile(file, []byte("#version 300 es\n// v2 changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(time.Second)
+	if err := os.Chtimes(file, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(reloaded) == 0 && time.Now().Before(deadline) {
+		gg.Update(1.0 / 60)
+		time.Sleep(time.Millisecond)
+	}
+	gg.Update(1.0 / 60)
+	if len(reloaded) != 1 || reloaded[0] != sd {
+		t.Errorf("want only the loaded value reloaded, got %v", reloaded)
+	}
+}
+
+func TestWatchReleaseRef(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(file string, n int) error
+		load  func(res *resource.Context, name string)
+	}{
+		{
+			name: "test.png",
+			write: func(file string, n int) error {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close() // nolint: errcheck
+				return png.Encode(f, image.NewGray(image.Rect(0, 0, n, n)))
+			},
+			load: func(res *resource.Context, name string) { res.Texture(name) },
+		},
+		{
+			name: "test.obj",
+			write: func(file string, n int) error {
+				src := "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"
+				for i := 1; i < n; i++ {
+					src += "f 1 2 3\n"
+				}
+				return os.WriteFile(file, []byte(src), 0o644)
+			},
+			load: func(res *resource.Context, name string) { res.Mesh(name) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, tt.name)
+			if err := tt.write(file, 1); err != nil {
+				t.Fatal(err)
+			}
+
+			var g *gorge.Context
+			gg := gorge.New(func(c *gorge.Context) { g = c })
+			if err := gg.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer gg.Close()
+
+			res := resource.FromContext(g)
+			res.AddFS("", resource.FileFS{BasePath: dir})
+			res.Watch(5 * time.Millisecond)
+			defer res.StopWatch()
+
+			var loaded, reloads int
+			event.Handle(g, func(e resource.EventLoadComplete) { loaded++ })
+			event.Handle(g, func(e resource.EventReload) { reloads++ })
+
+			tt.load(res, tt.name)
+			deadline := time.Now().Add(2 * time.Second)
+			for loaded == 0 && time.Now().Before(deadline) {
+				gg.Update(1.0 / 60)
+				time.Sleep(time.Millisecond)
+			}
+			res.Release(tt.name)
+
+			if err := tt.write(file, 2); err != nil {
+				t.Fatal(err)
+			}
+			mod := time.Now().Add(time.Second)
+			if err := os.Chtimes(file, mod, mod); err != nil {
+				t.Fatal(err)
+			}
+			deadline = time.Now().Add(50 * time.Millisecond)
+			for time.Now().Before(deadline) {
+				gg.Update(1.0 / 60)
+				time.Sleep(time.Millisecond)
+			}
+			if reloads != 0 {
+				t.Errorf("want released %s not reloaded, got %d reloads", tt.name, reloads)
+			}
+		})
+	}
+}
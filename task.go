@@ -0,0 +1,210 @@
+package gorge
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/stdiopt/gorge/core/event"
+)
+
+// Awaiter is implemented by things that can be awaited by a Task, like
+// anim.Animation or another Task.
+type Awaiter interface {
+	Done() bool
+}
+
+// Task is a coroutine like func that is stepped on the main loop, it runs on
+// its own goroutine but never in parallel with the main loop so it is safe to
+// access gorge state from it.
+type Task struct {
+	gorge *Gorge
+	owner Entity
+
+	resume chan bool
+	yield  chan struct{}
+
+	cond      func() bool
+	running   bool
+	cancelled bool
+	done      bool
+}
+
+// Done returns true when the task func returned or the task was cancelled.
+func (t *Task) Done() bool { return t.done }
+
+// Owner returns the entity that owns the task.
+func (t *Task) Owner() Entity { return t.owner }
+
+// Time returns the gorge timing state.
+func (t *Task) Time() *Time { return t.gorge.Time() }
+
+// Cancel stops the task, the deferred funcs on the task func will be called,
+// it must be called from the main loop or from a task. If the task is blocked
+// starting another task it stops once that task yields, a task cancelling
+// itself, also by removing its owner, stops at its next wait.
+func (t *Task) Cancel() {
+	if t.done {
+		return
+	}
+	if t.running {
+		t.cancelled = true
+		return
+	}
+	t.run(false)
+}
+
+// WaitUntil suspends the task until cond returns true, cond is checked once
+// per frame, it returns right away if cond is already true.
+func (t *Task) WaitUntil(cond func() bool) {
+	if t.cancelled {
+		runtime.Goexit()
+	}
+	if cond() {
+		return
+	}
+	t.cond = cond
+	t.running = false
+	t.yield <- struct{}{}
+	if !<-t.resume {
+		runtime.Goexit()
+	}
+	t.running = true
+	t.cond = nil
+}
+
+// WaitFrames suspends the task for n frames.
+func (t *Task) WaitFrames(n int) {
+	tm := t.gorge.Time()
+	target := tm.Frame() + uint64(n)
+	t.WaitUntil(func() bool { return tm.Frame() >= target })
+}
+
+// WaitSeconds suspends the task for s seconds of scaled time.
+func (t *Task) WaitSeconds(s float32) {
+	tm := t.gorge.Time()
+	target := tm.Total() + float64(s)
+	t.WaitUntil(func() bool { return tm.Total() >= target })
+}
+
+// Await suspends the task until a is done.
+func (t *Task) Await(a Awaiter) {
+	t.WaitUntil(a.Done)
+}
+
+// run resumes the task and waits for it to yield, the caller task stops if it
+// was cancelled meanwhile.
+func (t *Task) run(ok bool) {
+	s := &t.gorge.tasks
+	caller := s.current
+	s.current = t
+	t.running = ok
+	t.resume <- ok
+	<-t.yield
+	s.current = caller
+	if caller != nil && caller.cancelled {
+		runtime.Goexit()
+	}
+}
+
+// WaitEvent suspends the task until an event of type T for which filter
+// returns true is triggered, filter can be nil to accept any event.
+func WaitEvent[T any](t *Task, filter func(T) bool) T {
+	var got *T
+	sub := event.Handle(t.gorge, func(e T) {
+		if got == nil && (filter == nil || filter(e)) {
+			got = &e
+		}
+	})
+	defer sub.Cancel()
+	t.WaitUntil(func() bool { return got != nil })
+	return *got
+}
+
+type scheduler struct {
+	tasks    []*Task
+	stepping bool
+	// current is the task running, nil if it is the main loop.
+	current *Task
+}
+
+func (s *scheduler) add(t *Task) {
+	s.tasks = append(s.tasks, t)
+}
+
+// step resumes every task waiting on a satisfied condition.
+func (s *scheduler) step() {
+	s.stepping = true
+	// Tasks started while stepping are not stepped until next frame.
+	for _, t := range s.tasks {
+		if t.done || (t.cond != nil && !t.cond()) {
+			continue
+		}
+		t.run(true)
+	}
+	s.stepping = false
+	s.sweep()
+}
+
+// cancelOwner cancels tasks owned by e.
+func (s *scheduler) cancelOwner(e Entity) {
+	for _, t := range s.tasks {
+		if t.owner != nil && t.owner == e {
+			t.Cancel()
+		}
+	}
+	s.sweep()
+}
+
+func (s *scheduler) cancelAll() {
+	for _, t := range s.tasks {
+		t.Cancel()
+	}
+	s.sweep()
+}
+
+func (s *scheduler) sweep() {
+	if s.stepping {
+		return
+	}
+	n := 0
+	for _, t := range s.tasks {
+		if !t.done {
+			s.tasks[n] = t
+			n++
+		}
+	}
+	for i := n; i < len(s.tasks); i++ {
+		s.tasks[i] = nil
+	}
+	s.tasks = s.tasks[:n]
+}
+
+// Go starts a task owned by owner, the task runs right away until the first
+// wait and then it is stepped after EventUpdate on each frame, the task is
+// cancelled when the owner entity is removed, owner can be nil.
+// It must be called from the main loop.
+func (g *Gorge) Go(owner Entity, fn func(t *Task)) *Task {
+	t := &Task{
+		gorge:  g,
+		owner:  owner,
+		resume: make(chan bool),
+		yield:  make(chan struct{}),
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				g.Error(fmt.Errorf("task panic: %v", r))
+			}
+			t.running = false
+			t.done = true
+			t.yield <- struct{}{}
+		}()
+		if !<-t.resume {
+			return
+		}
+		fn(t)
+	}()
+	g.tasks.add(t)
+	t.run(true)
+	return t
+}
@@ -0,0 +1,168 @@
+package gorge_test
+
+import (
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+)
+
+type eventPing struct{ n int }
+
+func TestTask(t *testing.T) {
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	var steps []string
+	owner := &struct{ name string }{"owner"}
+
+	seq := g.Go(nil, func(tk *gorge.Task) {
+		steps = append(steps, "start")
+		tk.WaitFrames(2)
+		steps = append(steps, "frames")
+		tk.WaitSeconds(1)
+		steps = append(steps, "seconds")
+		e := gorge.WaitEvent(tk, func(e eventPing) bool { return e.n == 2 })
+		steps = append(steps, "event", string(rune('0'+e.n)))
+	})
+	owned := g.Go(owner, func(tk *gorge.Task) {
+		defer func() { steps = append(steps, "owned deferred") }()
+		tk.WaitUntil(func() bool { return false })
+		steps = append(steps, "never")
+	})
+
+	if len(steps) != 1 || steps[0] != "start" {
+		t.Fatalf("task should run until the first wait, got %v", steps)
+	}
+	g.Update(0.5)
+	g.Update(0.5)
+	if seq.Done() {
+		t.Fatal("task should not be done")
+	}
+	g.Update(0.5) // seconds
+	g.Update(0.5)
+	event.Trigger(g, eventPing{1})
+	g.Update(0.5)
+	event.Trigger(g, eventPing{2})
+	g.Update(0.5)
+	if !seq.Done() {
+		t.Fatalf("task should be done, got %v", steps)
+	}
+
+	g.Remove(owner)
+	if !owned.Done() {
+		t.Fatal("owned task should be cancelled")
+	}
+
+	awaiter := g.Go(nil, func(tk *gorge.Task) {
+		tk.Await(seq)
+		steps = append(steps, "awaited")
+	})
+	if !awaiter.Done() {
+		t.Fatal("awaiter should be done")
+	}
+
+	want := []string{
+		"start", "frames", "seconds", "event", "2",
+		"owned deferred", "awaited",
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("\nwant: %v\n got: %v\n", want, steps)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Fatalf("\nwant: %v\n got: %v\n", want, steps)
+		}
+	}
+}
+
+func TestTaskCancelParent(t *testing.T) {
+	gg := gorge.New()
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	var steps []string
+	parent := gg.Go(nil, func(a *gorge.Task) {
+		defer func() { steps = append(steps, "parent deferred") }()
+		gg.Go(nil, func(b *gorge.Task) {
+			a.Cancel()
+			steps = append(steps, "child")
+			b.WaitFrames(1)
+			steps = append(steps, "child resumed")
+		})
+		steps = append(steps, "parent never")
+	})
+	if !parent.Done() {
+		t.Fatal("parent should be cancelled")
+	}
+	gg.Update(0.1)
+
+	want := []string{"child", "parent deferred", "child resumed"}
+	if !equalStrings(steps, want) {
+		t.Fatalf("\nwant: %v\n got: %v\n", want, steps)
+	}
+}
+
+func TestTaskPanic(t *testing.T) {
+	gg := gorge.New()
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	var errs []error
+	gg.HandleError(func(err error) { errs = append(errs, err) })
+	tk := gg.Go(nil, func(tk *gorge.Task) {
+		tk.WaitFrames(1)
+		panic("boom")
+	})
+	gg.Update(0.1)
+	if !tk.Done() {
+		t.Fatal("task should be done")
+	}
+	if len(errs) != 1 {
+		t.Fatalf("want 1 error, got %v", errs)
+	}
+}
+
+func TestTaskRemoveOwner(t *testing.T) {
+	gg := gorge.New()
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	var removed []gorge.Entity
+	event.Handle(gg, func(e gorge.EventRemoveEntity) { removed = append(removed, e.Entity) })
+
+	a, b := &struct{ name string }{"a"}, &struct{ name string }{"b"}
+	owner := &gorge.Container{a, b}
+	gg.Add(owner)
+
+	var steps []string
+	tk := gg.Go(owner, func(tk *gorge.Task) {
+		defer func() { steps = append(steps, "deferred") }()
+		tk.WaitFrames(1)
+		gg.Remove(owner)
+		steps = append(steps, "removed")
+		tk.WaitFrames(1)
+		steps = append(steps, "never")
+	})
+	gg.Update(0.1)
+	if !tk.Done() {
+		t.Fatal("task should be cancelled")
+	}
+	if len(removed) != 3 {
+		t.Fatalf("want 3 removed entities, got %v", removed)
+	}
+	want := []string{"removed", "deferred"}
+	if !equalStrings(steps, want) {
+		t.Fatalf("\nwant: %v\n got: %v\n", want, steps)
+	}
+}
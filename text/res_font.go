@@ -1,6 +1,7 @@
 package text
 
 import (
+	"encoding/json"
 	"fmt"
 	"image"
 	"image/color"
@@ -18,6 +19,31 @@ import (
 
 func init() {
 	resource.Register((*Font)(nil), ".ttf", fontLoader)
+	resource.RegisterAsset("font", (*Font)(nil), fontAssetOptions)
+}
+
+// fontAssetOptions decodes manifest options, i.e:
+//
+//	{"resolution": 512, "chars": "abc", "foreground": [1, 1, 1, 1]}
+func fontAssetOptions(raw json.RawMessage) ([]any, error) {
+	var o struct {
+		Resolution int      `json:"resolution"`
+		Chars      string   `json:"chars"`
+		Background *gm.Vec4 `json:"background"`
+		Foreground *gm.Vec4 `json:"foreground"`
+	}
+	if err := json.Unmarshal(raw, &o); err != nil {
+		return nil, err
+	}
+	opt := FontOptions{
+		Resolution: o.Resolution,
+		Background: o.Background,
+		Foreground: o.Foreground,
+	}
+	if o.Chars != "" {
+		opt.Chars = []rune(o.Chars)
+	}
+	return []any{opt}, nil
 }
 
 var commonChars = []rune("`" + `
@@ -35,11 +61,14 @@ func fontLoader(res *resource.Context, v any, name string, opts ...any) error {
 	opt := FontOptions{}
 
 	for _, o := range opts {
-		fontOpt, ok := o.(FontOptionsFunc)
-		if !ok {
-			return fmt.Errorf("wront options type")
+		switch o := o.(type) {
+		case FontOptionsFunc:
+			o(&opt)
+		case FontOptions:
+			opt = o
+		default:
+			return fmt.Errorf("wrong options type: %T", o)
 		}
-		fontOpt(&opt)
 	}
 
 	bg := color.Color(color.RGBA{0, 0, 0, 0})
@@ -210,3 +239,21 @@ func calcSize(sz float32, n int) float32 {
 
 	return l
 }
+
+// Reload implements resource.Reloader, the atlas texture is updated in place.
+func (f *Font) Reload(res *resource.Context, src any) error {
+	nf, ok := src.(*Font)
+	if !ok {
+		return fmt.Errorf("font reload: invalid source %T", src)
+	}
+	if f.Texture == nil || nf.Texture == nil {
+		f.Texture = nf.Texture
+	} else if err := res.Replace(f.Texture, nf.Texture); err != nil {
+		return err
+	}
+	f.Face = nf.Face
+	f.Glyphs = nf.Glyphs
+	f.SpaceAdv = nf.SpaceAdv
+	f.Size = nf.Size
+	return nil
+}
@@ -98,6 +98,40 @@ func (f TextureFormat) String() string {
 		return "Gray16"
 	case TextureFormatRGB32F:
 		return "RGB32F"
+	case TextureFormatBC1:
+		return "BC1"
+	case TextureFormatBC2:
+		return "BC2"
+	case TextureFormatBC3:
+		return "BC3"
+	case TextureFormatBC4:
+		return "BC4"
+	case TextureFormatBC5:
+		return "BC5"
+	case TextureFormatBC6H:
+		return "BC6H"
+	case TextureFormatBC7:
+		return "BC7"
+	case TextureFormatETC2RGB:
+		return "ETC2RGB"
+	case TextureFormatETC2RGBA:
+		return "ETC2RGBA"
+	case TextureFormatASTC4x4:
+		return "ASTC4x4"
+	case TextureFormatASTC6x6:
+		return "ASTC6x6"
+	case TextureFormatASTC8x8:
+		return "ASTC8x8"
+	case TextureFormatR16F:
+		return "R16F"
+	case TextureFormatRG16F:
+		return "RG16F"
+	case TextureFormatRGBA16F:
+		return "RGBA16F"
+	case TextureFormatR32F:
+		return "R32F"
+	case TextureFormatRGBA32F:
+		return "RGBA32F"
 	default:
 		return "Unknown"
 	}
@@ -110,8 +144,81 @@ const (
 	TextureFormatGray
 	TextureFormatGray16
 	TextureFormatRGB32F
+
+	// Block compressed formats, PixelData holds the blocks row by row.
+	TextureFormatBC1
+	TextureFormatBC2
+	TextureFormatBC3
+	TextureFormatBC4
+	TextureFormatBC5
+	TextureFormatBC6H
+	TextureFormatBC7
+	TextureFormatETC2RGB
+	TextureFormatETC2RGBA
+	TextureFormatASTC4x4
+	TextureFormatASTC6x6
+	TextureFormatASTC8x8
+
+	// Floating point formats, PixelData holds little endian 16 bit half
+	// floats or 32 bit floats per channel.
+	TextureFormatR16F
+	TextureFormatRG16F
+	TextureFormatRGBA16F
+	TextureFormatR32F
+	TextureFormatRGBA32F
 )
 
+// Compressed returns true if the format is block compressed.
+func (f TextureFormat) Compressed() bool {
+	return f >= TextureFormatBC1 && f <= TextureFormatASTC8x8
+}
+
+// Float returns true if the format channels are floating point.
+func (f TextureFormat) Float() bool {
+	return f == TextureFormatRGB32F || (f >= TextureFormatR16F && f <= TextureFormatRGBA32F)
+}
+
+// BlockSize returns the block dimensions in pixels and the block size in
+// bytes, uncompressed formats have 1x1 blocks of a pixel.
+func (f TextureFormat) BlockSize() (w, h, size int) {
+	switch f {
+	case TextureFormatRGBA:
+		return 1, 1, 4
+	case TextureFormatRGB:
+		return 1, 1, 3
+	case TextureFormatGray:
+		return 1, 1, 1
+	case TextureFormatGray16, TextureFormatR16F:
+		return 1, 1, 2
+	case TextureFormatRG16F, TextureFormatR32F:
+		return 1, 1, 4
+	case TextureFormatRGBA16F:
+		return 1, 1, 8
+	case TextureFormatRGB32F:
+		return 1, 1, 12
+	case TextureFormatRGBA32F:
+		return 1, 1, 16
+	case TextureFormatBC1, TextureFormatBC4, TextureFormatETC2RGB:
+		return 4, 4, 8
+	case TextureFormatBC2, TextureFormatBC3, TextureFormatBC5,
+		TextureFormatBC6H, TextureFormatBC7, TextureFormatETC2RGBA,
+		TextureFormatASTC4x4:
+		return 4, 4, 16
+	case TextureFormatASTC6x6:
+		return 6, 6, 16
+	case TextureFormatASTC8x8:
+		return 8, 8, 16
+	default:
+		return 1, 1, 4
+	}
+}
+
+// DataSize returns the size in bytes of a width x height image in the format.
+func (f TextureFormat) DataSize(width, height int) int {
+	bw, bh, sz := f.BlockSize()
+	return ((width + bw - 1) / bw) * ((height + bh - 1) / bh) * sz
+}
+
 // TextureWrap for texture
 type TextureWrap int
 
@@ -152,7 +259,18 @@ type TextureData struct {
 	Format        TextureFormat
 	Width, Height int
 	PixelData     []byte
-	Updates       int
+	// Mips are pre-built mip levels after PixelData, each level is half the
+	// size of the previous one, if empty the renderer generates them for
+	// uncompressed formats.
+	Mips [][]byte
+	// SRGB uploads color data with an sRGB internal format so the gpu
+	// decodes samples to linear, the default shaders decode albedo
+	// themselves and expect it unset.
+	SRGB bool
+	// NoMipmaps disables mip levels, only PixelData is uploaded and
+	// sampled.
+	NoMipmaps bool
+	Updates   int
 }
 
 // Resource implements TextureResourcer.
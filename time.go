@@ -0,0 +1,69 @@
+package gorge
+
+// Time tracks frame timing, it is advanced on each Gorge.Update and the
+// scaled delta is the one sent on update events.
+type Time struct {
+	delta         float32
+	unscaledDelta float32
+	total         float64
+	unscaledTotal float64
+	frame         uint64
+	scale         float32
+	paused        bool
+	step          bool
+}
+
+func newTime() Time {
+	return Time{scale: 1}
+}
+
+// Delta returns the scaled delta time of the current frame in seconds, it is
+// 0 while paused.
+func (t *Time) Delta() float32 { return t.delta }
+
+// UnscaledDelta returns the real delta time of the current frame in seconds.
+func (t *Time) UnscaledDelta() float32 { return t.unscaledDelta }
+
+// Total returns the scaled time since start in seconds.
+func (t *Time) Total() float64 { return t.total }
+
+// UnscaledTotal returns the real time since start in seconds.
+func (t *Time) UnscaledTotal() float64 { return t.unscaledTotal }
+
+// Frame returns the current frame number.
+func (t *Time) Frame() uint64 { return t.frame }
+
+// Scale returns the time scale.
+func (t *Time) Scale() float32 { return t.scale }
+
+// SetScale sets the time scale, 1 is realtime and 0.5 is half speed.
+func (t *Time) SetScale(s float32) { t.scale = s }
+
+// Pause pauses the scaled time.
+func (t *Time) Pause() { t.paused = true }
+
+// Resume resumes the scaled time.
+func (t *Time) Resume() { t.paused = false }
+
+// Paused returns true if the time is paused.
+func (t *Time) Paused() bool { return t.paused }
+
+// Step advances the next frame while paused.
+func (t *Time) Step() { t.step = true }
+
+// advance updates the time with the real delta and returns the scaled delta.
+func (t *Time) advance(dt float32) float32 {
+	t.frame++
+	t.unscaledDelta = dt
+	t.unscaledTotal += float64(dt)
+
+	sdt := dt * t.scale
+	if t.paused && !t.step {
+		sdt = 0
+	}
+	t.step = false
+
+	t.delta = sdt
+	t.total += float64(sdt)
+	return sdt
+}
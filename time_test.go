@@ -0,0 +1,152 @@
+package gorge_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+)
+
+func TestTime(t *testing.T) {
+	type frame struct {
+		dt     float32
+		before func(*gorge.Time)
+	}
+	type want struct {
+		delta         float32
+		total         float64
+		unscaledTotal float64
+		frame         uint64
+	}
+	tests := []struct {
+		name   string
+		frames []frame
+		want   want
+	}{
+		{
+			name:   "realtime",
+			frames: []frame{{dt: 0.5}, {dt: 0.25}},
+			want:   want{delta: 0.25, total: 0.75, unscaledTotal: 0.75, frame: 2},
+		},
+		{
+			name: "scaled",
+			frames: []frame{
+				{dt: 1, before: func(t *gorge.Time) { t.SetScale(0.5) }},
+				{dt: 1},
+			},
+			want: want{delta: 0.5, total: 1, unscaledTotal: 2, frame: 2},
+		},
+		{
+			name: "paused",
+			frames: []frame{
+				{dt: 1},
+				{dt: 1, before: func(t *gorge.Time) { t.Pause() }},
+				{dt: 1},
+			},
+			want: want{delta: 0, total: 1, unscaledTotal: 3, frame: 3},
+		},
+		{
+			name: "step while paused",
+			frames: []frame{
+				{dt: 1, before: func(t *gorge.Time) { t.Pause() }},
+				{dt: 1, before: func(t *gorge.Time) { t.Step() }},
+				{dt: 1},
+			},
+			want: want{delta: 0, total: 1, unscaledTotal: 3, frame: 3},
+		},
+		{
+			name: "resume",
+			frames: []frame{
+				{dt: 1, before: func(t *gorge.Time) { t.Pause() }},
+				{dt: 1, before: func(t *gorge.Time) { t.Resume() }},
+			},
+			want: want{delta: 1, total: 1, unscaledTotal: 2, frame: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gg := gorge.New()
+			if err := gg.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer gg.Close()
+			gg.SetFixedStep(0)
+
+			var updateDelta float32
+			event.Handle(gg, func(e gorge.EventUpdate) {
+				updateDelta = e.DeltaTime()
+			})
+			tm := gg.Time()
+			for _, f := range tt.frames {
+				if f.before != nil {
+					f.before(tm)
+				}
+				gg.Update(f.dt)
+			}
+			got := want{
+				delta:         tm.Delta(),
+				total:         tm.Total(),
+				unscaledTotal: tm.UnscaledTotal(),
+				frame:         tm.Frame(),
+			}
+			if got != tt.want {
+				t.Errorf("\nwant: %+v\n got: %+v\n", tt.want, got)
+			}
+			if updateDelta != tt.want.delta {
+				t.Errorf("want update delta %v, got %v", tt.want.delta, updateDelta)
+			}
+		})
+	}
+}
+
+func TestTimeAnimation(t *testing.T) {
+	gg := gorge.New()
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	var cur float32
+	a := gg.NewAnimation()
+	a.SetScale(time.Second)
+	a.AddChannel(timeChannel{&cur, 10})
+	a.Start()
+
+	gg.Time().SetScale(0.5)
+	gg.Update(1)
+	a.Update()
+	if cur != 0.5 {
+		t.Errorf("scaled animation want 0.5, got %v", cur)
+	}
+
+	gg.Time().Pause()
+	gg.Update(1)
+	a.Update()
+	if cur != 0.5 {
+		t.Errorf("paused animation want 0.5, got %v", cur)
+	}
+
+	// Another instance has its own clock.
+	other := gorge.New()
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	b := other.NewAnimation()
+	b.AddChannel(timeChannel{&cur, 10})
+	b.Start()
+	other.Update(1)
+	b.Update()
+	if cur != 1 {
+		t.Errorf("other instance animation want 1, got %v", cur)
+	}
+}
+
+type timeChannel struct {
+	cur *float32
+	end float32
+}
+
+func (c timeChannel) Update(t float32) { *c.cur = t }
+func (c timeChannel) EndTime() float32 { return c.end }
@@ -1,20 +1,28 @@
 package gorge
 
 import (
+	"errors"
+
 	"github.com/stdiopt/gorge/math/gm"
 )
 
-// TransformBuilds tracks the numbers of transforms for performance purposes
-var (
-	TransformBuilds    = 0
-	TransformBuildSave = 0
-)
+// ErrParentCycle is returned by SetParent when the parent is the transform
+// itself or one of its descendants.
+var ErrParentCycle = errors.New("transform parent cycle")
+
+// TransformStats counts world matrix builds for performance purposes.
+type TransformStats struct {
+	// Builds is the number of world matrices built.
+	Builds int
+	// Saved is the number of world matrices reused from cache.
+	Saved int
+}
 
 type (
 	// ParentGetter interface for a parent getter.
 	ParentGetter interface{ Parent() Matrixer }
 	// ParentSetter interface that Sets a parent.
-	ParentSetter interface{ SetParent(Matrixer) }
+	ParentSetter interface{ SetParent(Matrixer) error }
 	// Transformer interface for the transform component implementer.
 	Transformer interface {
 		Mat4() gm.Mat4
@@ -37,13 +45,17 @@ type affine struct {
 // TransformComponent Thing
 type TransformComponent struct {
 	affine
-	parent Matrixer
+	parent   Matrixer
+	children []*TransformComponent
 
 	cached         affine
 	cachedWorldMat gm.Mat4
 
-	updates      int
-	parentUpdate int
+	// dirty is set when the world matrix must be rebuilt, setters mark the
+	// descendants too so a dirty transform has only dirty descendants.
+	dirty   bool
+	updates int
+	stats   *TransformStats
 }
 
 // TransformIdent returns a transform identity copy.
@@ -69,31 +81,14 @@ func NewTransformComponent() *TransformComponent {
 // Transform component
 func (c *TransformComponent) Transform() *TransformComponent { return c }
 
-// Updated returns true if the transform or relative parents were updated.
+// Updated returns true if the transform or relative parents changed since
+// the last world matrix build, parents are only tracked through setters and
+// SetParent.
 func (c *TransformComponent) Updated() bool {
-	// Should force update if it is 0
-	if c.updates == 0 {
-		return true
-	}
-	if c.cached != c.affine {
-		return true
-	}
-	switch v := c.parent.(type) {
-	case interface{ Transform() *TransformComponent }:
-		t := v.Transform()
-		if t.Updated() {
-			return true
-		}
-		if c.parentUpdate != t.Updates() {
-			return true
-		}
-	case Matrixer:
-		return true
-	}
-	return false
+	return c.needsBuild()
 }
 
-// Updates return the current number of udpates.
+// Updates return the current number of world matrix builds.
 func (c *TransformComponent) Updates() int {
 	return c.updates
 }
@@ -108,12 +103,19 @@ func (c *TransformComponent) Mat4() gm.Mat4 {
 	if c == nil {
 		return gm.M4Ident()
 	}
-	if !c.Updated() {
-		TransformBuildSave++
+	if !c.needsBuild() {
+		if c.stats != nil {
+			c.stats.Saved++
+		}
 		return c.cachedWorldMat
 	}
-
-	TransformBuilds++
+	if c.stats != nil {
+		c.stats.Builds++
+	}
+	// Fields changed directly are only seen here, children are marked now.
+	if !c.dirty {
+		c.invalidateChildren()
+	}
 
 	// TODO: {lpf} Could have a local matrix cache as well instead of recalculating
 
@@ -121,18 +123,71 @@ func (c *TransformComponent) Mat4() gm.Mat4 {
 	c.cachedWorldMat = c.cachedWorldMat.Mul(c.Rotation.Mat4())
 	c.cachedWorldMat = c.cachedWorldMat.Mul(gm.Scale3D(c.Scale[0], c.Scale[1], c.Scale[2]))
 	if c.parent != nil {
-		// t := c.parent.Transform()
 		c.cachedWorldMat = c.parent.Mat4().Mul(c.cachedWorldMat)
-
-		if t, ok := c.parent.(interface{ Updates() int }); ok {
-			c.parentUpdate = t.Updates()
-		}
 	}
 	c.cached = c.affine
+	c.dirty = false
 	c.updates++
 	return c.cachedWorldMat
 }
 
+// needsBuild returns true if the world matrix was never built, the local
+// transform changed or the transform was invalidated by a parent, parents
+// that are not transforms can't notify so they always rebuild.
+func (c *TransformComponent) needsBuild() bool {
+	return c.updates == 0 || c.dirty || c.cached != c.affine ||
+		(c.parent != nil && c.parentTransform() == nil)
+}
+
+// invalidate marks the transform and its descendants to be rebuilt.
+func (c *TransformComponent) invalidate() {
+	if c.dirty {
+		return
+	}
+	c.dirty = true
+	c.invalidateChildren()
+}
+
+// invalidateChildren marks the descendants to be rebuilt.
+func (c *TransformComponent) invalidateChildren() {
+	for _, ch := range c.children {
+		ch.invalidate()
+	}
+}
+
+// Children returns the transforms parented to this transform, children
+// removed from gorge are not listed until added again.
+func (c *TransformComponent) Children() []*TransformComponent {
+	return c.children
+}
+
+// Walk calls fn for each descendant transform depth first, if fn returns false
+// the descendants of that transform are skipped.
+func (c *TransformComponent) Walk(fn func(t *TransformComponent) bool) {
+	for _, ch := range c.children {
+		if fn(ch) {
+			ch.Walk(fn)
+		}
+	}
+}
+
+// IsDescendantOf returns true if t is an ancestor of this transform.
+func (c *TransformComponent) IsDescendantOf(t *TransformComponent) bool {
+	for p := c.parentTransform(); p != nil; p = p.parentTransform() {
+		if p == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *TransformComponent) parentTransform() *TransformComponent {
+	if p, ok := c.parent.(interface{ Transform() *TransformComponent }); ok {
+		return p.Transform()
+	}
+	return nil
+}
+
 // SetMat4Decompose decomposes a 4x4 into position, rotation and scale.
 // https://answers.unity.com/questions/402280/how-to-decompose-a-trs-matrix.html
 func (c *TransformComponent) SetMat4Decompose(m gm.Mat4) {
@@ -144,11 +199,84 @@ func (c *TransformComponent) SetMat4Decompose(m gm.Mat4) {
 		m.Col(1).Len(),
 		m.Col(2).Len(),
 	}
+	c.invalidate()
 }
 
-// SetParent of the transform
-func (c *TransformComponent) SetParent(p Matrixer) {
+// SetParent of the transform, the local transform is kept.
+// It returns ErrParentCycle if p is this transform or one of its descendants,
+// the hierarchy is left unchanged.
+func (c *TransformComponent) SetParent(p Matrixer) error {
+	var t *TransformComponent
+	if pt, ok := p.(interface{ Transform() *TransformComponent }); ok {
+		t = pt.Transform()
+	}
+	if t != nil && (t == c || t.IsDescendantOf(c)) {
+		return ErrParentCycle
+	}
+	if old := c.parentTransform(); old != nil {
+		old.removeChild(c)
+	}
 	c.parent = p
+	c.invalidate()
+	if t != nil {
+		t.children = append(t.children, c)
+		if c.stats == nil {
+			c.stats = t.stats
+		}
+	}
+	return nil
+}
+
+// SetParentKeepWorld sets the parent changing the local transform so the
+// world transform is kept.
+func (c *TransformComponent) SetParentKeepWorld(p Matrixer) error {
+	world := c.Mat4()
+	if err := c.SetParent(p); err != nil {
+		return err
+	}
+	if p != nil {
+		world = p.Mat4().Inv().Mul(world)
+	}
+	c.SetMat4Decompose(world)
+	return nil
+}
+
+// Detach removes the parent, if keepWorld is true the local transform is
+// changed to keep the world transform.
+func (c *TransformComponent) Detach(keepWorld bool) {
+	if keepWorld {
+		c.SetParentKeepWorld(nil) // nolint: errcheck
+		return
+	}
+	c.SetParent(nil) // nolint: errcheck
+}
+
+// DetachChildren removes this transform as parent of every child.
+func (c *TransformComponent) DetachChildren(keepWorld bool) {
+	for len(c.children) > 0 {
+		c.children[len(c.children)-1].Detach(keepWorld)
+	}
+}
+
+func (c *TransformComponent) addChild(ch *TransformComponent) {
+	for _, v := range c.children {
+		if v == ch {
+			return
+		}
+	}
+	c.children = append(c.children, ch)
+}
+
+func (c *TransformComponent) removeChild(ch *TransformComponent) {
+	for i, v := range c.children {
+		if v != ch {
+			continue
+		}
+		copy(c.children[i:], c.children[i+1:])
+		c.children[len(c.children)-1] = nil
+		c.children = c.children[:len(c.children)-1]
+		return
+	}
 }
 
 // Set full transform
@@ -161,6 +289,7 @@ func (c *TransformComponent) Set(position gm.Vec3, euler gm.Vec3, scale gm.Vec3)
 // SetPositionv sets the current position on the world with a vector
 func (c *TransformComponent) SetPositionv(pos gm.Vec3) {
 	c.Position = pos
+	c.invalidate()
 }
 
 // SetEulerv sets the euler angles as a vector
@@ -169,6 +298,7 @@ func (c *TransformComponent) SetEulerv(angles gm.Vec3) {
 		angles[2], angles[1], angles[0],
 		gm.ZYX,
 	)
+	c.invalidate()
 }
 
 // SetPosition sets the current position on the world
@@ -179,6 +309,7 @@ func (c *TransformComponent) SetPosition(x, y, z float32) {
 // SetRotation set a quaternion
 func (c *TransformComponent) SetRotation(v gm.Quat) {
 	c.Rotation = v
+	c.invalidate()
 }
 
 // SetEuler convenient func
@@ -199,18 +330,20 @@ func (c *TransformComponent) SetScale(sz ...float32) {
 	default:
 		panic("wrong number of params")
 	}
+	c.invalidate()
 }
 
 // SetScalev just sets the scale
 func (c *TransformComponent) SetScalev(scale gm.Vec3) {
 	c.Scale = scale
+	c.invalidate()
 }
 
 // LookAt resets the local rotation to lookAt
 // if 1 param is used, we will Use default gm.Up() +Y vector
 func (c *TransformComponent) LookAt(target Matrixer, v ...gm.Vec3) {
 	up := gm.Vec3{0, 1, 0}
-	if len(v) > 1 {
+	if len(v) > 0 {
 		up = v[0]
 	}
 	pos := target.Mat4().Col(3)
@@ -219,11 +352,24 @@ func (c *TransformComponent) LookAt(target Matrixer, v ...gm.Vec3) {
 	c.SetRotation(gm.QLookAt(dir, up))
 }
 
+// LookAtWorld rotates the transform so its forward points to the target world
+// position, the world +Y is used as up if v is not passed.
+func (c *TransformComponent) LookAtWorld(target Matrixer, v ...gm.Vec3) {
+	up := gm.Vec3{0, 1, 0}
+	if len(v) > 0 {
+		up = v[0]
+	}
+	pos := target.Mat4().Col(3).Vec3()
+
+	dir := c.WorldPosition().Sub(pos).Normalize()
+	c.SetWorldRotation(gm.QLookAt(dir, up))
+}
+
 // LookAtPosition resets the local rotation to lookAt
 // if 1 param is used, we will Use default gm.Up() +Y vector
 func (c *TransformComponent) LookAtPosition(target gm.Vec3, v ...gm.Vec3) {
 	up := gm.Vec3{0, 1, 0}
-	if len(v) > 1 {
+	if len(v) > 0 {
 		up = v[0]
 	}
 
@@ -234,7 +380,7 @@ func (c *TransformComponent) LookAtPosition(target gm.Vec3, v ...gm.Vec3) {
 // LookDir looks at direction
 func (c *TransformComponent) LookDir(dir gm.Vec3, v ...gm.Vec3) {
 	up := gm.Vec3{0, 1, 0}
-	if len(v) > 1 {
+	if len(v) > 0 {
 		up = v[0]
 	}
 	c.SetRotation(gm.QLookAt(dir, up))
@@ -246,11 +392,13 @@ func (c *TransformComponent) LookDir(dir gm.Vec3, v ...gm.Vec3) {
 // Translate the thing
 func (c *TransformComponent) Translate(x, y, z float32) {
 	c.Position = c.Position.Add(gm.Vec3{x, y, z})
+	c.invalidate()
 }
 
 // Translatev translate by vector
 func (c *TransformComponent) Translatev(axis gm.Vec3) {
 	c.Position = c.Position.Add(axis)
+	c.invalidate()
 }
 
 // Rotate axis
@@ -259,6 +407,7 @@ func (c *TransformComponent) Rotate(x, y, z float32) {
 		x, y, z,
 		gm.XYZ,
 	))
+	c.invalidate()
 }
 
 // Rotatev axis by vector
@@ -267,6 +416,7 @@ func (c *TransformComponent) Rotatev(angles gm.Vec3) {
 		angles[0], angles[1], angles[2],
 		gm.XYZ,
 	))
+	c.invalidate()
 }
 
 // WorldPosition returns world position
@@ -274,9 +424,54 @@ func (c *TransformComponent) WorldPosition() gm.Vec3 {
 	return c.Mat4().Col(3).Vec3()
 }
 
+// SetWorldPosition sets the local position so the transform is placed at the
+// world position p.
+func (c *TransformComponent) SetWorldPosition(p gm.Vec3) {
+	if c.parent != nil {
+		p = c.parent.Mat4().Inv().MulV4(p.Vec4(1)).Vec3()
+	}
+	c.Position = p
+	c.invalidate()
+}
+
 // WorldRotation returns world rotation
 func (c *TransformComponent) WorldRotation() gm.Quat {
-	return c.Mat4().Quat()
+	if t := c.parentTransform(); t != nil {
+		return t.WorldRotation().Mul(c.Rotation)
+	}
+	if c.parent != nil {
+		return c.Mat4().Quat()
+	}
+	return c.Rotation
+}
+
+// SetWorldRotation sets the local rotation so the transform world rotation
+// is q.
+func (c *TransformComponent) SetWorldRotation(q gm.Quat) {
+	switch {
+	case c.parentTransform() != nil:
+		q = c.parentTransform().WorldRotation().Inv().Mul(q)
+	case c.parent != nil:
+		q = c.parent.Mat4().Quat().Inv().Mul(q)
+	}
+	c.Rotation = q
+	c.invalidate()
+}
+
+// TransformPoint transforms a point from local space to world space.
+func (c *TransformComponent) TransformPoint(p gm.Vec3) gm.Vec3 {
+	return c.Mat4().MulV4(p.Vec4(1)).Vec3()
+}
+
+// InverseTransformPoint transforms a point from world space to local space.
+func (c *TransformComponent) InverseTransformPoint(p gm.Vec3) gm.Vec3 {
+	return c.Inv().MulV4(p.Vec4(1)).Vec3()
+}
+
+// TransformDir transforms a direction from local space to world space, it is
+// not affected by position.
+func (c *TransformComponent) TransformDir(d gm.Vec3) gm.Vec3 {
+	return c.Mat4().MulV4(d.Vec4(0)).Vec3()
 }
 
 // Left returns World left of the transform
@@ -0,0 +1,146 @@
+package gorge_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+)
+
+func TestTransformHierarchy(t *testing.T) {
+	near := func(a, b gm.Vec3) bool {
+		return a.Sub(b).Len() < 1e-4
+	}
+	var ctx *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { ctx = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	root := gorge.NewTransformComponent()
+	child := gorge.NewTransformComponent()
+	leaf := gorge.NewTransformComponent()
+	child.SetParent(root)
+	leaf.SetParent(child)
+	ctx.Add(root, child, leaf)
+
+	child.SetPosition(1, 0, 0)
+	leaf.SetPosition(0, 1, 0)
+	if want, got := (gm.Vec3{1, 1, 0}), leaf.WorldPosition(); !near(got, want) {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+
+	// Setters on a grand parent mark the descendants.
+	root.SetPosition(0, 0, 5)
+	if !leaf.Updated() {
+		t.Error("want leaf updated by the grand parent setter")
+	}
+	if want, got := (gm.Vec3{1, 1, 5}), leaf.WorldPosition(); !near(got, want) {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+	// Direct field changes are propagated once the transform is rebuilt.
+	root.Position = gm.Vec3{0, 0, 6}
+	root.Mat4()
+	if want, got := (gm.Vec3{1, 1, 6}), leaf.WorldPosition(); !near(got, want) {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+	var walked int
+	root.Walk(func(*gorge.TransformComponent) bool { walked++; return true })
+	if walked != 2 {
+		t.Errorf("want 2 descendants, got %d", walked)
+	}
+
+	root.SetEuler(0, gm.Pi/2, 0)
+	p := leaf.TransformPoint(gm.Vec3{})
+	if !near(leaf.InverseTransformPoint(p), gm.Vec3{}) {
+		t.Errorf("want inverse transform point to be local origin, got %v", leaf.InverseTransformPoint(p))
+	}
+	if want, got := leaf.Mat4().Quat(), leaf.WorldRotation(); !near(got.V(), want.V()) {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+
+	world := leaf.WorldPosition()
+	leaf.SetParentKeepWorld(root)
+	if !near(leaf.WorldPosition(), world) || len(child.Children()) != 0 || len(root.Children()) != 2 {
+		t.Errorf("want reparented leaf at %v, got %v", world, leaf.WorldPosition())
+	}
+	leaf.Detach(true)
+	if !near(leaf.WorldPosition(), world) || leaf.Parent() != nil {
+		t.Errorf("want detached leaf at %v, got %v", world, leaf.WorldPosition())
+	}
+
+	child.SetWorldPosition(gm.Vec3{3, 2, 1})
+	if want, got := (gm.Vec3{3, 2, 1}), child.WorldPosition(); !near(got, want) {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+
+	before := ctx.TransformStats()
+	leaf.Mat4()
+	leaf.Mat4()
+	if got := ctx.TransformStats(); got.Saved != before.Saved+2 || got.Builds != before.Builds {
+		t.Errorf("want 2 saved builds, got %+v from %+v", got, before)
+	}
+}
+
+func TestTransformParentRemove(t *testing.T) {
+	var ctx *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { ctx = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	root := gorge.NewTransformComponent()
+	child := gorge.NewTransformComponent()
+	leaf := gorge.NewTransformComponent()
+	child.SetParent(root)
+	leaf.SetParent(child)
+	ctx.Add(root, child, leaf)
+
+	if err := root.SetParent(leaf); !errors.Is(err, gorge.ErrParentCycle) {
+		t.Errorf("\nwant: %v\n got: %v\n", gorge.ErrParentCycle, err)
+	}
+	if root.Parent() != nil || len(root.Children()) != 1 || len(child.Children()) != 1 {
+		t.Error("cycle error should leave the hierarchy unchanged")
+	}
+
+	ctx.Remove(leaf)
+	if len(child.Children()) != 0 {
+		t.Errorf("want removed leaf unlinked, got %d children", len(child.Children()))
+	}
+	walked := 0
+	root.Walk(func(*gorge.TransformComponent) bool { walked++; return true })
+	if walked != 1 {
+		t.Errorf("want 1 descendant, got %d", walked)
+	}
+
+	child.SetPosition(1, 0, 0)
+	ctx.Add(leaf)
+	if len(child.Children()) != 1 || leaf.WorldPosition() != (gm.Vec3{1, 0, 0}) {
+		t.Errorf("want leaf relinked at %v, got %v", gm.Vec3{1, 0, 0}, leaf.WorldPosition())
+	}
+}
+
+func TestTransformLookAt(t *testing.T) {
+	near := func(a, b gm.Vec3) bool {
+		return a.Sub(b).Len() < 1e-4
+	}
+	parent := gorge.NewTransformComponent()
+	parent.SetEuler(0, gm.Pi/2, 0)
+	c := gorge.NewTransformComponent()
+	c.SetParent(parent) // nolint: errcheck
+	target := gorge.NewTransformComponent()
+	target.SetPosition(0, 0, -1)
+
+	// Local look at ignores the parent rotation.
+	c.LookAt(target)
+	if want, got := (gm.Vec3{0, 0, -1}), c.Rotation.Mat4().MulV4(gm.Vec4{0, 0, -1, 0}).Vec3(); !near(got, want) {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+	c.LookAtWorld(target)
+	if want, got := (gm.Vec3{0, 0, -1}), c.Forward(); !near(got, want) {
+		t.Errorf("\nwant: %v\n got: %v\n", want, got)
+	}
+}
@@ -1,5 +1,7 @@
 package gorge
 
+import "strings"
+
 // StringHash builds an hash from a string
 func StringHash(str ...string) uint {
 	seed := uint(0)
@@ -47,3 +49,29 @@ func HasParent(e Entity, parent Entity) bool {
 	})
 	return hasParent
 }
+
+// Errors holds multiple errors, like the ones returned while closing.
+type Errors []error
+
+func (e Errors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Unwrap returns the errors.
+func (e Errors) Unwrap() []error { return e }
+
+// JoinErrors returns nil if there are no errors, the error if there is only
+// one or Errors.
+func JoinErrors(errs ...error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return Errors(errs)
+}
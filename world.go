@@ -0,0 +1,200 @@
+package gorge
+
+import (
+	"reflect"
+)
+
+// world indexes the entities added to gorge so they can be queried by
+// component interfaces.
+type world struct {
+	// entities is in insertion order, removed entities leave a nil hole
+	// until the next compact.
+	entities []Entity
+	holes    int
+	index    map[Entity]int
+	version  uint64
+	cache    map[any]*queryCache
+
+	// stats is set on added transforms.
+	stats *TransformStats
+}
+
+type queryCache struct {
+	version uint64
+	result  any
+}
+
+func (w *world) add(e Entity) {
+	if !indexable(e) {
+		return
+	}
+	if w.index == nil {
+		w.index = map[Entity]int{}
+	}
+	if _, ok := w.index[e]; ok {
+		return
+	}
+	if t := entityTransform(e); t != nil {
+		if t.stats == nil {
+			t.stats = w.stats
+		}
+		// Relink a transform removed before, its parent kept moving.
+		if p := t.parentTransform(); p != nil {
+			p.addChild(t)
+			t.invalidate()
+		}
+	}
+	w.index[e] = len(w.entities)
+	w.entities = append(w.entities, e)
+	w.version++
+}
+
+func (w *world) remove(e Entity) {
+	if !indexable(e) {
+		return
+	}
+	i, ok := w.index[e]
+	if !ok {
+		return
+	}
+	delete(w.index, e)
+	// Unlink from the parent so removed transforms are not kept alive by it,
+	// the parent is kept so the hierarchy is restored if added again.
+	if t := entityTransform(e); t != nil {
+		if p := t.parentTransform(); p != nil {
+			p.removeChild(t)
+		}
+	}
+	// Holes keep removal O(1), they are compacted before the next query or
+	// once they are half of the entities.
+	w.entities[i] = nil
+	w.holes++
+	if w.holes > len(w.entities)/2 {
+		w.compact()
+	}
+	w.version++
+}
+
+// compact removes the holes left by removed entities keeping the insertion
+// order.
+func (w *world) compact() {
+	if w.holes == 0 {
+		return
+	}
+	n := 0
+	for _, e := range w.entities {
+		if e == nil {
+			continue
+		}
+		w.entities[n] = e
+		w.index[e] = n
+		n++
+	}
+	for i := n; i < len(w.entities); i++ {
+		w.entities[i] = nil
+	}
+	w.entities = w.entities[:n]
+	w.holes = 0
+}
+
+// cached returns the cached result for key or calls build if the world
+// changed since it was cached.
+func (w *world) cached(key any, build func() any) any {
+	if w.cache == nil {
+		w.cache = map[any]*queryCache{}
+	}
+	c, ok := w.cache[key]
+	if !ok {
+		c = &queryCache{}
+		w.cache[key] = c
+	} else if c.version == w.version {
+		return c.result
+	}
+	c.version = w.version
+	w.compact()
+	c.result = build()
+	return c.result
+}
+
+func entityTransform(e Entity) *TransformComponent {
+	if t, ok := e.(interface{ Transform() *TransformComponent }); ok {
+		return t.Transform()
+	}
+	return nil
+}
+
+// indexable returns true if e can be used as a map key.
+func indexable(e Entity) bool {
+	return e != nil && reflect.TypeOf(e).Comparable()
+}
+
+// Entities returns the number of entities added to gorge.
+func (g *Gorge) Entities() int {
+	return len(g.world.entities) - g.world.holes
+}
+
+// TransformStats returns the world matrix build counters of the transforms
+// added to this instance.
+func (g *Gorge) TransformStats() TransformStats {
+	return g.transformStats
+}
+
+// HasEntity returns true if the entity was added and not removed.
+func (g *Gorge) HasEntity(e Entity) bool {
+	if !indexable(e) {
+		return false
+	}
+	_, ok := g.world.index[e]
+	return ok
+}
+
+// Match2 is an entity implementing two component interfaces.
+type Match2[A, B any] struct {
+	Entity Entity
+	A      A
+	B      B
+}
+
+// Query returns the added entities implementing T in the order they were
+// added, i.e: Query[gorge.Transformer](g) or
+// Query[interface{ Light() *LightComponent }](g).
+// The result is cached until an entity is added or removed and must not be
+// modified.
+func Query[T any](g Contexter) []T {
+	w := &g.G().world
+	key := reflect.TypeOf((*T)(nil)).Elem()
+	return w.cached(key, func() any {
+		var res []T
+		for _, e := range w.entities {
+			if v, ok := e.(T); ok {
+				res = append(res, v)
+			}
+		}
+		return res
+	}).([]T)
+}
+
+// Query2 returns the added entities implementing both A and B in the order
+// they were added, the result is cached like Query.
+func Query2[A, B any](g Contexter) []Match2[A, B] {
+	w := &g.G().world
+	key := [2]reflect.Type{
+		reflect.TypeOf((*A)(nil)).Elem(),
+		reflect.TypeOf((*B)(nil)).Elem(),
+	}
+	return w.cached(key, func() any {
+		var res []Match2[A, B]
+		for _, e := range w.entities {
+			a, ok := e.(A)
+			if !ok {
+				continue
+			}
+			b, ok := e.(B)
+			if !ok {
+				continue
+			}
+			res = append(res, Match2[A, B]{Entity: e, A: a, B: b})
+		}
+		return res
+	}).([]Match2[A, B])
+}
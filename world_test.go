@@ -0,0 +1,78 @@
+package gorge_test
+
+import (
+	"testing"
+
+	"github.com/stdiopt/gorge"
+)
+
+type queryEntity struct {
+	*gorge.TransformComponent
+	*gorge.LightComponent
+}
+
+func TestQuery(t *testing.T) {
+	var ctx *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { ctx = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	type lighter = interface{ Light() *gorge.LightComponent }
+	light := &queryEntity{gorge.NewTransformComponent(), gorge.NewLightComponent()}
+	transform := gorge.NewTransformComponent()
+	ctx.Add(transform, light, gorge.Container{light})
+
+	if got := len(gorge.Query[gorge.Transformer](ctx)); got != 2 {
+		t.Errorf("\nwant: %v\n got: %v\n", 2, got)
+	}
+	ls := gorge.Query2[gorge.Transformer, lighter](ctx)
+	if len(ls) != 1 || ls[0].Entity != light {
+		t.Errorf("\nwant: %v\n got: %v\n", light, ls)
+	}
+
+	ctx.Remove(light)
+	if got := gorge.Query[gorge.Transformer](ctx); len(got) != 1 || got[0] != transform {
+		t.Errorf("\nwant: %v\n got: %v\n", []any{transform}, got)
+	}
+	if got := gorge.Query2[gorge.Transformer, lighter](ctx); len(got) != 0 {
+		t.Errorf("\nwant: %v\n got: %v\n", 0, len(got))
+	}
+	if ctx.Entities() != 1 || ctx.HasEntity(light) {
+		t.Errorf("want 1 entity without light, got %d", ctx.Entities())
+	}
+}
+
+func TestQueryRemoveOrder(t *testing.T) {
+	var ctx *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { ctx = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	ts := make([]*gorge.TransformComponent, 6)
+	for i := range ts {
+		ts[i] = gorge.NewTransformComponent()
+		ctx.Add(ts[i])
+	}
+	ctx.Remove(ts[1], ts[4])
+	ctx.Add(ts[1])
+
+	want := []*gorge.TransformComponent{ts[0], ts[2], ts[3], ts[5], ts[1]}
+	got := gorge.Query[gorge.Transformer](ctx)
+	if ctx.Entities() != len(want) || len(got) != len(want) {
+		t.Fatalf("want %d entities, got %d and %d queried", len(want), ctx.Entities(), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("entity %d\nwant: %p\n got: %p\n", i, want[i], got[i])
+		}
+	}
+	// Removing after a compact uses the updated index.
+	ctx.Remove(ts[3])
+	if ctx.HasEntity(ts[3]) || len(gorge.Query[gorge.Transformer](ctx)) != 4 {
+		t.Error("want entity removed after compact")
+	}
+}
@@ -0,0 +1,249 @@
+// Package dds decodes 2D textures from DirectDraw Surface files into
+// gorge.TextureData.
+package dds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/stdiopt/gorge"
+)
+
+const magic = "DDS "
+
+const maxSize = 1 << 16
+
+// Header flags.
+const (
+	flagMipMapCount = 0x20000
+
+	pfAlphaPixels = 0x1
+	pfFourCC      = 0x4
+	pfRGB         = 0x40
+	pfLuminance   = 0x20000
+
+	caps2Cubemap = 0x200
+	caps2Volume  = 0x200000
+
+	miscTextureCube = 0x4
+)
+
+type pixelFormat struct {
+	Size        uint32
+	Flags       uint32
+	FourCC      [4]byte
+	RGBBitCount uint32
+	RBitMask    uint32
+	GBitMask    uint32
+	BBitMask    uint32
+	ABitMask    uint32
+}
+
+type header struct {
+	Size              uint32
+	Flags             uint32
+	Height            uint32
+	Width             uint32
+	PitchOrLinearSize uint32
+	Depth             uint32
+	MipMapCount       uint32
+	Reserved1         [11]uint32
+	PixelFormat       pixelFormat
+	Caps              uint32
+	Caps2             uint32
+	Caps3             uint32
+	Caps4             uint32
+	Reserved2         uint32
+}
+
+type headerDX10 struct {
+	DXGIFormat        uint32
+	ResourceDimension uint32
+	MiscFlag          uint32
+	ArraySize         uint32
+	MiscFlags2        uint32
+}
+
+var fourCCFormats = map[string]gorge.TextureFormat{
+	"DXT1": gorge.TextureFormatBC1,
+	"DXT3": gorge.TextureFormatBC2,
+	"DXT5": gorge.TextureFormatBC3,
+	"ATI1": gorge.TextureFormatBC4,
+	"BC4U": gorge.TextureFormatBC4,
+	"ATI2": gorge.TextureFormatBC5,
+	"BC5U": gorge.TextureFormatBC5,
+}
+
+// dxgiFormats maps DXGI_FORMAT values to texture formats, sRGB variants map to
+// their linear counterpart and are listed in srgbFormats.
+var dxgiFormats = map[uint32]gorge.TextureFormat{
+	2:  gorge.TextureFormatRGBA32F, // R32G32B32A32_FLOAT
+	6:  gorge.TextureFormatRGB32F,  // R32G32B32_FLOAT
+	10: gorge.TextureFormatRGBA16F, // R16G16B16A16_FLOAT
+	28: gorge.TextureFormatRGBA,    // R8G8B8A8_UNORM
+	29: gorge.TextureFormatRGBA,    // R8G8B8A8_UNORM_SRGB
+	34: gorge.TextureFormatRG16F,   // R16G16_FLOAT
+	41: gorge.TextureFormatR32F,    // R32_FLOAT
+	54: gorge.TextureFormatR16F,    // R16_FLOAT
+	61: gorge.TextureFormatGray,    // R8_UNORM
+	71: gorge.TextureFormatBC1,     // BC1_UNORM
+	72: gorge.TextureFormatBC1,     // BC1_UNORM_SRGB
+	74: gorge.TextureFormatBC2,     // BC2_UNORM
+	75: gorge.TextureFormatBC2,     // BC2_UNORM_SRGB
+	77: gorge.TextureFormatBC3,     // BC3_UNORM
+	78: gorge.TextureFormatBC3,     // BC3_UNORM_SRGB
+	80: gorge.TextureFormatBC4,     // BC4_UNORM
+	83: gorge.TextureFormatBC5,     // BC5_UNORM
+	95: gorge.TextureFormatBC6H,    // BC6H_UF16
+	98: gorge.TextureFormatBC7,     // BC7_UNORM
+	99: gorge.TextureFormatBC7,     // BC7_UNORM_SRGB
+}
+
+// srgbFormats holds the DXGI_FORMAT values with sRGB encoded color.
+var srgbFormats = map[uint32]bool{
+	29: true, 72: true, 75: true, 78: true, 99: true,
+}
+
+// Decode reads a DDS file, level 0 goes in PixelData and the remaining levels
+// in Mips, uncompressed RGB files are converted to RGBA.
+func Decode(rd io.Reader) (*gorge.TextureData, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, fmt.Errorf("dds: %w", err)
+	}
+	if !bytes.HasPrefix(data, []byte(magic)) {
+		return nil, errors.New("dds: invalid magic")
+	}
+	br := bytes.NewReader(data[len(magic):])
+
+	var h header
+	if err := binary.Read(br, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("dds: header: %w", err)
+	}
+	if h.Size != 124 {
+		return nil, fmt.Errorf("dds: invalid header size %d", h.Size)
+	}
+	if h.Caps2&(caps2Cubemap|caps2Volume) != 0 {
+		return nil, errors.New("dds: cubemaps and volumes are not supported")
+	}
+	if h.Width == 0 || h.Height == 0 || h.Width > maxSize || h.Height > maxSize {
+		return nil, fmt.Errorf("dds: invalid size %dx%d", h.Width, h.Height)
+	}
+
+	pf := h.PixelFormat
+	var format gorge.TextureFormat
+	var srgb bool
+	// convert is set for uncompressed formats that need conversion to RGBA.
+	var convert func([]byte, int, int) []byte
+	switch {
+	case pf.Flags&pfFourCC != 0 && string(pf.FourCC[:]) == "DX10":
+		var dx headerDX10
+		if err := binary.Read(br, binary.LittleEndian, &dx); err != nil {
+			return nil, fmt.Errorf("dds: dx10 header: %w", err)
+		}
+		if dx.ArraySize > 1 || dx.MiscFlag&miscTextureCube != 0 {
+			return nil, errors.New("dds: texture arrays and cubemaps are not supported")
+		}
+		f, ok := dxgiFormats[dx.DXGIFormat]
+		if !ok {
+			return nil, fmt.Errorf("dds: unsupported dxgi format %d", dx.DXGIFormat)
+		}
+		format, srgb = f, srgbFormats[dx.DXGIFormat]
+	case pf.Flags&pfFourCC != 0:
+		f, ok := fourCCFormats[string(pf.FourCC[:])]
+		if !ok {
+			return nil, fmt.Errorf("dds: unsupported fourCC %q", pf.FourCC[:])
+		}
+		format = f
+	case pf.Flags&pfRGB != 0 && (pf.RGBBitCount == 32 || pf.RGBBitCount == 24):
+		format = gorge.TextureFormatRGBA
+		convert = maskConverter(pf)
+	case pf.Flags&pfLuminance != 0 && pf.RGBBitCount == 8:
+		format = gorge.TextureFormatGray
+	default:
+		return nil, fmt.Errorf("dds: unsupported pixel format flags 0x%X", pf.Flags)
+	}
+
+	nlevels := 1
+	if h.Flags&flagMipMapCount != 0 && h.MipMapCount > 1 {
+		nlevels = int(h.MipMapCount)
+	}
+	if nlevels > 32 {
+		return nil, fmt.Errorf("dds: invalid mip count %d", nlevels)
+	}
+
+	texData := &gorge.TextureData{
+		Format: format,
+		Width:  int(h.Width),
+		Height: int(h.Height),
+		SRGB:   srgb,
+	}
+	off := len(data) - br.Len()
+	w, hh := texData.Width, texData.Height
+	for i := 0; i < nlevels; i++ {
+		sz := format.DataSize(w, hh)
+		if convert != nil {
+			sz = w * hh * int(pf.RGBBitCount/8)
+		}
+		if off+sz > len(data) {
+			return nil, fmt.Errorf("dds: level %d: want %d bytes, got %d", i, sz, len(data)-off)
+		}
+		pix := data[off : off+sz]
+		if convert != nil {
+			pix = convert(pix, w, hh)
+		}
+		if i == 0 {
+			texData.PixelData = pix
+		} else {
+			texData.Mips = append(texData.Mips, pix)
+		}
+		off += sz
+		w, hh = half(w), half(hh)
+	}
+	return texData, nil
+}
+
+// maskConverter returns a func converting 24 or 32 bit pixels with the
+// pixel format masks to RGBA.
+func maskConverter(pf pixelFormat) func([]byte, int, int) []byte {
+	bpp := int(pf.RGBBitCount / 8)
+	masks := [4]uint32{pf.RBitMask, pf.GBitMask, pf.BBitMask, 0}
+	if pf.Flags&pfAlphaPixels != 0 {
+		masks[3] = pf.ABitMask
+	}
+	return func(src []byte, w, h int) []byte {
+		dst := make([]byte, w*h*4)
+		for i := 0; i < w*h; i++ {
+			var p uint32
+			for b := 0; b < bpp; b++ {
+				p |= uint32(src[i*bpp+b]) << (8 * b)
+			}
+			for c, m := range masks {
+				dst[i*4+c] = channel(p, m)
+			}
+		}
+		return dst
+	}
+}
+
+// channel extracts the masked value scaled to 8 bits, an empty mask is 255.
+func channel(p, mask uint32) byte {
+	if mask == 0 {
+		return 255
+	}
+	shift := bits.TrailingZeros32(mask)
+	max := uint64(mask >> shift)
+	return byte(uint64((p&mask)>>shift) * 255 / max)
+}
+
+// half returns the size of the next mip level.
+func half(n int) int {
+	if n > 1 {
+		return n / 2
+	}
+	return 1
+}
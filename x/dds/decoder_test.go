@@ -0,0 +1,101 @@
+package dds_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/dds"
+)
+
+// writeDDS builds a DDS file, pf is the pixel format flags, fourCC, bit
+// count and RGBA masks.
+func writeDDS(width, height, mips uint32, pf [8]uint32, dx10 []uint32, data []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("DDS ")
+	le := binary.LittleEndian
+	flags := uint32(0x1007)
+	if mips > 0 {
+		flags |= 0x20000
+	}
+	binary.Write(buf, le, [7]uint32{124, flags, height, width, 0, 0, mips}) // nolint: errcheck
+	binary.Write(buf, le, [11]uint32{})                                     // nolint: errcheck
+	binary.Write(buf, le, pf)                                               // nolint: errcheck
+	binary.Write(buf, le, [5]uint32{0x1000})                                // nolint: errcheck
+	binary.Write(buf, le, dx10)                                             // nolint: errcheck
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func fourCC(s string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(s))
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("dxt1 mips", func(t *testing.T) {
+		// 4x4, 2x2 and 1x1 levels are a block each.
+		data := append(bytes.Repeat([]byte{1}, 8), bytes.Repeat([]byte{2}, 16)...)
+		file := writeDDS(4, 4, 3, [8]uint32{32, 0x4, fourCC("DXT1")}, nil, data)
+		td, err := dds.Decode(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if td.Format != gorge.TextureFormatBC1 || len(td.PixelData) != 8 || len(td.Mips) != 2 {
+			t.Errorf("want BC1 with 2 mips, got %v %d bytes %d mips", td.Format, len(td.PixelData), len(td.Mips))
+		}
+	})
+	t.Run("dx10 bc7", func(t *testing.T) {
+		file := writeDDS(8, 4, 0, [8]uint32{32, 0x4, fourCC("DX10")}, []uint32{98, 3, 0, 1, 0}, make([]byte, 32))
+		td, err := dds.Decode(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if td.Format != gorge.TextureFormatBC7 || len(td.PixelData) != 32 || td.SRGB {
+			t.Errorf("want linear BC7 with 32 bytes, got %v %d bytes sRGB %v", td.Format, len(td.PixelData), td.SRGB)
+		}
+	})
+	t.Run("dx10 bc7 srgb", func(t *testing.T) {
+		file := writeDDS(8, 4, 0, [8]uint32{32, 0x4, fourCC("DX10")}, []uint32{99, 3, 0, 1, 0}, make([]byte, 32))
+		td, err := dds.Decode(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if td.Format != gorge.TextureFormatBC7 || !td.SRGB {
+			t.Errorf("want sRGB BC7, got %v sRGB %v", td.Format, td.SRGB)
+		}
+	})
+	t.Run("bgra", func(t *testing.T) {
+		pf := [8]uint32{32, 0x41, 0, 32, 0xFF0000, 0xFF00, 0xFF, 0xFF000000}
+		file := writeDDS(1, 1, 0, pf, nil, []byte{10, 20, 30, 40})
+		td, err := dds.Decode(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []byte{30, 20, 10, 40}
+		if td.Format != gorge.TextureFormatRGBA || !bytes.Equal(td.PixelData, want) {
+			t.Errorf("\nwant: %v\n got: %v %v\n", want, td.Format, td.PixelData)
+		}
+	})
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"magic", []byte("PNG "), "invalid magic"},
+		{"fourcc", writeDDS(4, 4, 0, [8]uint32{32, 0x4, fourCC("DXT2")}, nil, make([]byte, 8)), "unsupported fourCC"},
+		{"short", writeDDS(8, 8, 0, [8]uint32{32, 0x4, fourCC("DXT5")}, nil, make([]byte, 16)), "want 64 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dds.Decode(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("\nwant: %v\n got: %v\n", tt.want, err)
+			}
+		})
+	}
+}
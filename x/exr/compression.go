@@ -0,0 +1,98 @@
+package exr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// decompress returns the uncompressed chunk data, chunks that wouldn't shrink
+// are stored uncompressed.
+func decompress(h *header, src []byte, width, rows, size int) ([]byte, error) {
+	if len(src) == size {
+		return src, nil
+	}
+	if len(src) > size {
+		return nil, fmt.Errorf("want %d bytes, got %d", size, len(src))
+	}
+	switch h.compression {
+	case compressionRLE:
+		raw, err := decompressRLE(src, size)
+		if err != nil {
+			return nil, err
+		}
+		return unpredict(raw), nil
+	case compressionZIPS, compressionZIP:
+		raw, err := decompressZIP(src, size)
+		if err != nil {
+			return nil, err
+		}
+		return unpredict(raw), nil
+	case compressionPIZ:
+		return decompressPIZ(src, h.channels, width, rows, size)
+	default:
+		return nil, fmt.Errorf("want %d bytes, got %d", size, len(src))
+	}
+}
+
+// decompressRLE expands runs, a negative count is followed by as many
+// literal bytes and a positive one by a byte repeated count+1 times.
+func decompressRLE(src []byte, size int) ([]byte, error) {
+	out := make([]byte, 0, size)
+	for len(src) > 0 {
+		n := int(int8(src[0]))
+		switch {
+		case n < 0:
+			if -n > len(src)-1 || len(out)-n > size {
+				return nil, errors.New("rle: invalid run")
+			}
+			out = append(out, src[1:1-n]...)
+			src = src[1-n:]
+		default:
+			if len(src) < 2 || len(out)+n+1 > size {
+				return nil, errors.New("rle: invalid run")
+			}
+			for i := 0; i <= n; i++ {
+				out = append(out, src[1])
+			}
+			src = src[2:]
+		}
+	}
+	if len(out) != size {
+		return nil, fmt.Errorf("rle: want %d bytes, got %d", size, len(out))
+	}
+	return out, nil
+}
+
+func decompressZIP(src []byte, size int) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("zip: %w", err)
+	}
+	defer zr.Close()
+	out := make([]byte, size)
+	if _, err := io.ReadFull(zr, out); err != nil {
+		return nil, fmt.Errorf("zip: %w", err)
+	}
+	return out, nil
+}
+
+// unpredict reverses the byte delta predictor and interleaves the two halves
+// RLE and ZIP store the even and odd bytes in.
+func unpredict(raw []byte) []byte {
+	for i := 1; i < len(raw); i++ {
+		raw[i] = raw[i-1] + raw[i] - 128
+	}
+	out := make([]byte, len(raw))
+	half := (len(raw) + 1) / 2
+	for i := range out {
+		if i%2 == 0 {
+			out[i] = raw[i/2]
+		} else {
+			out[i] = raw[half+i/2]
+		}
+	}
+	return out
+}
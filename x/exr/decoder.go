@@ -0,0 +1,435 @@
+// Package exr decodes single part scanline OpenEXR images into float
+// gorge.TextureData.
+package exr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/stdiopt/gorge"
+)
+
+const magic = 20000630
+
+// Version flags.
+const (
+	flagTiled     = 0x200
+	flagDeep      = 0x800
+	flagMultiPart = 0x1000
+)
+
+// maxPixels limits the decoded image size.
+const maxPixels = 1 << 28
+
+// Pixel types.
+const (
+	pixelUint  = 0
+	pixelHalf  = 1
+	pixelFloat = 2
+)
+
+// Compression methods.
+const (
+	compressionNone = 0
+	compressionRLE  = 1
+	compressionZIPS = 2
+	compressionZIP  = 3
+	compressionPIZ  = 4
+)
+
+var compressionNames = []string{"none", "rle", "zips", "zip", "piz", "pxr24", "b44", "b44a", "dwaa", "dwab"}
+
+type channel struct {
+	name      string
+	pixelType int32
+	xSampling int32
+	ySampling int32
+}
+
+// size returns the sample size in bytes.
+func (c channel) size() int {
+	if c.pixelType == pixelHalf {
+		return 2
+	}
+	return 4
+}
+
+type header struct {
+	channels    []channel
+	compression byte
+	// dataWindow is xMin, yMin, xMax, yMax inclusive.
+	dataWindow [4]int32
+}
+
+// linesPerChunk returns the scanlines stored in each chunk.
+func (h *header) linesPerChunk() int {
+	switch h.compression {
+	case compressionZIP:
+		return 16
+	case compressionPIZ:
+		return 32
+	default:
+		return 1
+	}
+}
+
+// Decode reads an OpenEXR file with none, RLE, ZIP or PIZ compression, R, G,
+// B, A and Y channels are loaded into an R, RG or RGBA texture, Y being
+// luminance. Half float images keep their precision in the 16F formats, the
+// others are converted to the 32F formats, rows start at the top.
+func Decode(rd io.Reader) (*gorge.TextureData, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, fmt.Errorf("exr: %w", err)
+	}
+	r := &cursor{data: data}
+	if r.u32() != magic {
+		return nil, errors.New("exr: invalid magic")
+	}
+	version := r.u32()
+	if version&0xFF != 2 {
+		return nil, fmt.Errorf("exr: unsupported version %d", version&0xFF)
+	}
+	if version&(flagTiled|flagDeep|flagMultiPart) != 0 {
+		return nil, errors.New("exr: tiled, deep and multipart files are not supported")
+	}
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	width := int(h.dataWindow[2]) - int(h.dataWindow[0]) + 1
+	height := int(h.dataWindow[3]) - int(h.dataWindow[1]) + 1
+	if width <= 0 || height <= 0 || width*height > maxPixels {
+		return nil, fmt.Errorf("exr: invalid data window %v", h.dataWindow)
+	}
+	lines := h.linesPerChunk()
+	nchunks := (height + lines - 1) / lines
+	if nchunks*8 > len(r.data)-r.off {
+		return nil, errors.New("exr: truncated offset table")
+	}
+	offsets := make([]uint64, nchunks)
+	for i := range offsets {
+		offsets[i] = r.u64()
+	}
+
+	out, err := newOutput(h.channels, width, height)
+	if err != nil {
+		return nil, err
+	}
+	lineSize := 0
+	for _, c := range h.channels {
+		lineSize += c.size() * width
+	}
+	for i, off := range offsets {
+		if off > uint64(len(data)) {
+			return nil, fmt.Errorf("exr: chunk %d: invalid offset %d", i, off)
+		}
+		cr := &cursor{data: data, off: int(off)}
+		y := int(cr.i32()) - int(h.dataWindow[1])
+		size := int(cr.i32())
+		src := cr.next(size)
+		if cr.err != nil || size < 0 {
+			return nil, fmt.Errorf("exr: chunk %d: truncated", i)
+		}
+		if y < 0 || y >= height || y%lines != 0 {
+			return nil, fmt.Errorf("exr: chunk %d: invalid scanline %d", i, y)
+		}
+		rows := lines
+		if y+rows > height {
+			rows = height - y
+		}
+		raw, err := decompress(h, src, width, rows, lineSize*rows)
+		if err != nil {
+			return nil, fmt.Errorf("exr: chunk %d: %w", i, err)
+		}
+		out.scanlines(raw, y, rows)
+	}
+
+	return &gorge.TextureData{
+		Format:    out.format,
+		Width:     width,
+		Height:    height,
+		PixelData: out.pix,
+	}, nil
+}
+
+func readHeader(r *cursor) (*header, error) {
+	h := &header{}
+	var hasChannels, hasCompression, hasDataWindow bool
+	for {
+		name := r.cstring()
+		if r.err != nil {
+			return nil, errors.New("exr: truncated header")
+		}
+		if name == "" {
+			break
+		}
+		r.cstring() // type
+		size := int(r.i32())
+		val := &cursor{data: r.next(size)}
+		if r.err != nil || size < 0 {
+			return nil, fmt.Errorf("exr: attribute %q: truncated", name)
+		}
+		switch name {
+		case "channels":
+			hasChannels = true
+			for {
+				cname := val.cstring()
+				if cname == "" {
+					break
+				}
+				c := channel{name: cname, pixelType: val.i32()}
+				val.next(4) // pLinear and reserved
+				c.xSampling, c.ySampling = val.i32(), val.i32()
+				h.channels = append(h.channels, c)
+			}
+		case "compression":
+			hasCompression = true
+			h.compression = val.u8()
+		case "dataWindow":
+			hasDataWindow = true
+			for i := range h.dataWindow {
+				h.dataWindow[i] = val.i32()
+			}
+		}
+		if val.err != nil {
+			return nil, fmt.Errorf("exr: attribute %q: %w", name, val.err)
+		}
+	}
+	if !hasChannels || !hasCompression || !hasDataWindow {
+		return nil, errors.New("exr: missing channels, compression or dataWindow")
+	}
+	for _, c := range h.channels {
+		if c.pixelType < pixelUint || c.pixelType > pixelFloat {
+			return nil, fmt.Errorf("exr: channel %q: invalid pixel type %d", c.name, c.pixelType)
+		}
+		if c.xSampling != 1 || c.ySampling != 1 {
+			return nil, fmt.Errorf("exr: channel %q: subsampled channels are not supported", c.name)
+		}
+	}
+	if h.compression > compressionPIZ {
+		name := fmt.Sprint(h.compression)
+		if int(h.compression) < len(compressionNames) {
+			name = compressionNames[h.compression]
+		}
+		return nil, fmt.Errorf("exr: unsupported compression %s", name)
+	}
+	return h, nil
+}
+
+// output assembles the texture from the channel data.
+type output struct {
+	format gorge.TextureFormat
+	pix    []byte
+	width  int
+	// half is set if the texture stores half floats.
+	half bool
+	// size is the texture channel count.
+	size     int
+	channels []channel
+	// targets holds the texture channels of each file channel.
+	targets [][]int
+}
+
+func newOutput(channels []channel, width, height int) (*output, error) {
+	o := &output{width: width, channels: channels}
+	slots := map[string]int{"R": 0, "G": 1, "B": 2, "A": 3}
+	var used [4]bool
+	hasY := false
+	for _, c := range channels {
+		if i, ok := slots[c.name]; ok {
+			used[i] = true
+		}
+		hasY = hasY || c.name == "Y"
+	}
+	// Luminance is loaded only without color channels.
+	luminance := hasY && !used[0] && !used[1] && !used[2]
+	switch {
+	case !luminance && !used[0] && !used[1] && !used[2] && !used[3]:
+		return nil, errors.New("exr: no R, G, B, A or Y channels")
+	case used[2] || used[3]:
+		o.size = 4
+	case used[1]:
+		o.size = 2
+	default:
+		o.size = 1
+	}
+	o.half = true
+	for _, c := range channels {
+		var t []int
+		if i, ok := slots[c.name]; ok {
+			t = []int{i}
+		} else if c.name == "Y" && luminance {
+			t = []int{0}
+			if o.size == 4 {
+				t = []int{0, 1, 2}
+			}
+		}
+		o.targets = append(o.targets, t)
+		if t != nil {
+			o.half = o.half && c.pixelType == pixelHalf
+		}
+	}
+
+	switch {
+	case o.half && o.size == 1:
+		o.format = gorge.TextureFormatR16F
+	case o.half && o.size == 2:
+		o.format = gorge.TextureFormatRG16F
+	case o.half:
+		o.format = gorge.TextureFormatRGBA16F
+	case o.size == 1:
+		o.format = gorge.TextureFormatR32F
+	default:
+		// There's no RG32F format.
+		o.size = 4
+		o.format = gorge.TextureFormatRGBA32F
+	}
+	o.pix = make([]byte, o.format.DataSize(width, height))
+	if o.size == 4 && !used[3] {
+		o.opaque()
+	}
+	return o, nil
+}
+
+// opaque sets the alpha channel to 1.
+func (o *output) opaque() {
+	one, pixelType := math.Float32bits(1), int32(pixelFloat)
+	if o.half {
+		one, pixelType = 0x3C00, pixelHalf
+	}
+	_, _, px := o.format.BlockSize()
+	for i := 0; i < len(o.pix); i += px {
+		o.put(o.pix[i:], 3, one, pixelType)
+	}
+}
+
+// put writes a sample in the texture channel of a pixel.
+func (o *output) put(pix []byte, ch int, v uint32, pixelType int32) {
+	if o.half {
+		binary.LittleEndian.PutUint16(pix[ch*2:], uint16(v))
+		return
+	}
+	var f float32
+	switch pixelType {
+	case pixelHalf:
+		f = halfToFloat32(uint16(v))
+	case pixelUint:
+		f = float32(v)
+	default:
+		f = math.Float32frombits(v)
+	}
+	binary.LittleEndian.PutUint32(pix[ch*4:], math.Float32bits(f))
+}
+
+// scanlines writes uncompressed chunk data starting at row y.
+func (o *output) scanlines(raw []byte, y, rows int) {
+	_, _, px := o.format.BlockSize()
+	off := 0
+	for row := y; row < y+rows; row++ {
+		line := o.pix[row*o.width*px:]
+		for ci, c := range o.channels {
+			sz := c.size()
+			targets := o.targets[ci]
+			if len(targets) == 0 {
+				off += sz * o.width
+				continue
+			}
+			for x := 0; x < o.width; x++ {
+				var v uint32
+				if sz == 2 {
+					v = uint32(binary.LittleEndian.Uint16(raw[off:]))
+				} else {
+					v = binary.LittleEndian.Uint32(raw[off:])
+				}
+				off += sz
+				for _, t := range targets {
+					o.put(line[x*px:], t, v, c.pixelType)
+				}
+			}
+		}
+	}
+}
+
+// halfToFloat32 converts IEEE 754 half float bits to float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1F
+	mant := uint32(h & 0x3FF)
+	switch {
+	case exp == 0:
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case exp == 0x1F:
+		return math.Float32frombits(sign | 0x7F800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | mant<<13)
+}
+
+// cursor reads little endian values, errors are sticky and reads past the
+// end return zero values.
+type cursor struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (c *cursor) next(n int) []byte {
+	if c.err != nil || n < 0 || n > len(c.data)-c.off {
+		c.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := c.data[c.off : c.off+n]
+	c.off += n
+	return b
+}
+
+func (c *cursor) u8() byte {
+	if b := c.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (c *cursor) u16() uint16 {
+	if b := c.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (c *cursor) u32() uint32 {
+	if b := c.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (c *cursor) i32() int32 { return int32(c.u32()) }
+
+func (c *cursor) u64() uint64 {
+	if b := c.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// cstring reads a null terminated string.
+func (c *cursor) cstring() string {
+	for i := c.off; i < len(c.data) && c.err == nil; i++ {
+		if c.data[i] == 0 {
+			s := string(c.data[c.off:i])
+			c.off = i + 1
+			return s
+		}
+	}
+	c.err = io.ErrUnexpectedEOF
+	return ""
+}
@@ -0,0 +1,482 @@
+package exr_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/exr"
+)
+
+type testChannel struct {
+	name string
+	typ  int32 // 0 uint, 1 half, 2 float
+}
+
+func (c testChannel) size() int {
+	if c.typ == 1 {
+		return 2
+	}
+	return 4
+}
+
+// pixFunc returns the value of a channel at x, y, values must be exact in
+// half floats.
+type pixFunc func(ch string, x, y int) float32
+
+func pattern(ch string, x, y int) float32 {
+	return float32((x+2*y+3*strings.Index("RGBAYZ", ch))%5) / 2
+}
+
+func constant(ch string, x, y int) float32 {
+	return 1.5
+}
+
+// writeEXR builds a scanline file, chunks are compressed with the test
+// encoders and must shrink.
+func writeEXR(t *testing.T, version uint32, chans []testChannel, compression byte, w, h int, pix pixFunc) []byte {
+	t.Helper()
+	le := binary.LittleEndian
+	buf := &bytes.Buffer{}
+	binary.Write(buf, le, [2]uint32{20000630, version}) // nolint: errcheck
+	attr := func(name, typ string, v any) {
+		val := &bytes.Buffer{}
+		binary.Write(val, le, v) // nolint: errcheck
+		buf.WriteString(name + "\x00" + typ + "\x00")
+		binary.Write(buf, le, int32(val.Len())) // nolint: errcheck
+		buf.Write(val.Bytes())
+	}
+	chlist := &bytes.Buffer{}
+	for _, c := range chans {
+		chlist.WriteString(c.name + "\x00")
+		binary.Write(chlist, le, [4]int32{c.typ, 0, 1, 1}) // nolint: errcheck
+	}
+	chlist.WriteByte(0)
+	attr("channels", "chlist", chlist.Bytes())
+	attr("compression", "compression", compression)
+	attr("dataWindow", "box2i", [4]int32{0, 0, int32(w - 1), int32(h - 1)})
+	attr("displayWindow", "box2i", [4]int32{0, 0, int32(w - 1), int32(h - 1)})
+	attr("lineOrder", "lineOrder", byte(0))
+	buf.WriteByte(0)
+
+	lines := map[byte]int{3: 16, 4: 32}[compression]
+	if lines == 0 {
+		lines = 1
+	}
+	var chunks [][]byte
+	for y := 0; y < h; y += lines {
+		rows := lines
+		if y+rows > h {
+			rows = h - y
+		}
+		raw := &bytes.Buffer{}
+		for yy := y; yy < y+rows; yy++ {
+			for _, c := range chans {
+				for x := 0; x < w; x++ {
+					v := pix(c.name, x, yy)
+					switch c.typ {
+					case 0:
+						binary.Write(raw, le, uint32(v)) // nolint: errcheck
+					case 1:
+						binary.Write(raw, le, toHalf(v)) // nolint: errcheck
+					default:
+						binary.Write(raw, le, v) // nolint: errcheck
+					}
+				}
+			}
+		}
+		data := raw.Bytes()
+		switch compression {
+		case 1:
+			data = rleEncode(predict(data))
+		case 2, 3:
+			data = zipEncode(t, predict(data))
+		case 4:
+			data = pizEncode(data, chans, w, rows)
+		}
+		if compression >= 1 && compression <= 4 && len(data) >= raw.Len() {
+			t.Fatalf("chunk %d doesn't shrink: %d >= %d bytes", y, len(data), raw.Len())
+		}
+		chunk := &bytes.Buffer{}
+		binary.Write(chunk, le, [2]int32{int32(y), int32(len(data))}) // nolint: errcheck
+		chunk.Write(data)
+		chunks = append(chunks, chunk.Bytes())
+	}
+	off := buf.Len() + 8*len(chunks)
+	for _, c := range chunks {
+		binary.Write(buf, le, uint64(off)) // nolint: errcheck
+		off += len(c)
+	}
+	for _, c := range chunks {
+		buf.Write(c)
+	}
+	return buf.Bytes()
+}
+
+// toHalf converts zero and normal values to half float bits.
+func toHalf(v float32) uint16 {
+	if v == 0 {
+		return 0
+	}
+	b := math.Float32bits(v)
+	return uint16(b>>16&0x8000 | (b>>23&0xFF-112)<<10 | b>>13&0x3FF)
+}
+
+func fromHalf(h uint16) float32 {
+	if h&0x7FFF == 0 {
+		return 0
+	}
+	return math.Float32frombits(uint32(h&0x8000)<<16 | (uint32(h>>10&0x1F)+112)<<23 | uint32(h&0x3FF)<<13)
+}
+
+// predict splits even and odd bytes and delta encodes them.
+func predict(raw []byte) []byte {
+	t := make([]byte, len(raw))
+	half := (len(raw) + 1) / 2
+	for i, b := range raw {
+		if i%2 == 0 {
+			t[i/2] = b
+		} else {
+			t[half+i/2] = b
+		}
+	}
+	for i := len(t) - 1; i > 0; i-- {
+		t[i] = t[i] - t[i-1] + 128
+	}
+	return t
+}
+
+func rleEncode(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		run := 1
+		for i+run < len(data) && data[i+run] == data[i] && run < 128 {
+			run++
+		}
+		if run >= 3 {
+			out = append(out, byte(run-1), data[i])
+			i += run
+			continue
+		}
+		n := 1
+		for i+n < len(data) && n < 127 && !(i+n+2 < len(data) && data[i+n] == data[i+n+1] && data[i+n] == data[i+n+2]) {
+			n++
+		}
+		out = append(out, byte(int8(-n)))
+		out = append(out, data[i:i+n]...)
+		i += n
+	}
+	return out
+}
+
+func zipEncode(t *testing.T, data []byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zlib.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pizEncode splits the chunk into channel planes, maps the values to a dense
+// range, applies the 14 bit wavelet transform and huffman codes the result.
+func pizEncode(raw []byte, chans []testChannel, w, rows int) []byte {
+	le := binary.LittleEndian
+	data := make([]uint16, len(raw)/2)
+	planes := make([]int, len(chans))
+	off := 0
+	for i, c := range chans {
+		planes[i] = off
+		off += w * rows * c.size() / 2
+	}
+	for i := 0; i < len(raw); {
+		for ci, c := range chans {
+			for j := 0; j < w*c.size()/2; j++ {
+				data[planes[ci]] = le.Uint16(raw[i:])
+				planes[ci]++
+				i += 2
+			}
+		}
+	}
+
+	bitmap := make([]byte, 8192)
+	for _, v := range data {
+		bitmap[v>>3] |= 1 << (v & 7)
+	}
+	bitmap[0] &^= 1
+	minNonZero, maxNonZero := len(bitmap)-1, 0
+	for i, b := range bitmap {
+		if b == 0 {
+			continue
+		}
+		if i < minNonZero {
+			minNonZero = i
+		}
+		maxNonZero = i
+	}
+	lut := make([]uint16, 1<<16)
+	k := 0
+	for i := range lut {
+		if i == 0 || bitmap[i>>3]&(1<<(i&7)) != 0 {
+			lut[i] = uint16(k)
+			k++
+		}
+	}
+	for i, v := range data {
+		data[i] = lut[v]
+	}
+	off = 0
+	for _, c := range chans {
+		n := c.size() / 2
+		for j := 0; j < n; j++ {
+			wav2Encode(data[off+j:], w, n, rows, w*n)
+		}
+		off += w * rows * n
+	}
+
+	huf := hufEncode(data)
+	buf := &bytes.Buffer{}
+	binary.Write(buf, le, [2]uint16{uint16(minNonZero), uint16(maxNonZero)}) // nolint: errcheck
+	if minNonZero <= maxNonZero {
+		buf.Write(bitmap[minNonZero : maxNonZero+1])
+	}
+	binary.Write(buf, le, int32(len(huf))) // nolint: errcheck
+	buf.Write(huf)
+	return buf.Bytes()
+}
+
+func wenc14(a, b uint16) (uint16, uint16) {
+	as, bs := int(int16(a)), int(int16(b))
+	return uint16(int16((as + bs) >> 1)), uint16(int16(as - bs))
+}
+
+func wav2Encode(in []uint16, nx, ox, ny, oy int) {
+	n := nx
+	if ny < n {
+		n = ny
+	}
+	for p, p2 := 1, 2; p2 <= n; p, p2 = p2, p2<<1 {
+		py := 0
+		for ; py <= oy*(ny-p2); py += oy * p2 {
+			px := py
+			for ; px <= py+ox*(nx-p2); px += ox * p2 {
+				p01, p10 := px+ox*p, px+oy*p
+				p11 := p10 + ox*p
+				i00, i01 := wenc14(in[px], in[p01])
+				i10, i11 := wenc14(in[p10], in[p11])
+				in[px], in[p10] = wenc14(i00, i10)
+				in[p01], in[p11] = wenc14(i01, i11)
+			}
+			if nx&p != 0 {
+				p10 := px + oy*p
+				in[px], in[p10] = wenc14(in[px], in[p10])
+			}
+		}
+		if ny&p != 0 {
+			for px := py; px <= py+ox*(nx-p2); px += ox * p2 {
+				p01 := px + ox*p
+				in[px], in[p01] = wenc14(in[px], in[p01])
+			}
+		}
+	}
+}
+
+type bitWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *bitWriter) write(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[len(w.buf)-1] |= byte(v>>i&1) << (7 - w.n%8)
+		w.n++
+	}
+}
+
+// hufEncode codes the values with equal length canonical codes, runs of the
+// previous value use the run symbol.
+func hufEncode(data []uint16) []byte {
+	im, iM := 1<<16, 0
+	for _, v := range data {
+		if int(v) < im {
+			im = int(v)
+		}
+		if int(v) > iM {
+			iM = int(v)
+		}
+	}
+	iM++ // run symbol
+	codes := make([]uint64, 1<<16+1)
+	count := 1
+	codes[iM] = 1
+	for _, v := range data {
+		if codes[v] == 0 {
+			codes[v] = 1
+			count++
+		}
+	}
+	l := uint64(1)
+	for 1<<l < count {
+		l++
+	}
+	var n [59]uint64
+	for i := range codes {
+		if codes[i] != 0 {
+			codes[i] = l
+		}
+		n[codes[i]]++
+	}
+	var c uint64
+	for i := 58; i > 0; i-- {
+		c, n[i] = (c+n[i])>>1, c
+	}
+	for i, l := range codes {
+		if l > 0 {
+			codes[i] = l | n[l]<<6
+			n[l]++
+		}
+	}
+
+	table := &bitWriter{}
+	for s := im; s <= iM; {
+		zeros := 0
+		for s+zeros <= iM && codes[s+zeros] == 0 && zeros < 255+6 {
+			zeros++
+		}
+		switch {
+		case zeros >= 6:
+			table.write(63, 6)
+			table.write(uint64(zeros-6), 8)
+		case zeros >= 2:
+			table.write(uint64(59+zeros-2), 6)
+		default:
+			table.write(codes[s]&63, 6)
+			zeros = 1
+		}
+		s += zeros
+	}
+	bits := &bitWriter{}
+	code := func(s int) { bits.write(codes[s]>>6, int(codes[s]&63)) }
+	for i := 0; i < len(data); {
+		code(int(data[i]))
+		run := 0
+		for i+1+run < len(data) && data[i+1+run] == data[i] && run < 255 {
+			run++
+		}
+		if run < 2 {
+			i++
+			continue
+		}
+		code(iM)
+		bits.write(uint64(run), 8)
+		i += 1 + run
+	}
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, [5]uint32{ // nolint: errcheck
+		uint32(im), uint32(iM), uint32(len(table.buf)), uint32(bits.n), 0,
+	})
+	buf.Write(table.buf)
+	buf.Write(bits.buf)
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	half := func(names ...string) []testChannel {
+		var ret []testChannel
+		for _, n := range names {
+			ret = append(ret, testChannel{n, 1})
+		}
+		return ret
+	}
+	tests := []struct {
+		name        string
+		chans       []testChannel
+		compression byte
+		w, h        int
+		pix         pixFunc
+		format      gorge.TextureFormat
+		// want are the texture channels, "1" is an opaque alpha.
+		want string
+	}{
+		{"none rgba", half("A", "B", "G", "R"), 0, 3, 2, pattern, gorge.TextureFormatRGBA16F, "RGBA"},
+		{"rle rgb", half("B", "G", "R"), 1, 8, 3, constant, gorge.TextureFormatRGBA16F, "RGB1"},
+		{"zips y", []testChannel{{"Y", 2}}, 2, 32, 2, pattern, gorge.TextureFormatR32F, "Y"},
+		{"zip rg", half("G", "R"), 3, 20, 20, pattern, gorge.TextureFormatRG16F, "RG"},
+		{
+			"piz mixed",
+			[]testChannel{{"A", 2}, {"B", 1}, {"G", 1}, {"R", 1}, {"Z", 0}},
+			4, 33, 53, pattern, gorge.TextureFormatRGBA32F, "RGBA",
+		},
+		{"piz runs", half("B", "G", "R"), 4, 8, 8, constant, gorge.TextureFormatRGBA16F, "RGB1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeEXR(t, 2, tt.chans, tt.compression, tt.w, tt.h, tt.pix)
+			td, err := exr.Decode(bytes.NewReader(file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if td.Format != tt.format || td.Width != tt.w || td.Height != tt.h {
+				t.Fatalf("\nwant: %v %dx%d\n got: %v %dx%d\n", tt.format, tt.w, tt.h, td.Format, td.Width, td.Height)
+			}
+			_, _, px := td.Format.BlockSize()
+			size := px / len(tt.want)
+			for y := 0; y < tt.h; y++ {
+				for x := 0; x < tt.w; x++ {
+					for c, ch := range tt.want {
+						want := float32(1)
+						if ch != '1' {
+							want = tt.pix(string(ch), x, y)
+						}
+						p := td.PixelData[(y*tt.w+x)*px+c*size:]
+						var got float32
+						if size == 2 {
+							got = fromHalf(binary.LittleEndian.Uint16(p))
+						} else {
+							got = math.Float32frombits(binary.LittleEndian.Uint32(p))
+						}
+						if got != want {
+							t.Fatalf("pixel %d,%d channel %c\nwant: %v\n got: %v\n", x, y, ch, want, got)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	rgb := []testChannel{{"B", 1}, {"G", 1}, {"R", 1}}
+	valid := writeEXR(t, 2, rgb, 0, 2, 2, pattern)
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"magic", []byte("not an exr file"), "invalid magic"},
+		{"tiled", writeEXR(t, 2|0x200, rgb, 0, 2, 2, pattern), "tiled"},
+		{"compression", writeEXR(t, 2, rgb, 5, 2, 2, pattern), "unsupported compression pxr24"},
+		{"channels", writeEXR(t, 2, []testChannel{{"Z", 1}}, 0, 2, 2, pattern), "no R, G, B, A or Y"},
+		{"truncated", valid[:len(valid)-4], "truncated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := exr.Decode(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("\nwant: %v\n got: %v\n", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package exr
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Huffman coding of PIZ data, codes are canonical and up to 58 bits long,
+// the largest symbol marks a run of the previous value.
+const (
+	hufEncSize = 1<<16 + 1
+	hufDecBits = 14
+	hufDecSize = 1 << hufDecBits
+	hufDecMask = hufDecSize - 1
+
+	shortZeroCodeRun = 59
+	longZeroCodeRun  = 63
+	shortestLongRun  = 2 + longZeroCodeRun - shortZeroCodeRun
+)
+
+var errHufCorrupt = errors.New("huf: corrupt data")
+
+// hufDec is a decoding table entry indexed by the next hufDecBits bits,
+// short codes have their length and symbol, longer ones list the candidate
+// symbols.
+type hufDec struct {
+	len  int
+	sym  int
+	long []int
+}
+
+// hufDecompress decodes len(out) values.
+func hufDecompress(src []byte, out []uint16) error {
+	if len(src) == 0 {
+		if len(out) != 0 {
+			return errHufCorrupt
+		}
+		return nil
+	}
+	if len(src) < 20 {
+		return errHufCorrupt
+	}
+	le := binary.LittleEndian
+	im, iM := int(le.Uint32(src)), int(le.Uint32(src[4:]))
+	nBits := int(le.Uint32(src[12:]))
+	if im >= hufEncSize || iM >= hufEncSize || im > iM {
+		return errHufCorrupt
+	}
+	codes := make([]uint64, hufEncSize)
+	src, err := hufUnpackEncTable(src[20:], im, iM, codes)
+	if err != nil {
+		return err
+	}
+	if nBits < 0 || nBits > 8*len(src) {
+		return errHufCorrupt
+	}
+	dec, err := hufBuildDecTable(codes, im, iM)
+	if err != nil {
+		return err
+	}
+	return hufDecode(codes, dec, src[:(nBits+7)/8], nBits, iM, out)
+}
+
+// hufUnpackEncTable reads the 6 bit code lengths of symbols im to iM and
+// returns the remaining data, codes holds the length in the lower 6 bits
+// and the code above it.
+func hufUnpackEncTable(src []byte, im, iM int, codes []uint64) ([]byte, error) {
+	var c uint64
+	lc, pos := 0, 0
+	bits := func(n int) (uint64, error) {
+		for lc < n {
+			if pos >= len(src) {
+				return 0, errHufCorrupt
+			}
+			c = c<<8 | uint64(src[pos])
+			pos++
+			lc += 8
+		}
+		lc -= n
+		return (c >> lc) & (1<<n - 1), nil
+	}
+	for ; im <= iM; im++ {
+		l, err := bits(6)
+		if err != nil {
+			return nil, err
+		}
+		codes[im] = l
+		zerun := 0
+		switch {
+		case l == longZeroCodeRun:
+			n, err := bits(8)
+			if err != nil {
+				return nil, err
+			}
+			zerun = int(n) + shortestLongRun
+		case l >= shortZeroCodeRun:
+			zerun = int(l) - shortZeroCodeRun + 2
+		default:
+			continue
+		}
+		if im+zerun > iM+1 {
+			return nil, errHufCorrupt
+		}
+		for i := 0; i < zerun; i++ {
+			codes[im+i] = 0
+		}
+		im += zerun - 1
+	}
+	hufCanonicalCodeTable(codes)
+	return src[pos:], nil
+}
+
+// hufCanonicalCodeTable assigns the canonical codes from the lengths,
+// longer codes get the lower values.
+func hufCanonicalCodeTable(codes []uint64) {
+	var n [59]uint64
+	for _, l := range codes {
+		n[l]++
+	}
+	var c uint64
+	for i := 58; i > 0; i-- {
+		nc := (c + n[i]) >> 1
+		n[i] = c
+		c = nc
+	}
+	for i, l := range codes {
+		if l > 0 {
+			codes[i] = l | n[l]<<6
+			n[l]++
+		}
+	}
+}
+
+func hufBuildDecTable(codes []uint64, im, iM int) ([]hufDec, error) {
+	dec := make([]hufDec, hufDecSize)
+	for ; im <= iM; im++ {
+		c, l := codes[im]>>6, int(codes[im]&63)
+		if c>>l != 0 {
+			return nil, errHufCorrupt
+		}
+		switch {
+		case l > hufDecBits:
+			pl := &dec[c>>(l-hufDecBits)]
+			if pl.len != 0 {
+				return nil, errHufCorrupt
+			}
+			pl.long = append(pl.long, im)
+		case l > 0:
+			base := c << (hufDecBits - l)
+			for i := uint64(0); i < 1<<(hufDecBits-l); i++ {
+				pl := &dec[base+i]
+				if pl.len != 0 || pl.long != nil {
+					return nil, errHufCorrupt
+				}
+				pl.len, pl.sym = l, im
+			}
+		}
+	}
+	return dec, nil
+}
+
+// hufDecode decodes nBits of src into out, rlc is the run symbol followed by
+// an 8 bit count of copies of the previous value.
+func hufDecode(codes []uint64, dec []hufDec, src []byte, nBits, rlc int, out []uint16) error {
+	var c uint64
+	lc, pos, o := 0, 0, 0
+	next := func() {
+		c = c<<8 | uint64(src[pos])
+		pos++
+		lc += 8
+	}
+	emit := func(sym int) error {
+		if sym != rlc {
+			if o >= len(out) {
+				return errHufCorrupt
+			}
+			out[o] = uint16(sym)
+			o++
+			return nil
+		}
+		if lc < 8 {
+			if pos >= len(src) {
+				return errHufCorrupt
+			}
+			next()
+		}
+		lc -= 8
+		n := int(byte(c >> lc))
+		if o < 1 || o+n > len(out) {
+			return errHufCorrupt
+		}
+		for v := out[o-1]; n > 0; n-- {
+			out[o] = v
+			o++
+		}
+		return nil
+	}
+
+	for pos < len(src) {
+		next()
+		for lc >= hufDecBits {
+			pl := &dec[(c>>(lc-hufDecBits))&hufDecMask]
+			if pl.len > 0 {
+				lc -= pl.len
+				if err := emit(pl.sym); err != nil {
+					return err
+				}
+				continue
+			}
+			if pl.long == nil {
+				return errHufCorrupt
+			}
+			found := false
+			for _, sym := range pl.long {
+				l := int(codes[sym] & 63)
+				for lc < l && pos < len(src) {
+					next()
+				}
+				if lc >= l && codes[sym]>>6 == (c>>(lc-l))&(1<<l-1) {
+					lc -= l
+					if err := emit(sym); err != nil {
+						return err
+					}
+					found = true
+					break
+				}
+			}
+			if !found {
+				return errHufCorrupt
+			}
+		}
+	}
+
+	// The last byte is padded.
+	i := (8 - nBits) & 7
+	c >>= i
+	lc -= i
+	for lc > 0 {
+		pl := &dec[(c<<(hufDecBits-lc))&hufDecMask]
+		if pl.len == 0 || pl.len > lc {
+			return errHufCorrupt
+		}
+		lc -= pl.len
+		if err := emit(pl.sym); err != nil {
+			return err
+		}
+	}
+	if o != len(out) {
+		return errHufCorrupt
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package exr
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// bitmapSize is the size of the bitmap marking the 16 bit values in use.
+const bitmapSize = 65536 / 8
+
+// decompressPIZ decodes the huffman coded and wavelet transformed channel
+// planes and rearranges them into scanlines.
+func decompressPIZ(src []byte, channels []channel, width, rows, size int) ([]byte, error) {
+	r := &cursor{data: src}
+	minNonZero, maxNonZero := int(r.u16()), int(r.u16())
+	if maxNonZero >= bitmapSize {
+		return nil, errors.New("piz: invalid bitmap range")
+	}
+	bitmap := make([]byte, bitmapSize)
+	if minNonZero <= maxNonZero {
+		copy(bitmap[minNonZero:], r.next(maxNonZero-minNonZero+1))
+	}
+	length := int(r.i32())
+	huf := r.next(length)
+	if r.err != nil {
+		return nil, errors.New("piz: truncated data")
+	}
+	lut, maxValue := reverseLUT(bitmap)
+
+	buf := make([]uint16, size/2)
+	if err := hufDecompress(huf, buf); err != nil {
+		return nil, err
+	}
+	// Each channel is a plane of width x rows samples, 32 bit samples are
+	// transformed as two interleaved 16 bit planes.
+	planes := make([]int, len(channels))
+	off := 0
+	for i, c := range channels {
+		n := c.size() / 2
+		planes[i] = off
+		for j := 0; j < n; j++ {
+			wav2Decode(buf[off+j:], width, n, rows, width*n, maxValue)
+		}
+		off += width * rows * n
+	}
+	for i, v := range buf {
+		buf[i] = lut[v]
+	}
+
+	out := make([]byte, size)
+	o := 0
+	for y := 0; y < rows; y++ {
+		for i, c := range channels {
+			n := width * c.size() / 2
+			for _, v := range buf[planes[i] : planes[i]+n] {
+				binary.LittleEndian.PutUint16(out[o:], v)
+				o += 2
+			}
+			planes[i] += n
+		}
+	}
+	return out, nil
+}
+
+// reverseLUT returns the table mapping the dense values back to the 16 bit
+// values marked in the bitmap and the largest dense value, zero is always
+// in use.
+func reverseLUT(bitmap []byte) ([]uint16, uint16) {
+	lut := make([]uint16, 1<<16)
+	k := 0
+	for i := 0; i < 1<<16; i++ {
+		if i == 0 || bitmap[i>>3]&(1<<(i&7)) != 0 {
+			lut[k] = uint16(i)
+			k++
+		}
+	}
+	return lut, uint16(k - 1)
+}
+
+// wav2Decode reverses the 2D haar wavelet transform of an nx x ny plane, ox
+// and oy are the offsets between samples and rows, values below 1<<14 use
+// the lossless 14 bit transform.
+func wav2Decode(in []uint16, nx, ox, ny, oy int, mx uint16) {
+	dec := wdec16
+	if mx < 1<<14 {
+		dec = wdec14
+	}
+	n := nx
+	if ny < n {
+		n = ny
+	}
+	p := 1
+	for p <= n {
+		p <<= 1
+	}
+	p >>= 1
+	p2 := p
+	p >>= 1
+
+	for ; p >= 1; p2, p = p, p>>1 {
+		oy1, oy2 := oy*p, oy*p2
+		ox1, ox2 := ox*p, ox*p2
+		ey := oy * (ny - p2)
+		py := 0
+		for ; py <= ey; py += oy2 {
+			ex := py + ox*(nx-p2)
+			px := py
+			for ; px <= ex; px += ox2 {
+				p01 := px + ox1
+				p10 := px + oy1
+				p11 := p10 + ox1
+				i00, i10 := dec(in[px], in[p10])
+				i01, i11 := dec(in[p01], in[p11])
+				in[px], in[p01] = dec(i00, i01)
+				in[p10], in[p11] = dec(i10, i11)
+			}
+			// Odd column.
+			if nx&p != 0 {
+				p10 := px + oy1
+				in[px], in[p10] = dec(in[px], in[p10])
+			}
+		}
+		// Odd line.
+		if ny&p != 0 {
+			ex := py + ox*(nx-p2)
+			for px := py; px <= ex; px += ox2 {
+				p01 := px + ox1
+				in[px], in[p01] = dec(in[px], in[p01])
+			}
+		}
+	}
+}
+
+// wdec14 reverses the wavelet step of values that fit in 14 bits.
+func wdec14(l, h uint16) (uint16, uint16) {
+	hi := int(int16(h))
+	ai := int(int16(l)) + (hi & 1) + (hi >> 1)
+	return uint16(int16(ai)), uint16(int16(ai - hi))
+}
+
+// wdec16 reverses the modulo wavelet step of 16 bit values.
+func wdec16(l, h uint16) (uint16, uint16) {
+	const (
+		offset = 1 << 15
+		mask   = 1<<16 - 1
+	)
+	m, d := int(l), int(h)
+	b := (m - (d >> 1)) & mask
+	a := (d + b - offset) & mask
+	return uint16(a), uint16(b)
+}
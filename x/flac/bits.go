@@ -0,0 +1,104 @@
+package flac
+
+import "io"
+
+// bitReader reads big endian bit fields.
+type bitReader struct {
+	buf []byte
+	pos int
+}
+
+func (b *bitReader) read(n uint) (uint64, error) {
+	if b.pos+int(n) > len(b.buf)*8 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	var v uint64
+	for n > 0 {
+		off := uint(b.pos & 7)
+		take := 8 - off
+		if take > n {
+			take = n
+		}
+		bits := uint64(b.buf[b.pos>>3]) >> (8 - off - take) & (1<<take - 1)
+		v = v<<take | bits
+		b.pos += int(take)
+		n -= take
+	}
+	return v, nil
+}
+
+func (b *bitReader) readSigned(n uint) (int64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	v, err := b.read(n)
+	if err != nil {
+		return 0, err
+	}
+	return int64(v<<(64-n)) >> (64 - n), nil
+}
+
+// unary returns the number of 0 bits before the next 1 bit.
+func (b *bitReader) unary() (int, error) {
+	n := 0
+	for {
+		if b.pos&7 == 0 {
+			// Skip whole zero bytes.
+			for b.pos>>3 < len(b.buf) && b.buf[b.pos>>3] == 0 {
+				n += 8
+				b.pos += 8
+			}
+		}
+		bit, err := b.read(1)
+		if err != nil {
+			return 0, err
+		}
+		if bit == 1 {
+			return n, nil
+		}
+		n++
+	}
+}
+
+func (b *bitReader) align() {
+	b.pos = (b.pos + 7) &^ 7
+}
+
+var crc8Table, crc16Table = func() ([256]byte, [256]uint16) {
+	var t8 [256]byte
+	var t16 [256]uint16
+	for i := 0; i < 256; i++ {
+		c8 := byte(i)
+		c16 := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if c8&0x80 != 0 {
+				c8 = c8<<1 ^ 0x07
+			} else {
+				c8 <<= 1
+			}
+			if c16&0x8000 != 0 {
+				c16 = c16<<1 ^ 0x8005
+			} else {
+				c16 <<= 1
+			}
+		}
+		t8[i], t16[i] = c8, c16
+	}
+	return t8, t16
+}()
+
+func crc8(b []byte) byte {
+	var c byte
+	for _, v := range b {
+		c = crc8Table[c^v]
+	}
+	return c
+}
+
+func crc16(b []byte) uint16 {
+	var c uint16
+	for _, v := range b {
+		c = c<<8 ^ crc16Table[byte(c>>8)^v]
+	}
+	return c
+}
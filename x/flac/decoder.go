@@ -0,0 +1,483 @@
+// Package flac decodes FLAC files into gorge.AudioClipData.
+package flac
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/stdiopt/gorge"
+)
+
+const (
+	blockStreamInfo = 0
+	frameSync       = 0x3FFE
+)
+
+type streamInfo struct {
+	sampleRate int
+	channels   int
+	bps        uint
+	total      int
+}
+
+// Decode reads a FLAC file, samples up to 16 bits are decoded as
+// gorge.AudioFormatS16 and wider samples as gorge.AudioFormatS32.
+func Decode(rd io.Reader) (*gorge.AudioClipData, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, fmt.Errorf("flac: %w", err)
+	}
+	data = skipID3(data)
+	if !bytes.HasPrefix(data, []byte("fLaC")) {
+		return nil, errors.New("flac: invalid marker")
+	}
+
+	pos := 4
+	var info *streamInfo
+	for last := false; !last; {
+		if pos+4 > len(data) {
+			return nil, errors.New("flac: truncated metadata")
+		}
+		last = data[pos]&0x80 != 0
+		typ := data[pos] & 0x7F
+		size := int(data[pos+1])<<16 | int(data[pos+2])<<8 | int(data[pos+3])
+		pos += 4
+		if pos+size > len(data) {
+			return nil, errors.New("flac: truncated metadata")
+		}
+		if typ == blockStreamInfo {
+			if info, err = readStreamInfo(data[pos : pos+size]); err != nil {
+				return nil, err
+			}
+		}
+		pos += size
+	}
+	if info == nil {
+		return nil, errors.New("flac: missing stream info")
+	}
+
+	d := &decoder{
+		info: info,
+		br:   bitReader{buf: data, pos: pos * 8},
+	}
+	format := gorge.AudioFormatS16
+	if info.bps > 16 {
+		format = gorge.AudioFormatS32
+	}
+	// The header total isn't trusted for the allocation, a small stream can
+	// claim any length, the buffer grows as frames are decoded.
+	size := info.total * info.channels * format.Size()
+	out := make([]byte, 0, minInt(size, len(data)))
+	for frames := 0; d.br.pos < len(data)*8; frames += d.blockSize {
+		if info.total > 0 && frames >= info.total {
+			break
+		}
+		// Trailing ID3v1 tag.
+		if bytes.HasPrefix(data[d.br.pos/8:], []byte("TAG")) {
+			break
+		}
+		if err := d.frame(); err != nil {
+			return nil, fmt.Errorf("flac: frame at %d: %w", frames, err)
+		}
+		out = d.appendSamples(out, format)
+	}
+	if info.total > 0 {
+		if len(out) < size {
+			return nil, fmt.Errorf("flac: stream has %d of %d samples", len(out)/(info.channels*format.Size()), info.total)
+		}
+		out = out[:size]
+	}
+	return &gorge.AudioClipData{
+		Format:     format,
+		SampleRate: info.sampleRate,
+		Channels:   info.channels,
+		Data:       out,
+	}, nil
+}
+
+func readStreamInfo(b []byte) (*streamInfo, error) {
+	if len(b) < 34 {
+		return nil, errors.New("flac: short stream info")
+	}
+	br := bitReader{buf: b, pos: 80}
+	rate, _ := br.read(20)
+	channels, _ := br.read(3)
+	bps, _ := br.read(5)
+	total, _ := br.read(36)
+	if rate == 0 {
+		return nil, errors.New("flac: invalid sample rate")
+	}
+	return &streamInfo{
+		sampleRate: int(rate),
+		channels:   int(channels) + 1,
+		bps:        uint(bps) + 1,
+		total:      int(total),
+	}, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// skipID3 skips an ID3v2 tag some encoders prepend.
+func skipID3(data []byte) []byte {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return data
+	}
+	size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+	if 10+size > len(data) {
+		return data
+	}
+	return data[10+size:]
+}
+
+type decoder struct {
+	info      *streamInfo
+	br        bitReader
+	blockSize int
+	bps       uint
+	channels  [8][]int64
+	nchannels int
+}
+
+// frame decodes the next frame into channels.
+func (d *decoder) frame() error {
+	br := &d.br
+	start := br.pos / 8
+	if sync, err := br.read(14); err != nil || sync != frameSync {
+		return errors.New("invalid sync code")
+	}
+	// Reserved bit and blocking strategy.
+	br.read(2) // nolint: errcheck
+	bsCode, _ := br.read(4)
+	srCode, _ := br.read(4)
+	assign, _ := br.read(4)
+	ssCode, _ := br.read(3)
+	br.read(1) // nolint: errcheck
+
+	// UTF-8 like coded frame or sample number.
+	first, err := br.read(8)
+	if err != nil {
+		return err
+	}
+	for mask := uint64(0x40); first&0x80 != 0 && first&mask != 0; mask >>= 1 {
+		br.read(8) // nolint: errcheck
+	}
+
+	switch {
+	case bsCode == 1:
+		d.blockSize = 192
+	case bsCode >= 2 && bsCode <= 5:
+		d.blockSize = 576 << (bsCode - 2)
+	case bsCode == 6:
+		v, _ := br.read(8)
+		d.blockSize = int(v) + 1
+	case bsCode == 7:
+		v, _ := br.read(16)
+		d.blockSize = int(v) + 1
+	case bsCode >= 8:
+		d.blockSize = 256 << (bsCode - 8)
+	default:
+		return errors.New("reserved block size")
+	}
+	switch srCode {
+	case 12:
+		br.read(8) // nolint: errcheck
+	case 13, 14:
+		br.read(16) // nolint: errcheck
+	case 15:
+		return errors.New("invalid sample rate")
+	}
+	switch ssCode {
+	case 0:
+		d.bps = d.info.bps
+	case 1:
+		d.bps = 8
+	case 2:
+		d.bps = 12
+	case 4:
+		d.bps = 16
+	case 5:
+		d.bps = 20
+	case 6:
+		d.bps = 24
+	case 7:
+		d.bps = 32
+	default:
+		return errors.New("reserved sample size")
+	}
+	end := br.pos / 8
+	crc, err := br.read(8)
+	if err != nil {
+		return err
+	}
+	if byte(crc) != crc8(br.buf[start:end]) {
+		return errors.New("header crc mismatch")
+	}
+
+	switch {
+	case assign < 8:
+		d.nchannels = int(assign) + 1
+	case assign <= 10:
+		d.nchannels = 2
+	default:
+		return errors.New("reserved channel assignment")
+	}
+	if d.nchannels != d.info.channels {
+		return fmt.Errorf("want %d channels, got %d", d.info.channels, d.nchannels)
+	}
+	for ch := 0; ch < d.nchannels; ch++ {
+		bps := d.bps
+		// The side channel has an extra bit.
+		if (assign == 8 || assign == 10) && ch == 1 || assign == 9 && ch == 0 {
+			bps++
+		}
+		if cap(d.channels[ch]) < d.blockSize {
+			d.channels[ch] = make([]int64, d.blockSize)
+		}
+		d.channels[ch] = d.channels[ch][:d.blockSize]
+		if err := d.subframe(d.channels[ch], bps); err != nil {
+			return fmt.Errorf("channel %d: %w", ch, err)
+		}
+	}
+	d.decorrelate(assign)
+
+	br.align()
+	end = br.pos / 8
+	crc, err = br.read(16)
+	if err != nil {
+		return err
+	}
+	if uint16(crc) != crc16(br.buf[start:end]) {
+		return errors.New("frame crc mismatch")
+	}
+	return nil
+}
+
+func (d *decoder) subframe(s []int64, bps uint) error {
+	br := &d.br
+	if pad, err := br.read(1); err != nil || pad != 0 {
+		return errors.New("invalid subframe padding")
+	}
+	typ, _ := br.read(6)
+	if flag, _ := br.read(1); flag == 1 {
+		n, err := br.unary()
+		if err != nil {
+			return err
+		}
+		wasted := uint(n) + 1
+		if wasted >= bps {
+			return errors.New("invalid wasted bits")
+		}
+		bps -= wasted
+		defer func() {
+			for i := range s {
+				s[i] <<= wasted
+			}
+		}()
+	}
+
+	switch {
+	case typ == 0:
+		v, err := br.readSigned(bps)
+		if err != nil {
+			return err
+		}
+		for i := range s {
+			s[i] = v
+		}
+		return nil
+	case typ == 1:
+		for i := range s {
+			v, err := br.readSigned(bps)
+			if err != nil {
+				return err
+			}
+			s[i] = v
+		}
+		return nil
+	case typ >= 8 && typ <= 12:
+		return d.fixed(s, int(typ-8), bps)
+	case typ >= 32:
+		return d.lpc(s, int(typ-31), bps)
+	default:
+		return fmt.Errorf("reserved subframe type %d", typ)
+	}
+}
+
+func (d *decoder) warmup(s []int64, order int, bps uint) error {
+	if order > len(s) {
+		return errors.New("predictor order larger than block")
+	}
+	for i := 0; i < order; i++ {
+		v, err := d.br.readSigned(bps)
+		if err != nil {
+			return err
+		}
+		s[i] = v
+	}
+	return nil
+}
+
+func (d *decoder) fixed(s []int64, order int, bps uint) error {
+	if err := d.warmup(s, order, bps); err != nil {
+		return err
+	}
+	if err := d.residual(s, order); err != nil {
+		return err
+	}
+	for i := order; i < len(s); i++ {
+		switch order {
+		case 1:
+			s[i] += s[i-1]
+		case 2:
+			s[i] += 2*s[i-1] - s[i-2]
+		case 3:
+			s[i] += 3*s[i-1] - 3*s[i-2] + s[i-3]
+		case 4:
+			s[i] += 4*s[i-1] - 6*s[i-2] + 4*s[i-3] - s[i-4]
+		}
+	}
+	return nil
+}
+
+func (d *decoder) lpc(s []int64, order int, bps uint) error {
+	br := &d.br
+	if err := d.warmup(s, order, bps); err != nil {
+		return err
+	}
+	precision, _ := br.read(4)
+	if precision == 15 {
+		return errors.New("invalid lpc precision")
+	}
+	shift, err := br.readSigned(5)
+	if err != nil {
+		return err
+	}
+	if shift < 0 {
+		return errors.New("negative lpc shift")
+	}
+	coefs := make([]int64, order)
+	for i := range coefs {
+		if coefs[i], err = br.readSigned(uint(precision) + 1); err != nil {
+			return err
+		}
+	}
+	if err := d.residual(s, order); err != nil {
+		return err
+	}
+	for i := order; i < len(s); i++ {
+		var sum int64
+		for j, c := range coefs {
+			sum += c * s[i-1-j]
+		}
+		s[i] += sum >> shift
+	}
+	return nil
+}
+
+// residual decodes the rice coded residual after the warmup samples.
+func (d *decoder) residual(s []int64, order int) error {
+	br := &d.br
+	method, _ := br.read(2)
+	if method > 1 {
+		return errors.New("reserved residual coding method")
+	}
+	paramBits, escape := uint(4), uint64(15)
+	if method == 1 {
+		paramBits, escape = 5, 31
+	}
+	porder, err := br.read(4)
+	if err != nil {
+		return err
+	}
+	parts := 1 << porder
+	n := len(s)
+	if n%parts != 0 || n/parts < order {
+		return errors.New("invalid residual partition order")
+	}
+	i := order
+	for p := 0; p < parts; p++ {
+		cnt := n / parts
+		if p == 0 {
+			cnt -= order
+		}
+		param, err := br.read(paramBits)
+		if err != nil {
+			return err
+		}
+		if param == escape {
+			nbits, _ := br.read(5)
+			for j := 0; j < cnt; j++ {
+				v, err := br.readSigned(uint(nbits))
+				if err != nil {
+					return err
+				}
+				s[i] = v
+				i++
+			}
+			continue
+		}
+		for j := 0; j < cnt; j++ {
+			q, err := br.unary()
+			if err != nil {
+				return err
+			}
+			low, err := br.read(uint(param))
+			if err != nil {
+				return err
+			}
+			v := uint64(q)<<param | low
+			s[i] = int64(v>>1) ^ -int64(v&1)
+			i++
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decorrelate(assign uint64) {
+	l, r := d.channels[0], d.channels[1]
+	switch assign {
+	case 8: // left, side
+		for i := range l {
+			r[i] = l[i] - r[i]
+		}
+	case 9: // side, right
+		for i := range l {
+			l[i] += r[i]
+		}
+	case 10: // mid, side
+		for i := range l {
+			mid := l[i]<<1 | r[i]&1
+			side := r[i]
+			l[i] = (mid + side) >> 1
+			r[i] = (mid - side) >> 1
+		}
+	}
+}
+
+// appendSamples appends the decoded frame interleaved in format.
+func (d *decoder) appendSamples(out []byte, format gorge.AudioFormat) []byte {
+	bits := uint(format.Size() * 8)
+	for i := 0; i < d.blockSize; i++ {
+		for ch := 0; ch < d.nchannels; ch++ {
+			v := d.channels[ch][i]
+			if d.bps < bits {
+				v <<= bits - d.bps
+			} else {
+				v >>= d.bps - bits
+			}
+			if format == gorge.AudioFormatS16 {
+				out = append(out, byte(v), byte(v>>8))
+				continue
+			}
+			out = append(out, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+		}
+	}
+	return out
+}
@@ -0,0 +1,225 @@
+package flac_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/flac"
+)
+
+type bitWriter struct {
+	buf []byte
+	n   uint
+}
+
+func (w *bitWriter) write(v uint64, bits uint) {
+	for i := bits; i > 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[len(w.buf)-1] |= byte(v>>(i-1)&1) << (7 - w.n%8)
+		w.n++
+	}
+}
+
+func (w *bitWriter) signed(v int64, bits uint) {
+	w.write(uint64(v)&(1<<bits-1), bits)
+}
+
+func (w *bitWriter) rice(v int64, k uint) {
+	u := uint64(v<<1 ^ v>>63)
+	for q := u >> k; q > 0; q-- {
+		w.write(0, 1)
+	}
+	w.write(1, 1)
+	w.write(u, k)
+}
+
+func (w *bitWriter) align() {
+	w.n = (w.n + 7) &^ 7
+}
+
+func crc(b []byte, poly, width uint) uint64 {
+	var c uint64
+	top := uint64(1) << (width - 1)
+	for _, v := range b {
+		c ^= uint64(v) << (width - 8)
+		for i := 0; i < 8; i++ {
+			if c&top != 0 {
+				c = c<<1 ^ uint64(poly)
+			} else {
+				c <<= 1
+			}
+		}
+		c &= 1<<width - 1
+	}
+	return c
+}
+
+const blockSize = 16
+
+// frame writes a 16 bit frame, sub writes the subframes.
+func frame(w *bitWriter, num, assign uint64, sub func()) {
+	start := len(w.buf)
+	w.write(0x3FFE, 14)
+	w.write(0, 2)
+	w.write(7, 4) // 16 bit block size at the end of the header
+	w.write(0, 4) // sample rate from stream info
+	w.write(assign, 4)
+	w.write(4, 3) // 16 bits per sample
+	w.write(0, 1)
+	w.write(num, 8)
+	w.write(blockSize-1, 16)
+	w.write(crc(w.buf[start:], 0x07, 8), 8)
+	sub()
+	w.align()
+	w.write(crc(w.buf[start:], 0x8005, 16), 16)
+}
+
+func verbatim(w *bitWriter, s []int64, bps uint) {
+	w.write(1<<1, 8)
+	for _, v := range s {
+		w.signed(v, bps)
+	}
+}
+
+// fixed2 writes a fixed order 2 subframe with 2 partitions, the second is
+// escaped with raw residuals.
+func fixed2(w *bitWriter, s []int64, bps uint) {
+	w.write(10<<1, 8)
+	w.signed(s[0], bps)
+	w.signed(s[1], bps)
+	w.write(0, 2) // 4 bit rice parameters
+	w.write(1, 4) // 2 partitions
+	w.write(3, 4)
+	for i := 2; i < len(s); i++ {
+		if i == len(s)/2 {
+			w.write(15, 4) // escape
+			w.write(18, 5)
+		}
+		res := s[i] - (2*s[i-1] - s[i-2])
+		if i < len(s)/2 {
+			w.rice(res, 3)
+			continue
+		}
+		w.signed(res, 18)
+	}
+}
+
+// lpc1 writes an order 1 lpc subframe with a wasted bit.
+func lpc1(w *bitWriter, s []int64, bps uint) {
+	w.write(32<<1|1, 8)
+	w.write(1, 1) // 1 wasted bit
+	bps--
+	w.signed(s[0]>>1, bps)
+	w.write(1, 4)  // precision 2
+	w.signed(0, 5) // shift
+	w.signed(1, 2) // coefficient
+	w.write(1, 2)  // 5 bit rice parameters
+	w.write(0, 4)  // 1 partition
+	w.write(4, 5)
+	for i := 1; i < len(s); i++ {
+		w.rice(s[i]>>1-s[i-1]>>1, 4)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var left, right [3 * blockSize]int64
+	for i := range left {
+		left[i] = int64(i*i*37%20000 - 10000)
+		right[i] = left[i] - int64(i%5)*2
+	}
+	const total = 40
+
+	w := &bitWriter{}
+	w.buf = append(w.buf, "fLaC"...)
+	w.buf = append(w.buf, 0x01, 0, 0, 4, 0, 0, 0, 0) // padding
+	w.buf = append(w.buf, 0x80, 0, 0, 34)
+	w.write(blockSize, 16)
+	w.write(blockSize, 16)
+	w.write(0, 48)
+	w.write(22050, 20)
+	w.write(1, 3)
+	w.write(15, 5)
+	w.write(total, 36)
+	w.write(0, 128)
+
+	l, r := left[:blockSize], right[:blockSize]
+	frame(w, 0, 1, func() {
+		verbatim(w, l, 16)
+		w.write(0, 8) // constant
+		w.signed(r[0], 16)
+	})
+	for i := range r {
+		r[i] = r[0]
+	}
+
+	l, r = left[blockSize:2*blockSize], right[blockSize:2*blockSize]
+	mid, side := make([]int64, blockSize), make([]int64, blockSize)
+	for i := range l {
+		mid[i], side[i] = (l[i]+r[i])>>1, l[i]-r[i]
+	}
+	frame(w, 1, 10, func() {
+		fixed2(w, mid, 16)
+		lpc1(w, side, 17)
+	})
+
+	l, r = left[2*blockSize:], right[2*blockSize:]
+	for i := range l {
+		side[i] = l[i] - r[i]
+	}
+	frame(w, 2, 9, func() {
+		verbatim(w, side, 17)
+		verbatim(w, r, 16)
+	})
+
+	clip, err := flac.Decode(bytes.NewReader(w.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clip.Format != gorge.AudioFormatS16 || clip.SampleRate != 22050 || clip.Channels != 2 {
+		t.Errorf("want S16 22050Hz stereo, got %v %dHz %d channels", clip.Format, clip.SampleRate, clip.Channels)
+	}
+	if clip.Frames() != total {
+		t.Fatalf("\nwant: %v\n got: %v\n", total, clip.Frames())
+	}
+	for i := 0; i < total; i++ {
+		gl := int64(int16(binary.LittleEndian.Uint16(clip.Data[i*4:])))
+		gr := int64(int16(binary.LittleEndian.Uint16(clip.Data[i*4+2:])))
+		if gl != left[i] || gr != right[i] {
+			t.Fatalf("sample %d\nwant: %v %v\n got: %v %v\n", i, left[i], right[i], gl, gr)
+		}
+	}
+
+	t.Run("crc", func(t *testing.T) {
+		bad := append([]byte{}, w.buf...)
+		bad[len(bad)-3] ^= 0xFF
+		_, err := flac.Decode(bytes.NewReader(bad))
+		if err == nil || !strings.Contains(err.Error(), "crc mismatch") {
+			t.Errorf("\nwant: %v\n got: %v\n", "crc mismatch", err)
+		}
+	})
+	t.Run("header total", func(t *testing.T) {
+		// A stream info claiming the largest total followed by a tag.
+		w := &bitWriter{}
+		w.buf = append(w.buf, "fLaC"...)
+		w.buf = append(w.buf, 0x80, 0, 0, 34)
+		w.write(blockSize, 16)
+		w.write(blockSize, 16)
+		w.write(0, 48)
+		w.write(44100, 20)
+		w.write(7, 3)
+		w.write(31, 5)
+		w.write(1<<36-1, 36)
+		w.write(0, 128)
+		w.buf = append(w.buf, "TAG"...)
+		w.buf = append(w.buf, make([]byte, 60-len(w.buf))...)
+		_, err := flac.Decode(bytes.NewReader(w.buf))
+		if err == nil || !strings.Contains(err.Error(), "samples") {
+			t.Errorf("\nwant: %v\n got: %v\n", "missing samples", err)
+		}
+	})
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/stdiopt/gorge"
 	"github.com/stdiopt/gorge/anim"
-	"github.com/stdiopt/gorge/gorgeutil"
 	"github.com/stdiopt/gorge/math/gm"
 	"github.com/stdiopt/gorge/static"
 	"github.com/stdiopt/gorge/systems/resource"
@@ -25,7 +24,8 @@ type GLTF struct {
 	Skins      []*GSkin
 	Animations []*anim.Animation
 
-	updateFn []func(dt float32)
+	animations []*animData
+	updateFn   []func(dt float32)
 }
 
 type gltfCreator struct {
@@ -43,7 +43,8 @@ type gltfCreator struct {
 	Skins      []*GSkin
 	Animations []*anim.Animation
 
-	updateFn []func(dt float32)
+	animations []*animData
+	updateFn   []func(dt float32)
 }
 
 // gltf Model into gorge based stuff
@@ -70,6 +71,7 @@ func create(g *gorge.Context, doc *Doc) *GLTF {
 		Meshes:     c.Meshes,
 		Skins:      c.Skins,
 		Animations: c.Animations,
+		animations: c.animations,
 		updateFn:   c.updateFn,
 	}
 	go c.gorge.RunInMain(func() {
@@ -91,6 +93,38 @@ func (r *GLTF) ReleaseRawData(g *gorge.Context) {
 	}
 }
 
+// Clone returns a new instance sharing textures, materials, meshes and skins,
+// nodes and scenes are deep cloned and skins and animations are bound to the
+// cloned nodes.
+func (r *GLTF) Clone() *GLTF {
+	c := gorge.NewCloner()
+	nodes := make([]*GNode, len(r.Nodes))
+	for i, n := range r.Nodes {
+		nodes[i] = c.Entity(n).(*GNode)
+	}
+	scenes := make([]*GScene, len(r.Scenes))
+	for i, s := range r.Scenes {
+		scenes[i] = c.Entity(s).(*GScene)
+	}
+	c.Finish()
+
+	animations := make([]*anim.Animation, len(r.animations))
+	for i, a := range r.animations {
+		animations[i] = a.bind(nodes)
+	}
+	return &GLTF{
+		Textures:   r.Textures,
+		Materials:  r.Materials,
+		Scenes:     scenes,
+		Nodes:      nodes,
+		Meshes:     r.Meshes,
+		Skins:      r.Skins,
+		Animations: animations,
+		animations: r.animations,
+		updateFn:   skinUpdates(nodes),
+	}
+}
+
 // UpdateDelta to be manually called to trigger animations, morphs etc.
 func (r *GLTF) UpdateDelta(dt float32) {
 	for _, fn := range r.updateFn {
@@ -323,7 +357,7 @@ func (c *gltfCreator) processNodes() {
 				// Clone mesh too
 				primMesh := r.Mesh.Clone()
 				primMesh.Define("HAS_SINGLE_INSTANCE")
-				p := gorgeutil.NewRenderable(primMesh, r.Material)
+				p := newPrimEntity(primMesh, r.Material)
 				p.SetParent(node)
 				node.entities = append(node.entities, p)
 				if node.skin == nil {
@@ -334,19 +368,6 @@ func (c *gltfCreator) processNodes() {
 					"USE_SKINNING",
 					fmt.Sprintf("JOINT_COUNT %d", len(node.skin.Matrices)),
 				)
-				fn := func(_ float32) {
-					for i, ni := range node.skin.Joints {
-						m := node.Mat4().Inv()
-						m = m.Mul(nodes[ni].Mat4())
-						m = m.Mul(node.skin.Matrices[i])
-						// This should be set somewhere automatically within mesh
-						primMesh.Set(fmt.Sprintf("u_jointMatrix[%d]", i), m)
-						primMesh.Set(fmt.Sprintf("u_jointNormalMatrix[%d]", i), m.Inv().Transpose())
-						primMesh.Update()
-					}
-				}
-				c.updateFn = append(c.updateFn, fn)
-
 			}
 		}
 
@@ -376,11 +397,43 @@ func (c *gltfCreator) processNodes() {
 	for i, n := range nodes {
 		for _, ci := range c.doc.Nodes[i].Children {
 			child := nodes[ci]
-			child.SetParent(n)
+			if err := child.SetParent(n); err != nil {
+				c.gorge.Log("gltf").Warn("invalid node child", "node", i, "child", ci, "error", err)
+				continue
+			}
 			n.children = append(n.children, child)
 		}
 	}
 	c.Nodes = nodes
+	c.updateFn = skinUpdates(nodes)
+}
+
+// skinUpdates returns the funcs that update the joint matrices of skinned
+// node primitives.
+func skinUpdates(nodes []*GNode) []func(dt float32) {
+	var fns []func(dt float32)
+	for _, node := range nodes {
+		if node.skin == nil {
+			continue
+		}
+		node := node
+		for _, e := range node.entities {
+			primMesh := e.(*primEntity).Mesh
+			fn := func(_ float32) {
+				for i, ni := range node.skin.Joints {
+					m := node.Mat4().Inv()
+					m = m.Mul(nodes[ni].Mat4())
+					m = m.Mul(node.skin.Matrices[i])
+					// This should be set somewhere automatically within mesh
+					primMesh.Set(fmt.Sprintf("u_jointMatrix[%d]", i), m)
+					primMesh.Set(fmt.Sprintf("u_jointNormalMatrix[%d]", i), m.Inv().Transpose())
+					primMesh.Update()
+				}
+			}
+			fns = append(fns, fn)
+		}
+	}
+	return fns
 }
 
 func (c *gltfCreator) processScenes() {
@@ -402,16 +455,33 @@ func (c *gltfCreator) processScenes() {
 func (c *gltfCreator) processAnimations() {
 	animations := []*anim.Animation{}
 	for _, a := range c.doc.Animations {
-		animations = append(animations, c.getGAnimation(a))
+		data := c.getAnimData(a)
+		c.animations = append(c.animations, data)
+		animations = append(animations, data.bind(c.Nodes))
 	}
 	c.Animations = animations
 }
 
-func (c *gltfCreator) getGAnimation(a *Animation) *anim.Animation {
-	gAnim := &anim.Animation{}
-	gAnim.SetLoop(anim.LoopAlways)
-	for _, ch := range a.Channels {
+// animData is a parsed animation, it is bound to nodes so clones can
+// retarget it.
+type animData struct {
+	channels []animChannel
+}
+
+type animChannel struct {
+	node int
+	path string
+	keys []float32
+	ease func(float32) float32
+	// values for translation and scale or rotation as xyzw.
+	values []gm.Vec4
+	// morph target weights per key.
+	weights [][]float32
+}
 
+func (c *gltfCreator) getAnimData(a *Animation) *animData {
+	data := &animData{}
+	for _, ch := range a.Channels {
 		s := a.Samplers[ch.Sampler]
 		keys := bufF32Slice(acBuf(c.doc.AccessorBuffer(s.Input)))
 
@@ -423,41 +493,70 @@ func (c *gltfCreator) getGAnimation(a *Animation) *anim.Animation {
 			ds = 3
 		}
 
-		// var track *anim.Channel[gm.Vec3]
-		// var val []any
-		// We have to manually add node as we don't have it in gorge stuff
-		// Track translation for now
-		targetNode := c.Nodes[ch.Target.Node]
+		achan := animChannel{
+			node: ch.Target.Node,
+			path: ch.Target.Path,
+			keys: append([]float32{}, keys...),
+			ease: animEase(s.Interpolation),
+		}
 		switch ch.Target.Path {
-		case "translation":
-			data := bufVec3Slice(acBuf(c.doc.AccessorBuffer(s.Output)))
-			ch := anim.AddChannel(gAnim, anim.Vec3)
-			ch.On(anim.Ptr(&targetNode.Position))
-			for i, k := range keys {
-				kk := ch.SetKey(k, data[i*ds+off])
-				kk.SetEase(animEase(s.Interpolation))
+		case "translation", "scale":
+			buf := bufVec3Slice(acBuf(c.doc.AccessorBuffer(s.Output)))
+			for i := range keys {
+				achan.values = append(achan.values, buf[i*ds+off].Vec4(0))
 			}
 		case "rotation":
-			data := bufVec4Slice(acBuf(c.doc.AccessorBuffer(s.Output)))
+			buf := bufVec4Slice(acBuf(c.doc.AccessorBuffer(s.Output)))
+			for i := range keys {
+				achan.values = append(achan.values, buf[i*ds+off])
+			}
+		case "weights":
+			buf := bufF32Slice(acBuf(c.doc.AccessorBuffer(s.Output)))
 
-			ch := anim.AddChannel(gAnim, anim.Quat)
-			ch.On(anim.Ptr(&targetNode.Rotation))
-			for i, k := range keys {
-				kk := ch.SetKey(k, gm.Quat(data[i*ds+off]))
-				kk.SetEase(animEase(s.Interpolation))
+			wlen := len(c.Nodes[ch.Target.Node].mesh.Weights)
+			for i := range keys {
+				kd := make([]float32, wlen)
+				off := i*ds*wlen + off
+				end := off + wlen
+				copy(kd, buf[off:end])
+				achan.weights = append(achan.weights, kd)
 			}
-		case "scale":
-			data := bufVec3Slice(acBuf(c.doc.AccessorBuffer(s.Output)))
+		default:
+			continue
+		}
+		data.channels = append(data.channels, achan)
+	}
+	return data
+}
 
+// bind creates an animation targeting nodes.
+func (a *animData) bind(nodes []*GNode) *anim.Animation {
+	gAnim := &anim.Animation{}
+	gAnim.SetLoop(anim.LoopAlways)
+	for _, achan := range a.channels {
+		// We have to manually add node as we don't have it in gorge stuff
+		targetNode := nodes[achan.node]
+		switch achan.path {
+		case "translation", "scale":
+			// Setters so the node children follow the animation.
+			set := targetNode.SetPositionv
+			if achan.path == "scale" {
+				set = targetNode.SetScalev
+			}
 			ch := anim.AddChannel(gAnim, anim.Vec3)
-			ch.On(anim.Ptr(&targetNode.Scale))
-			for i, k := range keys {
-				kk := ch.SetKey(k, data[i*ds+off])
-				kk.SetEase(animEase(s.Interpolation))
+			ch.On(set)
+			for i, k := range achan.keys {
+				kk := ch.SetKey(k, achan.values[i].Vec3())
+				kk.SetEase(achan.ease)
+			}
+		case "rotation":
+			ch := anim.AddChannel(gAnim, anim.Quat)
+			ch.On(targetNode.SetRotation)
+			for i, k := range achan.keys {
+				kk := ch.SetKey(k, gm.Quat(achan.values[i]))
+				kk.SetEase(achan.ease)
 			}
 		case "weights":
-			data := bufF32Slice(acBuf(c.doc.AccessorBuffer(s.Output)))
-
 			wlen := len(targetNode.mesh.Weights)
 			weightProps := make([]string, wlen)
 			for i := 0; i < wlen; i++ {
@@ -469,23 +568,18 @@ func (c *gltfCreator) getGAnimation(a *Animation) *anim.Animation {
 					v := gm.Lerp(a[i], b[i], dt) // Might be different according to interpolator
 					// Set in every entity?
 					for _, e := range targetNode.entities {
-						p := e.(*gorgeutil.Entity)
+						p := e.(*primEntity)
 						p.Mesh.Set(weightProps[i], v)
 					}
 				}
 				return nil
 			}))
 
-			for i, k := range keys {
-				kd := make([]float32, wlen)
-				off := i*ds*wlen + off
-				end := off + wlen
-				copy(kd, data[off:end])
-				kk := ch.SetKey(k, kd)
-				kk.SetEase(animEase(s.Interpolation))
+			for i, k := range achan.keys {
+				kk := ch.SetKey(k, achan.weights[i])
+				kk.SetEase(achan.ease)
 			}
 		}
-
 	}
 	// Just mark as started, do not actually start animating
 	gAnim.Start()
@@ -676,6 +770,21 @@ type GNode struct {
 	children []*GNode
 }
 
+// CloneEntity implements gorge.EntityCloner, primitives and children are
+// cloned sharing the mesh and skin, skins are only updated by GLTF.Clone.
+func (n *GNode) CloneEntity(c *gorge.Cloner) gorge.Entity {
+	v := &GNode{
+		TransformComponent: c.Transform(n.TransformComponent),
+		mesh:               n.mesh,
+		skin:               n.skin,
+		entities:           c.Entities(n.entities),
+	}
+	for _, ch := range n.children {
+		v.children = append(v.children, c.Entity(ch).(*GNode))
+	}
+	return v
+}
+
 // GetEntities implements the entity container and returns the underlying
 // primitive entities.
 func (n *GNode) GetEntities() []gorge.Entity {
@@ -687,12 +796,37 @@ func (n *GNode) GetEntities() []gorge.Entity {
 	return entities
 }
 
+// primEntity renders a node mesh primitive, it is declared here instead of
+// using gorgeutil so the loader doesn't depend on the renderer.
+type primEntity struct {
+	gorge.TransformComponent
+	*gorge.RenderableComponent
+	*gorge.ColorableComponent
+}
+
+func newPrimEntity(mesh gorge.Mesher, mat gorge.Materialer) *primEntity {
+	return &primEntity{
+		TransformComponent:  gorge.TransformIdent(),
+		RenderableComponent: gorge.NewRenderableComponent(mesh, mat),
+		ColorableComponent:  gorge.NewColorableComponent(1, 1, 1, 1),
+	}
+}
+
 // GScene entity container with nodes.
 type GScene struct {
 	*gorge.TransformComponent
 	Nodes []*GNode
 }
 
+// CloneEntity implements gorge.EntityCloner.
+func (s *GScene) CloneEntity(c *gorge.Cloner) gorge.Entity {
+	v := &GScene{TransformComponent: c.Transform(s.TransformComponent)}
+	for _, n := range s.Nodes {
+		v.Nodes = append(v.Nodes, c.Entity(n).(*GNode))
+	}
+	return v
+}
+
 // GetEntities implements the entity container and returns the existing gltf
 // scene entities.
 func (s *GScene) GetEntities() []gorge.Entity {
@@ -18,6 +18,7 @@ import (
 func init() {
 	resource.Register(&GLTF{}, ".gltf", gltfLoader)
 	resource.Register(&GLTF{}, ".glb", glbLoader)
+	resource.RegisterAsset("gltf", &GLTF{}, nil)
 }
 
 func gltfLoader(res *resource.Context, v any, name string, _ ...any) error {
@@ -0,0 +1,199 @@
+// Package ktx2 decodes 2D textures from KTX 2.0 containers into
+// gorge.TextureData, basis universal and zstd supercompression are not
+// supported.
+package ktx2
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/stdiopt/gorge"
+)
+
+var identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+const maxSize = 1 << 16
+
+// Supercompression schemes.
+const (
+	supercompressionNone = 0
+	supercompressionZlib = 3
+)
+
+// header is the fixed part after the identifier.
+type header struct {
+	VkFormat               uint32
+	TypeSize               uint32
+	PixelWidth             uint32
+	PixelHeight            uint32
+	PixelDepth             uint32
+	LayerCount             uint32
+	FaceCount              uint32
+	LevelCount             uint32
+	SupercompressionScheme uint32
+
+	DFDByteOffset uint32
+	DFDByteLength uint32
+	KVDByteOffset uint32
+	KVDByteLength uint32
+	SGDByteOffset uint64
+	SGDByteLength uint64
+}
+
+type level struct {
+	ByteOffset             uint64
+	ByteLength             uint64
+	UncompressedByteLength uint64
+}
+
+// formats maps vkFormat values to texture formats, sRGB variants map to their
+// linear counterpart and are listed in srgbFormats.
+var formats = map[uint32]gorge.TextureFormat{
+	9:   gorge.TextureFormatGray,    // R8_UNORM
+	15:  gorge.TextureFormatGray,    // R8_SRGB
+	23:  gorge.TextureFormatRGB,     // R8G8B8_UNORM
+	29:  gorge.TextureFormatRGB,     // R8G8B8_SRGB
+	37:  gorge.TextureFormatRGBA,    // R8G8B8A8_UNORM
+	43:  gorge.TextureFormatRGBA,    // R8G8B8A8_SRGB
+	76:  gorge.TextureFormatR16F,    // R16_SFLOAT
+	83:  gorge.TextureFormatRG16F,   // R16G16_SFLOAT
+	97:  gorge.TextureFormatRGBA16F, // R16G16B16A16_SFLOAT
+	100: gorge.TextureFormatR32F,    // R32_SFLOAT
+	106: gorge.TextureFormatRGB32F,  // R32G32B32_SFLOAT
+	109: gorge.TextureFormatRGBA32F, // R32G32B32A32_SFLOAT
+
+	131: gorge.TextureFormatBC1,  // BC1_RGB_UNORM_BLOCK
+	132: gorge.TextureFormatBC1,  // BC1_RGB_SRGB_BLOCK
+	133: gorge.TextureFormatBC1,  // BC1_RGBA_UNORM_BLOCK
+	134: gorge.TextureFormatBC1,  // BC1_RGBA_SRGB_BLOCK
+	135: gorge.TextureFormatBC2,  // BC2_UNORM_BLOCK
+	136: gorge.TextureFormatBC2,  // BC2_SRGB_BLOCK
+	137: gorge.TextureFormatBC3,  // BC3_UNORM_BLOCK
+	138: gorge.TextureFormatBC3,  // BC3_SRGB_BLOCK
+	139: gorge.TextureFormatBC4,  // BC4_UNORM_BLOCK
+	141: gorge.TextureFormatBC5,  // BC5_UNORM_BLOCK
+	143: gorge.TextureFormatBC6H, // BC6H_UFLOAT_BLOCK
+	145: gorge.TextureFormatBC7,  // BC7_UNORM_BLOCK
+	146: gorge.TextureFormatBC7,  // BC7_SRGB_BLOCK
+
+	147: gorge.TextureFormatETC2RGB,  // ETC2_R8G8B8_UNORM_BLOCK
+	148: gorge.TextureFormatETC2RGB,  // ETC2_R8G8B8_SRGB_BLOCK
+	151: gorge.TextureFormatETC2RGBA, // ETC2_R8G8B8A8_UNORM_BLOCK
+	152: gorge.TextureFormatETC2RGBA, // ETC2_R8G8B8A8_SRGB_BLOCK
+
+	157: gorge.TextureFormatASTC4x4, // ASTC_4x4_UNORM_BLOCK
+	158: gorge.TextureFormatASTC4x4, // ASTC_4x4_SRGB_BLOCK
+	165: gorge.TextureFormatASTC6x6, // ASTC_6x6_UNORM_BLOCK
+	166: gorge.TextureFormatASTC6x6, // ASTC_6x6_SRGB_BLOCK
+	171: gorge.TextureFormatASTC8x8, // ASTC_8x8_UNORM_BLOCK
+	172: gorge.TextureFormatASTC8x8, // ASTC_8x8_SRGB_BLOCK
+}
+
+// srgbFormats holds the vkFormat values with sRGB encoded color.
+var srgbFormats = map[uint32]bool{
+	15: true, 29: true, 43: true,
+	132: true, 134: true, 136: true, 138: true, 146: true,
+	148: true, 152: true,
+	158: true, 166: true, 172: true,
+}
+
+// Decode reads a KTX2 file, level 0 goes in PixelData and the remaining
+// levels in Mips.
+func Decode(rd io.Reader) (*gorge.TextureData, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, fmt.Errorf("ktx2: %w", err)
+	}
+	if !bytes.HasPrefix(data, identifier) {
+		return nil, errors.New("ktx2: invalid identifier")
+	}
+	br := bytes.NewReader(data[len(identifier):])
+
+	var h header
+	if err := binary.Read(br, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("ktx2: header: %w", err)
+	}
+	format, ok := formats[h.VkFormat]
+	if !ok {
+		return nil, fmt.Errorf("ktx2: unsupported vkFormat %d", h.VkFormat)
+	}
+	switch {
+	case h.PixelDepth > 0:
+		return nil, errors.New("ktx2: 3D textures are not supported")
+	case h.LayerCount > 0:
+		return nil, errors.New("ktx2: texture arrays are not supported")
+	case h.FaceCount != 1:
+		return nil, errors.New("ktx2: cubemaps are not supported")
+	case h.PixelWidth == 0 || h.PixelHeight == 0 ||
+		h.PixelWidth > maxSize || h.PixelHeight > maxSize:
+		return nil, fmt.Errorf("ktx2: invalid size %dx%d", h.PixelWidth, h.PixelHeight)
+	}
+	if s := h.SupercompressionScheme; s != supercompressionNone && s != supercompressionZlib {
+		return nil, fmt.Errorf("ktx2: unsupported supercompression scheme %d", s)
+	}
+
+	// A level count of 0 asks the loader to generate mips.
+	nlevels := int(h.LevelCount)
+	if nlevels == 0 {
+		nlevels = 1
+	}
+	if nlevels > 32 {
+		return nil, fmt.Errorf("ktx2: invalid level count %d", nlevels)
+	}
+	levels := make([]level, nlevels)
+	if err := binary.Read(br, binary.LittleEndian, levels); err != nil {
+		return nil, fmt.Errorf("ktx2: level index: %w", err)
+	}
+
+	texData := &gorge.TextureData{
+		Format: format,
+		Width:  int(h.PixelWidth),
+		Height: int(h.PixelHeight),
+		SRGB:   srgbFormats[h.VkFormat],
+	}
+	w, hh := texData.Width, texData.Height
+	for i, l := range levels {
+		end := l.ByteOffset + l.ByteLength
+		if end < l.ByteOffset || end > uint64(len(data)) {
+			return nil, fmt.Errorf("ktx2: level %d out of bounds", i)
+		}
+		pix := data[l.ByteOffset:end]
+		if h.SupercompressionScheme == supercompressionZlib {
+			if pix, err = inflate(pix); err != nil {
+				return nil, fmt.Errorf("ktx2: level %d: %w", i, err)
+			}
+		}
+		if want := format.DataSize(w, hh); len(pix) < want {
+			return nil, fmt.Errorf("ktx2: level %d: want %d bytes, got %d", i, want, len(pix))
+		}
+		pix = pix[:format.DataSize(w, hh)]
+		if i == 0 {
+			texData.PixelData = pix
+		} else {
+			texData.Mips = append(texData.Mips, pix)
+		}
+		w, hh = half(w), half(hh)
+	}
+	return texData, nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close() // nolint: errcheck
+	return io.ReadAll(zr)
+}
+
+// half returns the size of the next mip level.
+func half(n int) int {
+	if n > 1 {
+		return n / 2
+	}
+	return 1
+}
@@ -0,0 +1,116 @@
+package ktx2_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/ktx2"
+)
+
+var identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+// writeKTX2 builds a 2D KTX2 file with the levels and supercompression
+// scheme.
+func writeKTX2(vkFormat, width, height, scheme uint32, levels ...[]byte) []byte {
+	const headerSize = 12 + 9*4 + 4*4 + 2*8
+	buf := &bytes.Buffer{}
+	buf.Write(identifier)
+	le := binary.LittleEndian
+	binary.Write(buf, le, [9]uint32{vkFormat, 1, width, height, 0, 0, 1, uint32(len(levels)), scheme}) // nolint: errcheck
+	binary.Write(buf, le, [4]uint32{})                                                                 // nolint: errcheck
+	binary.Write(buf, le, [2]uint64{})                                                                 // nolint: errcheck
+
+	data := make([][]byte, len(levels))
+	for i, l := range levels {
+		data[i] = l
+		if scheme == 3 {
+			zbuf := &bytes.Buffer{}
+			zw := zlib.NewWriter(zbuf)
+			zw.Write(l) // nolint: errcheck
+			zw.Close()  // nolint: errcheck
+			data[i] = zbuf.Bytes()
+		}
+	}
+	off := uint64(headerSize + len(levels)*24)
+	for i, l := range data {
+		binary.Write(buf, le, [3]uint64{off, uint64(len(l)), uint64(len(levels[i]))}) // nolint: errcheck
+		off += uint64(len(l))
+	}
+	for _, l := range data {
+		buf.Write(l)
+	}
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	level0 := bytes.Repeat([]byte{1}, 4*8) // 8x8 BC1 is 2x2 blocks.
+	level1 := bytes.Repeat([]byte{2}, 8)
+	level2 := bytes.Repeat([]byte{3}, 8)
+
+	for _, scheme := range []uint32{0, 3} {
+		file := writeKTX2(131, 8, 8, scheme, level0, level1, level2)
+		td, err := ktx2.Decode(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if td.Format != gorge.TextureFormatBC1 || td.Width != 8 || td.Height != 8 {
+			t.Errorf("\nwant: %v 8x8\n got: %v %dx%d\n", gorge.TextureFormatBC1, td.Format, td.Width, td.Height)
+		}
+		if !bytes.Equal(td.PixelData, level0) {
+			t.Errorf("scheme %d: level 0 mismatch", scheme)
+		}
+		if len(td.Mips) != 2 || !bytes.Equal(td.Mips[0], level1) || !bytes.Equal(td.Mips[1], level2) {
+			t.Errorf("scheme %d: want 2 mips, got %v", scheme, td.Mips)
+		}
+	}
+}
+
+func TestDecodeSRGB(t *testing.T) {
+	tests := []struct {
+		vkFormat uint32
+		format   gorge.TextureFormat
+		srgb     bool
+	}{
+		{37, gorge.TextureFormatRGBA, false},
+		{43, gorge.TextureFormatRGBA, true},
+		{131, gorge.TextureFormatBC1, false},
+		{132, gorge.TextureFormatBC1, true},
+		{171, gorge.TextureFormatASTC8x8, false},
+		{172, gorge.TextureFormatASTC8x8, true},
+	}
+	for _, tt := range tests {
+		file := writeKTX2(tt.vkFormat, 4, 4, 0, make([]byte, tt.format.DataSize(4, 4)))
+		td, err := ktx2.Decode(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if td.Format != tt.format || td.SRGB != tt.srgb {
+			t.Errorf("vkFormat %d\nwant: %v %v\n got: %v %v\n", tt.vkFormat, tt.format, tt.srgb, td.Format, td.SRGB)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"identifier", []byte("KTX 11"), "invalid identifier"},
+		{"format", writeKTX2(1000, 4, 4, 0, make([]byte, 8)), "unsupported vkFormat"},
+		{"supercompression", writeKTX2(131, 4, 4, 2, make([]byte, 8)), "supercompression"},
+		{"short level", writeKTX2(37, 4, 4, 0, make([]byte, 8)), "want 64 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ktx2.Decode(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("\nwant: %v\n got: %v\n", tt.want, err)
+			}
+		})
+	}
+}
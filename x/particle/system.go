@@ -14,6 +14,13 @@ type emitter interface {
 
 type Context struct{}
 
+// SystemDef declares the particle system.
+var SystemDef = gorge.SystemDef{
+	Name: "particle",
+	Deps: []string{"render"},
+	Init: System,
+}
+
 func System(g *gorge.Context) {
 	if _, ok := gorge.GetContext[*Context](g); ok {
 		return
@@ -46,8 +53,9 @@ func System(g *gorge.Context) {
 		}
 	})
 	event.Handle(g, func(e gorge.EventUpdate) {
+		dt := e.DeltaTime()
 		for _, em := range emitters {
-			em.Emitter().update(g, em, e.DeltaTime())
+			em.Emitter().update(g, em, dt)
 			// Fixed rate
 			// em.Emitter().update(g, em, 0.016)
 		}
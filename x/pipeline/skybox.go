@@ -23,12 +23,13 @@ func (pl *PL) LoadHDR(src string, target string) PipelineFunc {
 			Type: gl.TEXTURE_2D,
 		}
 		{
+			iformat, format, ty := hdrFormat(tex.Format)
 			gl.BindTexture(gl.TEXTURE_2D, hdrTexture.ID)
 			gl.TexImage2D(
 				gl.TEXTURE_2D, 0,
-				gl.RGB16F,
+				iformat,
 				tex.Width, tex.Height,
-				gl.RGB, gl.FLOAT, tex.PixelData) // note how we specify the texture's data value to be float
+				format, ty, tex.PixelData)
 
 			gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
 			gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
@@ -68,6 +69,22 @@ func (pl *PL) LoadHDR(src string, target string) PipelineFunc {
 	}
 }
 
+// hdrFormat returns the upload formats for an equirectangular texture, 32 bit
+// float data is stored as half floats so it can be linearly filtered without
+// extensions.
+func hdrFormat(f gorge.TextureFormat) (int, gl.Enum, gl.Enum) {
+	iformat, format, ty := render.TextureFormat(f)
+	switch iformat {
+	case gl.R32F:
+		iformat = gl.R16F
+	case gl.RGB32F:
+		iformat = gl.RGB16F
+	case gl.RGBA32F:
+		iformat = gl.RGBA16F
+	}
+	return iformat, format, ty
+}
+
 func (pl *PL) LoadSkyboxStage(target string) PipelineFunc {
 	return func(_ *render.Context, next StepFunc) StepFunc {
 		srcs := []string{
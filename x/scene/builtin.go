@@ -0,0 +1,192 @@
+package scene
+
+import (
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+)
+
+func init() {
+	Register("renderable", getRenderable, encodeRenderable, decodeRenderable)
+	Register("light", getLight, encodeLight, decodeLight)
+	Register("camera", getCamera, encodeCamera, decodeCamera)
+}
+
+// RenderableData is the serialized renderable component, Mesh is the mesh
+// resource path, Material the document material index or -1 and Color is set
+// if the entity has a ColorableComponent.
+type RenderableData struct {
+	Name     string              `json:"name,omitempty"`
+	Mesh     string              `json:"mesh,omitempty"`
+	DrawMode gorge.DrawMode      `json:"drawMode,omitempty"`
+	Material int                 `json:"material"`
+	Order    int                 `json:"order,omitempty"`
+	CullMask gorge.CullMaskFlags `json:"cullMask,omitempty"`
+	Color    *gm.Vec4            `json:"color,omitempty"`
+}
+
+type renderable struct {
+	*gorge.RenderableComponent
+	colorable *gorge.ColorableComponent
+}
+
+func getRenderable(e gorge.Entity) (renderable, bool) {
+	v, ok := e.(interface {
+		Renderable() *gorge.RenderableComponent
+	})
+	if !ok || v.Renderable() == nil {
+		return renderable{}, false
+	}
+	r := renderable{RenderableComponent: v.Renderable()}
+	if c, ok := e.(interface {
+		Colorable() *gorge.ColorableComponent
+	}); ok {
+		r.colorable = c.Colorable()
+	}
+	return r, true
+}
+
+func encodeRenderable(enc *Encoder, r renderable) (RenderableData, error) {
+	d := RenderableData{
+		Name:     r.Name,
+		Material: -1,
+		Order:    r.Order,
+		CullMask: r.CullMask,
+	}
+	if r.Mesh != nil {
+		p, err := enc.AssetPath(r.Mesh)
+		if err != nil {
+			return d, err
+		}
+		d.Mesh = p
+		d.DrawMode = r.Mesh.DrawMode
+	}
+	id, err := enc.Material(r.Material)
+	if err != nil {
+		return d, err
+	}
+	d.Material = id
+	if r.colorable != nil {
+		c := r.colorable.Color
+		d.Color = &c
+	}
+	return d, nil
+}
+
+func decodeRenderable(dec *Decoder, n *Node, d RenderableData) error {
+	r := &gorge.RenderableComponent{
+		Name:     d.Name,
+		Order:    d.Order,
+		CullMask: d.CullMask,
+	}
+	if d.Mesh != "" {
+		mesh, err := dec.Mesh(d.Mesh)
+		if err != nil {
+			return err
+		}
+		mesh.DrawMode = d.DrawMode
+		r.Mesh = mesh
+	}
+	mat, err := dec.Material(d.Material)
+	if err != nil {
+		return err
+	}
+	r.Material = mat
+	if d.Color == nil {
+		n.Add(&renderableEntity{n.TransformComponent, r})
+		return nil
+	}
+	n.Add(&colorableEntity{
+		n.TransformComponent,
+		r,
+		&gorge.ColorableComponent{Color: *d.Color},
+	})
+	return nil
+}
+
+func getLight(e gorge.Entity) (*gorge.LightComponent, bool) {
+	v, ok := e.(interface{ Light() *gorge.LightComponent })
+	if !ok || v.Light() == nil {
+		return nil, false
+	}
+	return v.Light(), true
+}
+
+func encodeLight(_ *Encoder, l *gorge.LightComponent) (gorge.LightComponent, error) {
+	return *l, nil
+}
+
+func decodeLight(_ *Decoder, n *Node, d gorge.LightComponent) error {
+	n.Add(&lightEntity{n.TransformComponent, &d})
+	return nil
+}
+
+// CameraData is the serialized camera component, ClearMaterial is the
+// document material index or -1.
+type CameraData struct {
+	Name           string               `json:"name,omitempty"`
+	ProjectionType gorge.ProjectionType `json:"projectionType"`
+	CullMask       gorge.CullMaskFlags  `json:"cullMask"`
+	Fov            float32              `json:"fov"`
+	OrthoSize      float32              `json:"orthoSize"`
+	AspectRatio    float32              `json:"aspectRatio,omitempty"`
+	Near           float32              `json:"near"`
+	Far            float32              `json:"far"`
+	ClearFlag      gorge.ClearType      `json:"clearFlag"`
+	ClearMaterial  int                  `json:"clearMaterial"`
+	Order          int                  `json:"order,omitempty"`
+	Viewport       gm.Vec4              `json:"viewport"`
+	ClearColor     gm.Vec3              `json:"clearColor"`
+}
+
+func getCamera(e gorge.Entity) (*gorge.CameraComponent, bool) {
+	v, ok := e.(interface{ Camera() *gorge.CameraComponent })
+	if !ok || v.Camera() == nil {
+		return nil, false
+	}
+	return v.Camera(), true
+}
+
+func encodeCamera(enc *Encoder, c *gorge.CameraComponent) (CameraData, error) {
+	mat, err := enc.Material(c.ClearMaterial)
+	if err != nil {
+		return CameraData{}, err
+	}
+	return CameraData{
+		Name:           c.Name,
+		ProjectionType: c.ProjectionType,
+		CullMask:       c.CullMask,
+		Fov:            c.Fov,
+		OrthoSize:      c.OrthoSize,
+		AspectRatio:    c.AspectRatio,
+		Near:           c.Near,
+		Far:            c.Far,
+		ClearFlag:      c.ClearFlag,
+		ClearMaterial:  mat,
+		Order:          c.Order,
+		Viewport:       c.Viewport,
+		ClearColor:     c.ClearColor,
+	}, nil
+}
+
+func decodeCamera(dec *Decoder, n *Node, d CameraData) error {
+	mat, err := dec.Material(d.ClearMaterial)
+	if err != nil {
+		return err
+	}
+	n.Add(&cameraEntity{n.TransformComponent, &gorge.CameraComponent{
+		Name:           d.Name,
+		ProjectionType: d.ProjectionType,
+		CullMask:       d.CullMask,
+		Fov:            d.Fov,
+		OrthoSize:      d.OrthoSize,
+		AspectRatio:    d.AspectRatio,
+		Near:           d.Near,
+		Far:            d.Far,
+		ClearFlag:      d.ClearFlag,
+		ClearMaterial:  mat,
+		Order:          d.Order,
+		Viewport:       d.Viewport,
+		ClearColor:     d.ClearColor,
+	}})
+	return nil
+}
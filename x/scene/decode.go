@@ -0,0 +1,251 @@
+package scene
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+// Decoder decodes documents into nodes loading assets through resource.
+type Decoder struct {
+	// Assets are used instead of loading the path from resource, like meshes
+	// created in code, values must implement gorge.Mesher, gorge.Texturer or
+	// gorge.ShaderResourcer.
+	Assets map[string]any
+
+	res       *resource.Context
+	doc       *Document
+	materials []*gorge.Material
+	decoding  []bool
+	textures  map[TextureData]*gorge.Texture
+	shaders   map[string]gorge.ShaderResourcer
+}
+
+// NewDecoder returns a decoder that loads assets from the gorge resource
+// context.
+func NewDecoder(g *gorge.Context) *Decoder {
+	return &Decoder{res: resource.FromContext(g)}
+}
+
+// Decode decodes the document nodes, parents are set on node transforms,
+// meshes and textures are loaded asynchronously by resource.
+func (d *Decoder) Decode(doc *Document) ([]*Node, error) {
+	d.doc = doc
+	d.materials = make([]*gorge.Material, len(doc.Materials))
+	d.decoding = make([]bool, len(doc.Materials))
+	d.textures = map[TextureData]*gorge.Texture{}
+	d.shaders = map[string]gorge.ShaderResourcer{}
+	defer func() {
+		d.doc, d.materials, d.decoding = nil, nil, nil
+		d.textures, d.shaders = nil, nil
+	}()
+
+	nodes := make([]*Node, len(doc.Nodes))
+	for i, nd := range doc.Nodes {
+		n := NewNode(nd.Name)
+		n.Position = nd.Position
+		n.Rotation = nd.Rotation
+		n.Scale = nd.Scale
+		nodes[i] = n
+	}
+	for i, nd := range doc.Nodes {
+		if nd.Parent == -1 {
+			continue
+		}
+		if nd.Parent < 0 || nd.Parent >= len(nodes) || nd.Parent == i {
+			return nil, fmt.Errorf("node %d: invalid parent %d", i, nd.Parent)
+		}
+		if err := nodes[i].SetParent(nodes[nd.Parent]); err != nil {
+			return nil, fmt.Errorf("node %d: parent %d: %w", i, nd.Parent, err)
+		}
+	}
+	for i, nd := range doc.Nodes {
+		for _, c := range nd.Components {
+			cc, err := getCodec(c.Type)
+			if err != nil {
+				return nil, fmt.Errorf("node %d: %w", i, err)
+			}
+			if err := cc.decode(d, nodes[i], c.Data); err != nil {
+				return nil, fmt.Errorf("node %d component %q: %w", i, c.Type, err)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// Mesh returns a mesh for the path.
+func (d *Decoder) Mesh(path string) (*gorge.Mesh, error) {
+	if a, ok := d.Assets[path]; ok {
+		m, ok := a.(gorge.Mesher)
+		if !ok {
+			return nil, fmt.Errorf("asset %q is not a mesh: %T", path, a)
+		}
+		return m.Mesh(), nil
+	}
+	return d.res.Mesh(path), nil
+}
+
+// Texture returns a texture for the texture data, textures with the same data
+// are shared.
+func (d *Decoder) Texture(td TextureData) (*gorge.Texture, error) {
+	if t, ok := d.textures[td]; ok {
+		return t, nil
+	}
+	var tex *gorge.Texture
+	if a, ok := d.Assets[td.Path]; ok {
+		t, ok := a.(gorge.Texturer)
+		if !ok {
+			return nil, fmt.Errorf("asset %q is not a texture: %T", td.Path, a)
+		}
+		tex = t.Texture()
+	} else {
+		tex = d.res.Texture(td.Path)
+		tex.Wrap = td.Wrap
+		tex.FilterMode = td.FilterMode
+	}
+	d.textures[td] = tex
+	return tex, nil
+}
+
+// Material returns the document material at index i, -1 returns nil.
+func (d *Decoder) Material(i int) (*gorge.Material, error) {
+	if i == -1 {
+		return nil, nil
+	}
+	if i < 0 || i >= len(d.materials) {
+		return nil, fmt.Errorf("invalid material %d", i)
+	}
+	if m := d.materials[i]; m != nil {
+		return m, nil
+	}
+	if d.decoding[i] {
+		return nil, fmt.Errorf("material %d parent cycle", i)
+	}
+	d.decoding[i] = true
+
+	md := d.doc.Materials[i]
+	m := gorge.NewMaterial()
+	switch {
+	case md.Parent != -1:
+		parent, err := d.Material(md.Parent)
+		if err != nil {
+			return nil, err
+		}
+		m.Resourcer = parent
+	case md.Shader != "":
+		shader, err := d.shader(md.Shader)
+		if err != nil {
+			return nil, err
+		}
+		m.Resourcer = shader
+	}
+	m.Name = md.Name
+	m.Queue = md.Queue
+	m.Depth = md.Depth
+	m.DoubleSided = md.DoubleSided
+	m.DisableShadow = md.DisableShadow
+	m.Blend = md.Blend
+	m.Stencil = md.Stencil
+	m.ColorMask = md.ColorMask
+	for k, v := range md.Defines {
+		if v == "" {
+			m.Define(k)
+			continue
+		}
+		m.Define(k + " " + v)
+	}
+	for k, p := range md.Props {
+		v, err := decodeProp(p)
+		if err != nil {
+			return nil, fmt.Errorf("material %q prop %q: %w", md.Name, k, err)
+		}
+		m.Set(k, v)
+	}
+	for k, td := range md.Textures {
+		t, err := d.Texture(td)
+		if err != nil {
+			return nil, err
+		}
+		m.SetTexture(k, t)
+	}
+	d.materials[i] = m
+	return m, nil
+}
+
+func (d *Decoder) shader(path string) (gorge.ShaderResourcer, error) {
+	if s, ok := d.shaders[path]; ok {
+		return s, nil
+	}
+	var shader gorge.ShaderResourcer
+	if a, ok := d.Assets[path]; ok {
+		s, ok := a.(gorge.ShaderResourcer)
+		if !ok {
+			return nil, fmt.Errorf("asset %q is not a shader: %T", path, a)
+		}
+		shader = s
+	} else {
+		data := &gorge.ShaderData{}
+		if err := d.res.Load(data, path); err != nil {
+			return nil, err
+		}
+		shader = data
+	}
+	d.shaders[path] = shader
+	return shader, nil
+}
+
+func decodeProp(p PropData) (any, error) {
+	want := map[string]int{
+		"int": 1, "float": 1, "vec2": 2, "vec3": 3, "vec4": 4, "mat3": 9, "mat4": 16,
+	}
+	n, ok := want[p.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %q", p.Type)
+	}
+	if len(p.Values) != n {
+		return nil, fmt.Errorf("%s: want %d values, got %d", p.Type, n, len(p.Values))
+	}
+	switch p.Type {
+	case "int":
+		return int(p.Values[0]), nil
+	case "float":
+		return p.Values[0], nil
+	case "vec2":
+		return gm.Vec2{p.Values[0], p.Values[1]}, nil
+	case "vec3":
+		return gm.Vec3{p.Values[0], p.Values[1], p.Values[2]}, nil
+	case "vec4":
+		var v gm.Vec4
+		copy(v[:], p.Values)
+		return v, nil
+	case "mat3":
+		var v gm.Mat3
+		copy(v[:], p.Values)
+		return v, nil
+	default:
+		var v gm.Mat4
+		copy(v[:], p.Values)
+		return v, nil
+	}
+}
+
+// Load reads a document in any format and returns a scene with the decoded
+// nodes.
+func Load(g *gorge.Context, r io.Reader) (*Scene, error) {
+	doc, err := ReadDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := NewDecoder(g).Decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	s := New("")
+	for _, n := range nodes {
+		s.Add(n)
+	}
+	return s, nil
+}
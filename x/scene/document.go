@@ -0,0 +1,272 @@
+package scene
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+)
+
+// DocumentVersion is the current document format version.
+const DocumentVersion = 1
+
+// binaryMagic prefixes binary documents.
+const binaryMagic = "GSCN"
+
+// Format of a serialized document.
+type Format int
+
+// Document formats.
+const (
+	FormatJSON = Format(iota)
+	FormatBinary
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "FormatJSON"
+	case FormatBinary:
+		return "FormatBinary"
+	default:
+		return fmt.Sprintf("FormatUnknown(%d)", int(f))
+	}
+}
+
+// Document is the serializable form of an entity graph.
+type Document struct {
+	Version   int            `json:"version"`
+	Materials []MaterialData `json:"materials,omitempty"`
+	Nodes     []NodeData     `json:"nodes"`
+}
+
+// NodeData is a transform and the components of the entities sharing it,
+// Parent is the index of the parent node or -1.
+type NodeData struct {
+	Name       string          `json:"name,omitempty"`
+	Parent     int             `json:"parent"`
+	Position   gm.Vec3         `json:"position"`
+	Rotation   gm.Quat         `json:"rotation"`
+	Scale      gm.Vec3         `json:"scale"`
+	Components []ComponentData `json:"components,omitempty"`
+}
+
+// ComponentData is the data of a registered component type.
+type ComponentData struct {
+	Type string
+	Data any
+}
+
+type jsonComponent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c ComponentData) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(c.Data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonComponent{Type: c.Type, Data: data})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the data is decoded into the
+// registered type.
+func (c *ComponentData) UnmarshalJSON(b []byte) error {
+	var raw jsonComponent
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	cc, err := getCodec(raw.Type)
+	if err != nil {
+		return err
+	}
+	data, err := cc.unmarshal(func(v any) error {
+		return json.Unmarshal(raw.Data, v)
+	})
+	if err != nil {
+		return fmt.Errorf("component %q: %w", raw.Type, err)
+	}
+	*c = ComponentData{Type: raw.Type, Data: data}
+	return nil
+}
+
+// MaterialData is a material shared by renderables and cameras, Shader is the
+// shader resource path, empty for the default shader and Parent is the index
+// of the parent material or -1.
+type MaterialData struct {
+	Name          string                 `json:"name,omitempty"`
+	Shader        string                 `json:"shader,omitempty"`
+	Parent        int                    `json:"parent"`
+	Queue         int                    `json:"queue,omitempty"`
+	Depth         gorge.DepthMode        `json:"depth,omitempty"`
+	DoubleSided   bool                   `json:"doubleSided,omitempty"`
+	DisableShadow bool                   `json:"disableShadow,omitempty"`
+	Blend         *gorge.Blend           `json:"blend,omitempty"`
+	Stencil       *gorge.Stencil         `json:"stencil,omitempty"`
+	ColorMask     *[4]bool               `json:"colorMask,omitempty"`
+	Defines       map[string]string      `json:"defines,omitempty"`
+	Props         map[string]PropData    `json:"props,omitempty"`
+	Textures      map[string]TextureData `json:"textures,omitempty"`
+}
+
+// TextureData is a texture resource path and its sampling state.
+type TextureData struct {
+	Path       string               `json:"path"`
+	Wrap       [3]gorge.TextureWrap `json:"wrap"`
+	FilterMode gorge.TextureFilter  `json:"filterMode"`
+}
+
+// PropData is a shader property value.
+type PropData struct {
+	Type   string    `json:"type"`
+	Values []float32 `json:"values"`
+}
+
+// Write writes the document in the format.
+func (d *Document) Write(w io.Writer, f Format) error {
+	switch f {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(d)
+	case FormatBinary:
+		return d.writeBinary(w)
+	default:
+		return fmt.Errorf("unknown format: %v", f)
+	}
+}
+
+// ReadDocument reads a document detecting the format.
+func ReadDocument(r io.Reader) (*Document, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(binaryMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	doc := &Document{}
+	if bytes.Equal(head, []byte(binaryMagic)) {
+		if _, err := br.Discard(len(binaryMagic)); err != nil {
+			return nil, err
+		}
+		err = doc.readBinary(br)
+	} else {
+		err = json.NewDecoder(br).Decode(doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doc.Version > DocumentVersion {
+		return nil, fmt.Errorf("unsupported document version: %d", doc.Version)
+	}
+	return doc, nil
+}
+
+// binary documents are a gob stream so types are described once.
+type binaryHeader struct {
+	Version   int
+	Materials []MaterialData
+	Nodes     int
+}
+
+type binaryNode struct {
+	Name       string
+	Parent     int
+	Position   gm.Vec3
+	Rotation   gm.Quat
+	Scale      gm.Vec3
+	Components int
+}
+
+func (d *Document) writeBinary(w io.Writer) error {
+	if _, err := io.WriteString(w, binaryMagic); err != nil {
+		return err
+	}
+	enc := gob.NewEncoder(w)
+	err := enc.Encode(binaryHeader{
+		Version:   d.Version,
+		Materials: d.Materials,
+		Nodes:     len(d.Nodes),
+	})
+	if err != nil {
+		return err
+	}
+	for _, n := range d.Nodes {
+		err := enc.Encode(binaryNode{
+			Name:       n.Name,
+			Parent:     n.Parent,
+			Position:   n.Position,
+			Rotation:   n.Rotation,
+			Scale:      n.Scale,
+			Components: len(n.Components),
+		})
+		if err != nil {
+			return err
+		}
+		for _, c := range n.Components {
+			if err := enc.Encode(c.Type); err != nil {
+				return err
+			}
+			if err := enc.Encode(c.Data); err != nil {
+				return fmt.Errorf("component %q: %w", c.Type, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Document) readBinary(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	var h binaryHeader
+	if err := dec.Decode(&h); err != nil {
+		return err
+	}
+	if h.Nodes < 0 {
+		return fmt.Errorf("invalid node count: %d", h.Nodes)
+	}
+	d.Version = h.Version
+	d.Materials = h.Materials
+	// Counts come from the stream, nodes are appended as they are decoded so
+	// a bad count fails on the missing data.
+	d.Nodes = nil
+	for i := 0; i < h.Nodes; i++ {
+		var n binaryNode
+		if err := dec.Decode(&n); err != nil {
+			return err
+		}
+		if n.Components < 0 {
+			return fmt.Errorf("node %d: invalid component count: %d", i, n.Components)
+		}
+		nd := NodeData{
+			Name:     n.Name,
+			Parent:   n.Parent,
+			Position: n.Position,
+			Rotation: n.Rotation,
+			Scale:    n.Scale,
+		}
+		for j := 0; j < n.Components; j++ {
+			var typ string
+			if err := dec.Decode(&typ); err != nil {
+				return err
+			}
+			cc, err := getCodec(typ)
+			if err != nil {
+				return err
+			}
+			data, err := cc.unmarshal(dec.Decode)
+			if err != nil {
+				return fmt.Errorf("component %q: %w", typ, err)
+			}
+			nd.Components = append(nd.Components, ComponentData{Type: typ, Data: data})
+		}
+		d.Nodes = append(d.Nodes, nd)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package scene
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+type transformer interface {
+	Transform() *gorge.TransformComponent
+}
+
+// Encoder encodes entity graphs into documents.
+type Encoder struct {
+	// Resolve returns the path of assets that weren't loaded through resource
+	// like meshes created in code, it is called before the resource lookup.
+	Resolve func(asset any) (string, bool)
+
+	res       *resource.Context
+	doc       *Document
+	nodes     map[*gorge.TransformComponent]int
+	materials map[*gorge.Material]int
+	seen      map[any]bool
+}
+
+// NewEncoder returns an encoder that resolves asset paths from the gorge
+// resource context.
+func NewEncoder(g *gorge.Context) *Encoder {
+	return &Encoder{res: resource.FromContext(g)}
+}
+
+// Encode encodes the entities and their contained entities, entities sharing
+// a transform are encoded as a single node and parent transforms are encoded
+// as nodes even if not passed, entities without a transform are skipped.
+func (e *Encoder) Encode(ents ...gorge.Entity) (*Document, error) {
+	e.doc = &Document{Version: DocumentVersion, Nodes: []NodeData{}}
+	e.nodes = map[*gorge.TransformComponent]int{}
+	e.materials = map[*gorge.Material]int{}
+	e.seen = map[any]bool{}
+	defer func() {
+		e.nodes, e.materials, e.seen = nil, nil, nil
+	}()
+
+	var err error
+	for _, ent := range ents {
+		gorge.EachEntity(ent, func(ent gorge.Entity) {
+			if err == nil {
+				err = e.encodeEntity(ent)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e.doc, nil
+}
+
+func (e *Encoder) encodeEntity(ent gorge.Entity) error {
+	t, ok := ent.(transformer)
+	if !ok || t.Transform() == nil {
+		return nil
+	}
+	id := e.node(t.Transform())
+	if n, ok := ent.(*Node); ok {
+		e.doc.Nodes[id].Name = n.Name
+	}
+	for _, c := range codecOrder {
+		comp, ok := c.get(ent)
+		if !ok {
+			continue
+		}
+		// Components shared by several entities are encoded once per node.
+		if reflect.TypeOf(comp).Comparable() {
+			key := [3]any{id, c.name, comp}
+			if e.seen[key] {
+				continue
+			}
+			e.seen[key] = true
+		}
+		data, err := c.encode(e, comp)
+		if err != nil {
+			return fmt.Errorf("component %q: %w", c.name, err)
+		}
+		nd := &e.doc.Nodes[id]
+		nd.Components = append(nd.Components, ComponentData{Type: c.name, Data: data})
+	}
+	return nil
+}
+
+// node returns the node index of the transform adding it and its parents.
+func (e *Encoder) node(t *gorge.TransformComponent) int {
+	if id, ok := e.nodes[t]; ok {
+		return id
+	}
+	parent := -1
+	if p, ok := t.Parent().(transformer); ok && p.Transform() != nil {
+		parent = e.node(p.Transform())
+	}
+	id := len(e.doc.Nodes)
+	e.nodes[t] = id
+	e.doc.Nodes = append(e.doc.Nodes, NodeData{
+		Parent:   parent,
+		Position: t.Position,
+		Rotation: t.Rotation,
+		Scale:    t.Scale,
+	})
+	return id
+}
+
+// AssetPath returns the path of a mesh or texture.
+func (e *Encoder) AssetPath(asset any) (string, error) {
+	if e.Resolve != nil {
+		if p, ok := e.Resolve(asset); ok {
+			return p, nil
+		}
+	}
+	if p, ok := e.res.Path(asset); ok {
+		return p, nil
+	}
+	return "", fmt.Errorf("no resource path for %T", asset)
+}
+
+// Material adds the material to the document and returns its index, nil
+// materials return -1.
+func (e *Encoder) Material(m *gorge.Material) (int, error) {
+	if m == nil {
+		return -1, nil
+	}
+	if id, ok := e.materials[m]; ok {
+		return id, nil
+	}
+	md := MaterialData{
+		Name:          m.Name,
+		Parent:        -1,
+		Queue:         m.Queue,
+		Depth:         m.Depth,
+		DoubleSided:   m.DoubleSided,
+		DisableShadow: m.DisableShadow,
+		Blend:         m.Blend,
+		Stencil:       m.Stencil,
+		ColorMask:     m.ColorMask,
+	}
+	switch r := m.Resourcer.(type) {
+	case nil:
+	case *gorge.Material:
+		id, err := e.Material(r)
+		if err != nil {
+			return -1, err
+		}
+		md.Parent = id
+	case *gorge.ShaderData:
+		md.Shader = r.Name
+	default:
+		p, err := e.AssetPath(r)
+		if err != nil {
+			return -1, fmt.Errorf("material %q shader: %w", m.Name, err)
+		}
+		md.Shader = p
+	}
+	// Material.Defines merges the parent defines.
+	if md.Parent == -1 {
+		md.Defines = m.Defines()
+	} else {
+		md.Defines = materialOwnDefines(m)
+	}
+	for k, v := range m.Props() {
+		pd, err := encodeProp(v)
+		if err != nil {
+			return -1, fmt.Errorf("material %q prop %q: %w", m.Name, k, err)
+		}
+		if md.Props == nil {
+			md.Props = map[string]PropData{}
+		}
+		md.Props[k] = pd
+	}
+	for k, t := range m.Samplers() {
+		if t == nil {
+			continue
+		}
+		p, err := e.AssetPath(t)
+		if err != nil {
+			return -1, fmt.Errorf("material %q texture %q: %w", m.Name, k, err)
+		}
+		if md.Textures == nil {
+			md.Textures = map[string]TextureData{}
+		}
+		md.Textures[k] = TextureData{
+			Path:       p,
+			Wrap:       t.Wrap,
+			FilterMode: t.FilterMode,
+		}
+	}
+	id := len(e.doc.Materials)
+	e.materials[m] = id
+	e.doc.Materials = append(e.doc.Materials, md)
+	return id, nil
+}
+
+// materialOwnDefines returns the defines set on the material without the
+// parent ones.
+func materialOwnDefines(m *gorge.Material) map[string]string {
+	parent, _ := m.Resourcer.(*gorge.Material)
+	pdefs := parent.Defines()
+	var ret map[string]string
+	for k, v := range m.Defines() {
+		if pv, ok := pdefs[k]; ok && pv == v {
+			continue
+		}
+		if ret == nil {
+			ret = map[string]string{}
+		}
+		ret[k] = v
+	}
+	return ret
+}
+
+func encodeProp(v any) (PropData, error) {
+	switch v := v.(type) {
+	case int:
+		return PropData{"int", []float32{float32(v)}}, nil
+	case float32:
+		return PropData{"float", []float32{v}}, nil
+	case gm.Vec2:
+		return PropData{"vec2", v[:]}, nil
+	case gm.Vec3:
+		return PropData{"vec3", v[:]}, nil
+	case gm.Vec4:
+		return PropData{"vec4", v[:]}, nil
+	case gm.Mat3:
+		return PropData{"mat3", v[:]}, nil
+	case gm.Mat4:
+		return PropData{"mat4", v[:]}, nil
+	default:
+		return PropData{}, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// Save encodes the entities and writes the document in the format.
+func Save(g *gorge.Context, w io.Writer, f Format, ents ...gorge.Entity) error {
+	doc, err := NewEncoder(g).Encode(ents...)
+	if err != nil {
+		return err
+	}
+	return doc.Write(w, f)
+}
@@ -0,0 +1,319 @@
+package scene
+
+import (
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/systems/resource"
+)
+
+// EventPreloadProgress is triggered on gorge when a resource preloaded by a
+// scene finishes loading.
+type EventPreloadProgress struct {
+	Scene  *Scene
+	Loaded int
+	Total  int
+}
+
+// Progress returns the loaded fraction from 0 to 1.
+func (e EventPreloadProgress) Progress() float32 {
+	if e.Total == 0 {
+		return 1
+	}
+	return float32(e.Loaded) / float32(e.Total)
+}
+
+// EventPreloadComplete is triggered on gorge when every resource preloaded by
+// a scene finished loading, Err is the first load error.
+type EventPreloadComplete struct {
+	Scene *Scene
+	Err   error
+}
+
+// Transition funcs are run as gorge tasks while changing scenes, Out runs
+// before the outgoing scene is removed and In after the incoming scene is
+// added, either can be nil.
+type Transition struct {
+	Out func(t *gorge.Task)
+	In  func(t *gorge.Task)
+}
+
+// Fade returns a transition that calls set with alpha going from 0 to 1 over
+// seconds before the scene change and from 1 to 0 after, set can drive an
+// overlay color.
+func Fade(seconds float32, set func(alpha float32)) *Transition {
+	fade := func(t *gorge.Task, from, to float32) {
+		tm := t.Time()
+		start := tm.Total()
+		for {
+			d := float32(tm.Total() - start)
+			if d >= seconds {
+				break
+			}
+			set(from + (to-from)*d/seconds)
+			t.WaitFrames(1)
+		}
+		set(to)
+	}
+	return &Transition{
+		Out: func(t *gorge.Task) { fade(t, 0, 1) },
+		In:  func(t *gorge.Task) { fade(t, 1, 0) },
+	}
+}
+
+type preload struct {
+	pending map[any]struct{}
+	loaded  int
+	total   int
+	err     error
+	done    bool
+}
+
+// SetTransition sets the transition used on Push, Pop and Replace, nil
+// disables transitions.
+func (c *Context) SetTransition(t *Transition) {
+	c.transition = t
+}
+
+// SetAutoUnload enables or disables releasing the gpu data of resources
+// loaded by scenes when they are popped or replaced, it is enabled by default.
+func (c *Context) SetAutoUnload(v bool) {
+	c.noUnload = !v
+}
+
+// Current returns the scene on top of the stack or nil.
+func (c *Context) Current() *Scene {
+	if len(c.stack) == 0 {
+		return nil
+	}
+	return c.stack[len(c.stack)-1]
+}
+
+// Stack returns the stacked scenes, the last one is the current.
+func (c *Context) Stack() []*Scene {
+	return append([]*Scene(nil), c.stack...)
+}
+
+// Additive returns the scenes added with AddAdditive.
+func (c *Context) Additive() []*Scene {
+	return append([]*Scene(nil), c.additive...)
+}
+
+// Push preloads s and puts it on top of the stack, the current scene is
+// suspended until s is popped, suspended scenes are removed from gorge but
+// keep their state.
+// The returned task finishes when the transition completes.
+func (c *Context) Push(s *Scene) *gorge.Task {
+	out := c.Current()
+	c.stack = append(c.stack, s)
+	return c.change(out, false, s)
+}
+
+// Pop removes the current scene and resumes the previous one.
+func (c *Context) Pop() *gorge.Task {
+	out := c.Current()
+	if out == nil {
+		return nil
+	}
+	c.stack[len(c.stack)-1] = nil
+	c.stack = c.stack[:len(c.stack)-1]
+	return c.change(out, true, c.Current())
+}
+
+// Replace preloads s and replaces the current scene with it.
+func (c *Context) Replace(s *Scene) *gorge.Task {
+	out := c.Current()
+	if out == nil {
+		c.stack = append(c.stack, s)
+	} else {
+		c.stack[len(c.stack)-1] = s
+	}
+	return c.change(out, true, s)
+}
+
+// AddAdditive preloads s and adds it alongside the stacked scenes without
+// transitions.
+func (c *Context) AddAdditive(s *Scene) *gorge.Task {
+	c.additive = append(c.additive, s)
+	return c.change(nil, false, s)
+}
+
+// RemoveAdditive removes a scene added with AddAdditive.
+func (c *Context) RemoveAdditive(s *Scene) *gorge.Task {
+	for i, v := range c.additive {
+		if v != s {
+			continue
+		}
+		c.additive = append(c.additive[:i], c.additive[i+1:]...)
+		return c.change(s, true, nil)
+	}
+	return nil
+}
+
+// Preload starts loading the scene resources with the scene preload func,
+// progress is reported with EventPreloadProgress and EventPreloadComplete.
+func (c *Context) Preload(s *Scene) {
+	if s.preloadfn == nil || s.preload != nil {
+		return
+	}
+	s.preload = &preload{pending: map[any]struct{}{}}
+	c.preloading = append(c.preloading, s)
+	c.collect(s, func() { s.preloadfn(c.gorge) })
+	c.checkPreload(s)
+}
+
+// change runs a scene change as a task, changes are serialized so a change
+// requested during a transition starts after it.
+func (c *Context) change(out *Scene, unload bool, in *Scene) *gorge.Task {
+	prev := c.busy
+	tr := c.transition
+	if out == nil || in == nil || out == in {
+		tr = nil
+	}
+	t := c.gorge.Go(nil, func(t *gorge.Task) {
+		if prev != nil {
+			t.Await(prev)
+		}
+		if in != nil {
+			c.Preload(in)
+			t.WaitUntil(in.Ready)
+		}
+		if out != nil && out != in {
+			if tr != nil && tr.Out != nil {
+				tr.Out(t)
+			}
+			out.suspended = !unload
+			c.gorge.Remove(out)
+			if unload {
+				c.unload(out)
+			}
+		}
+		if in != nil && in != out {
+			in.suspended = false
+			c.gorge.Add(in)
+			if tr != nil && tr.In != nil {
+				tr.In(t)
+			}
+		}
+	})
+	if !t.Done() {
+		c.busy = t
+	}
+	return t
+}
+
+// collect tracks the resources loaded while fn runs as loaded by s, load
+// events posted while fn runs are tracked when they are flushed.
+func (c *Context) collect(s *Scene, fn func()) {
+	prev := c.collecting
+	c.collecting = s
+	if c.flushing == nil {
+		c.flushing = map[*Scene]int{}
+	}
+	c.flushing[s]++
+	c.gorge.PostFunc(func() { c.posted = append(c.posted, s) })
+	defer func() {
+		c.collecting = prev
+		c.gorge.PostFunc(func() {
+			c.posted = c.posted[:len(c.posted)-1]
+			if c.flushing[s]--; c.flushing[s] == 0 {
+				delete(c.flushing, s)
+			}
+			if s.preload != nil {
+				c.checkPreload(s)
+			}
+		})
+	}()
+	fn()
+}
+
+// collectingScene returns the scene collecting the current load events.
+func (c *Context) collectingScene() *Scene {
+	if c.collecting != nil {
+		return c.collecting
+	}
+	if n := len(c.posted); n > 0 {
+		return c.posted[n-1]
+	}
+	return nil
+}
+
+func (c *Context) handleLoadStart(e resource.EventLoadStart) {
+	s := c.collectingScene()
+	if s == nil {
+		return
+	}
+	if s.assets == nil {
+		s.assets = map[string]struct{}{}
+	}
+	s.assets[e.Name] = struct{}{}
+	if p := s.preload; p != nil && !p.done {
+		p.pending[e.Resource] = struct{}{}
+		p.total++
+	}
+}
+
+func (c *Context) handleLoadComplete(e resource.EventLoadComplete) {
+	for _, s := range append([]*Scene(nil), c.preloading...) {
+		p := s.preload
+		if _, ok := p.pending[e.Resource]; !ok {
+			continue
+		}
+		delete(p.pending, e.Resource)
+		p.loaded++
+		if e.Err != nil && p.err == nil {
+			p.err = e.Err
+		}
+		event.Trigger(c.gorge, EventPreloadProgress{
+			Scene:  s,
+			Loaded: p.loaded,
+			Total:  p.total,
+		})
+		c.checkPreload(s)
+	}
+}
+
+func (c *Context) checkPreload(s *Scene) {
+	p := s.preload
+	// Loads might complete while the preload func is still running or before
+	// its posted load events are flushed.
+	if p.done || len(p.pending) > 0 || c.collecting == s || c.flushing[s] > 0 {
+		return
+	}
+	p.done = true
+	for i, v := range c.preloading {
+		if v == s {
+			c.preloading = append(c.preloading[:i], c.preloading[i+1:]...)
+			break
+		}
+	}
+	event.Trigger(c.gorge, EventPreloadComplete{Scene: s, Err: p.err})
+}
+
+// unload releases the resources loaded by s that aren't loaded by any
+// active scene.
+func (c *Context) unload(s *Scene) {
+	assets := s.assets
+	s.assets = nil
+	s.preload = nil
+	if c.noUnload {
+		return
+	}
+	res := resource.FromContext(c.gorge)
+	for name := range assets {
+		if c.inUse(name) {
+			continue
+		}
+		res.Release(name)
+	}
+}
+
+func (c *Context) inUse(name string) bool {
+	for _, list := range [][]*Scene{c.stack, c.additive} {
+		for _, s := range list {
+			if _, ok := s.assets[name]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
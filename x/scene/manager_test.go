@@ -0,0 +1,110 @@
+package scene_test
+
+import (
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/core/event"
+	"github.com/stdiopt/gorge/systems/resource"
+	"github.com/stdiopt/gorge/x/scene"
+)
+
+func TestManager(t *testing.T) {
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c }, scene.System)
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	sm := scene.FromContext(g)
+
+	inits := map[string]int{}
+	newScene := func(name string) *scene.Scene {
+		s := scene.New(name)
+		s.OnInit(func(*gorge.Context) { inits[name]++ })
+		return s
+	}
+
+	a, b := newScene("a"), newScene("b")
+	var progress []float32
+	event.Handle(g, func(e scene.EventPreloadProgress) {
+		progress = append(progress, e.Progress())
+	})
+	res := []*int{new(int), new(int)}
+	b.OnPreload(func(g *gorge.Context) {
+		for i, r := range res {
+			name := []string{"b1", "b2"}[i]
+			event.Trigger(g, resource.EventLoadStart{Name: name, Resource: r})
+			gorge.Post(g, resource.EventLoadComplete{Name: name, Resource: r})
+		}
+	})
+
+	var fades []float32
+	sm.SetTransition(scene.Fade(0, func(a float32) { fades = append(fades, a) }))
+
+	if tk := sm.Push(a); !tk.Done() || !g.HasEntity(a) {
+		t.Fatal("want scene a added right away")
+	}
+	tk := sm.Push(b)
+	if tk.Done() || g.HasEntity(b) {
+		t.Fatal("want scene b waiting for preload")
+	}
+	for i := 0; i < 3; i++ {
+		gg.Update(1.0 / 60)
+	}
+	if !tk.Done() || !g.HasEntity(b) || g.HasEntity(a) {
+		t.Fatalf("want scene b on top of suspended a, done: %v", tk.Done())
+	}
+	if len(progress) != 2 || progress[1] != 1 {
+		t.Errorf("want 2 progress events ending at 1, got %v", progress)
+	}
+	if len(fades) != 2 || fades[0] != 1 || fades[1] != 0 {
+		t.Errorf("want fade out and in, got %v", fades)
+	}
+
+	if tk := sm.Pop(); !tk.Done() || !g.HasEntity(a) || g.HasEntity(b) {
+		t.Fatal("want scene a resumed")
+	}
+	if inits["a"] != 1 || inits["b"] != 1 {
+		t.Errorf("want scenes initialized once, got %v", inits)
+	}
+	if sm.Current() != a || len(sm.Stack()) != 1 {
+		t.Errorf("want a as the only stacked scene, got %v", sm.Stack())
+	}
+
+	c := newScene("c")
+	sm.Replace(c)
+	if sm.Current() != c || g.HasEntity(a) || !g.HasEntity(c) {
+		t.Error("want a replaced by c")
+	}
+}
+
+func TestManagerPostedLoads(t *testing.T) {
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c }, scene.System)
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+	sm := scene.FromContext(g)
+
+	r := new(int)
+	s := scene.New("posted")
+	s.OnPreload(func(g *gorge.Context) {
+		// Start events are posted by loads started off the main loop.
+		gorge.Post(g, resource.EventLoadStart{Name: "p1", Resource: r})
+	})
+	tk := sm.Push(s)
+	for i := 0; i < 3; i++ {
+		gg.Update(1.0 / 60)
+	}
+	if tk.Done() || s.Ready() {
+		t.Fatal("want scene waiting for posted load")
+	}
+	gorge.Post(g, resource.EventLoadComplete{Name: "p1", Resource: r})
+	gg.Update(1.0 / 60)
+	gg.Update(1.0 / 60)
+	if !tk.Done() || !g.HasEntity(s) {
+		t.Fatal("want scene added after posted load")
+	}
+}
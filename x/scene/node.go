@@ -0,0 +1,61 @@
+package scene
+
+import "github.com/stdiopt/gorge"
+
+// Node is an entity loaded from a document, it holds the node transform and
+// the entities of the decoded components which share it.
+type Node struct {
+	*gorge.TransformComponent
+	Name string
+
+	entities []gorge.Entity
+}
+
+// NewNode returns a new node with an identity transform.
+func NewNode(name string) *Node {
+	return &Node{
+		TransformComponent: gorge.NewTransformComponent(),
+		Name:               name,
+	}
+}
+
+// Add adds component entities to the node, it should be called before the
+// node is added to gorge.
+func (n *Node) Add(ents ...gorge.Entity) {
+	n.entities = append(n.entities, ents...)
+}
+
+// GetEntities implements gorge.EntityContainer.
+func (n *Node) GetEntities() []gorge.Entity {
+	return n.entities
+}
+
+// CloneEntity implements gorge.EntityCloner.
+func (n *Node) CloneEntity(c *gorge.Cloner) gorge.Entity {
+	return &Node{
+		TransformComponent: c.Transform(n.TransformComponent),
+		Name:               n.Name,
+		entities:           c.Entities(n.entities),
+	}
+}
+
+// Entities of the decoded builtin components.
+type (
+	renderableEntity struct {
+		*gorge.TransformComponent
+		*gorge.RenderableComponent
+	}
+	colorableEntity struct {
+		*gorge.TransformComponent
+		*gorge.RenderableComponent
+		*gorge.ColorableComponent
+	}
+	lightEntity struct {
+		*gorge.TransformComponent
+		*gorge.LightComponent
+	}
+	cameraEntity struct {
+		*gorge.TransformComponent
+		*gorge.CameraComponent
+	}
+)
@@ -0,0 +1,74 @@
+package scene
+
+import (
+	"fmt"
+
+	"github.com/stdiopt/gorge"
+)
+
+// codec encodes and decodes a registered component type.
+type codec struct {
+	name   string
+	get    func(e gorge.Entity) (any, bool)
+	encode func(enc *Encoder, c any) (any, error)
+	// unmarshal decodes the component data with a json or gob decode func.
+	unmarshal func(dec func(any) error) (any, error)
+	decode    func(dec *Decoder, n *Node, data any) error
+}
+
+var (
+	codecs     = map[string]*codec{}
+	codecOrder []*codec
+)
+
+// Register registers a component type to be serialized, get returns the
+// component C of an entity, encode converts it to the data D written in the
+// document and decode adds the component back to a loaded node.
+// D must be encodable with encoding/json and encoding/gob, registering an
+// existing name replaces it.
+func Register[C, D any](
+	name string,
+	get func(gorge.Entity) (C, bool),
+	encode func(*Encoder, C) (D, error),
+	decode func(*Decoder, *Node, D) error,
+) {
+	c := &codec{
+		name: name,
+		get: func(e gorge.Entity) (any, bool) {
+			return get(e)
+		},
+		encode: func(enc *Encoder, c any) (any, error) {
+			return encode(enc, c.(C))
+		},
+		unmarshal: func(dec func(any) error) (any, error) {
+			var d D
+			err := dec(&d)
+			return d, err
+		},
+		decode: func(dec *Decoder, n *Node, data any) error {
+			d, ok := data.(D)
+			if !ok {
+				return fmt.Errorf("component %q: invalid data type %T", name, data)
+			}
+			return decode(dec, n, d)
+		},
+	}
+	if old, ok := codecs[name]; ok {
+		for i, v := range codecOrder {
+			if v == old {
+				codecOrder[i] = c
+			}
+		}
+	} else {
+		codecOrder = append(codecOrder, c)
+	}
+	codecs[name] = c
+}
+
+func getCodec(name string) (*codec, error) {
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown component type: %q", name)
+	}
+	return c, nil
+}
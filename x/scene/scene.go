@@ -13,10 +13,18 @@ type Scene struct {
 
 	Name string
 
-	initfn func(*gorge.Context)
+	initfn    func(*gorge.Context)
+	preloadfn func(*gorge.Context)
+	subs      event.Group
 
 	gorge       *gorge.Context
 	initialized bool
+	// suspended is set by the manager while the scene is under the stack top.
+	suspended bool
+
+	// assets are the resource names loaded by the scene.
+	assets  map[string]struct{}
+	preload *preload
 }
 
 func New(name string) *Scene {
@@ -32,10 +40,31 @@ func (s *Scene) OnInit(fn func(*gorge.Context)) {
 	s.initfn = fn
 }
 
+// OnPreload sets a func to load the scene resources before the scene is shown
+// by the manager, resources loaded by it are tracked as preload progress.
+func (s *Scene) OnPreload(fn func(*gorge.Context)) {
+	s.preloadfn = fn
+}
+
+// Ready returns true if the scene resources are preloaded, scenes without
+// preload func are always ready.
+func (s *Scene) Ready() bool {
+	if s.preloadfn == nil {
+		return true
+	}
+	return s.preload != nil && s.preload.done
+}
+
 func (s *Scene) G() *gorge.Context {
 	return s.gorge
 }
 
+// Track ties event subscriptions to the scene, they will be cancelled when the
+// scene is removed, scenes suspended by the manager keep them.
+func (s *Scene) Track(subs ...*event.Subscription) {
+	s.subs.Add(subs...)
+}
+
 func (s *Scene) Add(e ...gorge.Entity) {
 	s.Container.Add(e...)
 
@@ -56,7 +85,8 @@ func (s *Scene) initScene(g *gorge.Context) {
 	s.gorge = g
 	g.AddBus(s)
 
-	if s.initfn != nil {
+	// Suspended scenes keep their state.
+	if !s.initialized && s.initfn != nil {
 		s.initfn(g)
 	}
 	s.initialized = true
@@ -65,4 +95,9 @@ func (s *Scene) initScene(g *gorge.Context) {
 func (s *Scene) destroyScene(g *gorge.Context) {
 	g.RemoveBus(s)
 	s.gorge = nil
+	if s.suspended {
+		return
+	}
+	s.subs.Cancel()
+	s.initialized = false
 }
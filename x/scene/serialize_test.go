@@ -0,0 +1,161 @@
+package scene_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/math/gm"
+	"github.com/stdiopt/gorge/x/scene"
+)
+
+type lightEntity struct {
+	*gorge.TransformComponent
+	*gorge.LightComponent
+}
+
+type renderEntity struct {
+	*gorge.TransformComponent
+	*gorge.RenderableComponent
+	*gorge.ColorableComponent
+}
+
+func TestSaveLoad(t *testing.T) {
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	mesh := gorge.NewMesh(&gorge.MeshData{})
+	mat := gorge.NewMaterial()
+	mat.Name = "red"
+	mat.Set("albedo", gm.Vec4{1, 0, 0, 1})
+	mat.Define("HAS_ALBEDO")
+
+	root := &lightEntity{gorge.NewTransformComponent(), gorge.NewLightComponent()}
+	root.SetPosition(1, 2, 3)
+	root.Intensity = 42
+
+	child := &renderEntity{
+		gorge.NewTransformComponent(),
+		gorge.NewRenderableComponent(mesh, mat),
+		gorge.NewColorableComponent(0, 1, 0, 1),
+	}
+	child.SetParent(root)
+	child.SetPosition(0, 1, 0)
+
+	for _, f := range []scene.Format{scene.FormatJSON, scene.FormatBinary} {
+		t.Run(f.String(), func(t *testing.T) {
+			enc := scene.NewEncoder(g)
+			enc.Resolve = func(a any) (string, bool) {
+				return "cube", a == mesh
+			}
+			doc, err := enc.Encode(child, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := &bytes.Buffer{}
+			if err := doc.Write(buf, f); err != nil {
+				t.Fatal(err)
+			}
+			doc, err = scene.ReadDocument(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dec := scene.NewDecoder(g)
+			dec.Assets = map[string]any{"cube": mesh}
+			nodes, err := dec.Decode(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes) != 2 {
+				t.Fatalf("want 2 nodes, got %d", len(nodes))
+			}
+			gotRoot, gotChild := nodes[0], nodes[1]
+
+			if want, got := child.WorldPosition(), gotChild.WorldPosition(); want != got {
+				t.Errorf("\nwant: %v\n got: %v\n", want, got)
+			}
+			l, ok := gotRoot.GetEntities()[0].(interface{ Light() *gorge.LightComponent })
+			if !ok || l.Light().Intensity != 42 {
+				t.Errorf("want root light with intensity 42, got %v", gotRoot.GetEntities())
+			}
+			r, ok := gotChild.GetEntities()[0].(interface {
+				Renderable() *gorge.RenderableComponent
+				Colorable() *gorge.ColorableComponent
+			})
+			if !ok {
+				t.Fatalf("want child colorable renderable, got %v", gotChild.GetEntities())
+			}
+			rc := r.Renderable()
+			if rc.Mesh != mesh || rc.Material.Name != "red" {
+				t.Errorf("want renderable with mesh and red material, got %v %v", rc.Mesh, rc.Material)
+			}
+			if want, got := (gm.Vec4{1, 0, 0, 1}), rc.Material.Get("albedo"); got != want {
+				t.Errorf("\nwant: %v\n got: %v\n", want, got)
+			}
+			if _, ok := rc.Material.Defines()["HAS_ALBEDO"]; !ok {
+				t.Errorf("want HAS_ALBEDO define, got %v", rc.Material.Defines())
+			}
+			if want, got := (gm.Vec4{0, 1, 0, 1}), r.Colorable().Color; got != want {
+				t.Errorf("\nwant: %v\n got: %v\n", want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeMissingAsset(t *testing.T) {
+	var g *gorge.Context
+	gg := gorge.New(func(c *gorge.Context) { g = c })
+	if err := gg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gg.Close()
+
+	ent := &renderEntity{
+		gorge.NewTransformComponent(),
+		gorge.NewRenderableComponent(gorge.NewMesh(&gorge.MeshData{}), gorge.NewMaterial()),
+		nil,
+	}
+	if _, err := scene.NewEncoder(g).Encode(ent); err == nil {
+		t.Error("want error encoding a mesh without path")
+	}
+}
+
+func TestReadDocumentCounts(t *testing.T) {
+	type header struct {
+		Version int
+		Nodes   int
+	}
+	type node struct {
+		Name       string
+		Components int
+	}
+	tests := []struct {
+		name  string
+		nodes int
+		comps int
+	}{
+		{"negative nodes", -1, 0},
+		{"huge nodes", 1 << 40, 0},
+		{"negative components", 1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBufferString("GSCN")
+			enc := gob.NewEncoder(buf)
+			if err := enc.Encode(header{Version: 1, Nodes: tt.nodes}); err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.Encode(node{Name: "a", Components: tt.comps}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := scene.ReadDocument(buf); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -1,16 +1,32 @@
 package scene
 
 import (
-	"log"
-
 	"github.com/stdiopt/gorge"
 	"github.com/stdiopt/gorge/core/event"
 )
 
 type Context struct {
 	gorge *gorge.Context
+
+	stack      []*Scene
+	additive   []*Scene
+	transition *Transition
+	noUnload   bool
+	// collecting receives the names of resources loaded while a scene inits
+	// or preloads.
+	collecting *Scene
+	// posted is the scene collecting events posted while it was collecting,
+	// they are flushed on the main loop after collect returns, flushing
+	// counts the collects of a scene with posted events not flushed yet.
+	posted     []*Scene
+	flushing   map[*Scene]int
+	preloading []*Scene
+	busy       *gorge.Task
 }
 
+// SystemDef declares the scene system.
+var SystemDef = gorge.SystemDef{Name: "scene", Init: System}
+
 func System(g *gorge.Context) {
 	FromContext(g)
 }
@@ -23,12 +39,16 @@ func FromContext(g *gorge.Context) *Context {
 	if ctx, ok := gorge.GetContext[*Context](g); ok {
 		return ctx
 	}
-	log.Println("Initializing system")
+	g.Log("scene").Info("initializing system")
+
+	ctx := &Context{
+		gorge: g,
+	}
 
 	event.Handle(g, func(e gorge.EventAddEntity) {
 		if sg, ok := e.Entity.(sceneGetter); ok {
 			s := sg.GetScene()
-			s.initScene(g)
+			ctx.collect(s, func() { s.initScene(g) })
 			event.Trigger(s, EventAttached{g})
 		}
 	})
@@ -38,9 +58,8 @@ func FromContext(g *gorge.Context) *Context {
 			s.destroyScene(g)
 		}
 	})
-	ctx := &Context{
-		gorge: g,
-	}
+	event.Handle(g, ctx.handleLoadStart)
+	event.Handle(g, ctx.handleLoadComplete)
 
 	// Handle scene management
 	return gorge.SetContext(g, ctx)
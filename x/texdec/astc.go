@@ -0,0 +1,587 @@
+package texdec
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// astcError is the color of blocks that are invalid or use HDR endpoints.
+var astcError = [4]byte{255, 0, 255, 255}
+
+// astcLevels are the integer sequence ranges from the smallest.
+var astcLevels = [...]int{
+	2, 3, 4, 5, 6, 8, 10, 12, 16, 20, 24, 32, 40, 48, 64, 80, 96, 128, 160, 192, 256,
+}
+
+// astcWeightLevels are the weight ranges by the block mode precision bit and
+// range bits.
+var astcWeightLevels = [2][8]int{
+	{2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 8},
+	{2: 10, 3: 12, 4: 16, 5: 20, 6: 24, 7: 32},
+}
+
+// decodeASTC returns a decoder for LDR ASTC blocks of bw x bh pixels.
+func decodeASTC(bw, bh int) blockFunc {
+	return func(dst [][4]byte, b []byte) {
+		if !decodeASTCBlock(dst, b, bw, bh) {
+			for i := range dst {
+				dst[i] = astcError
+			}
+		}
+	}
+}
+
+type astcMode struct {
+	gw, gh int // weight grid size
+	levels int // weight range
+	dual   bool
+}
+
+// decodeASTCBlock decodes a block and returns false if the block is invalid.
+func decodeASTCBlock(dst [][4]byte, b []byte, bw, bh int) bool {
+	lo := binary.LittleEndian.Uint64(b)
+	hi := binary.LittleEndian.Uint64(b[8:])
+	at := func(off, n int) int { return bits128(lo, hi, off, n) }
+
+	if at(0, 9) == 0x1fc {
+		// Void extent block with a constant 16 bit color.
+		if at(9, 1) == 1 {
+			return false
+		}
+		c := [4]byte{byte(at(72, 8)), byte(at(88, 8)), byte(at(104, 8)), byte(at(120, 8))}
+		for i := range dst {
+			dst[i] = c
+		}
+		return true
+	}
+	mode, ok := astcBlockMode(at(0, 11))
+	if !ok || mode.gw > bw || mode.gh > bh {
+		return false
+	}
+	planes := 1
+	if mode.dual {
+		planes = 2
+	}
+	parts := at(11, 2) + 1
+	nweights := mode.gw * mode.gh * planes
+	weightBits := iseBits(mode.levels, nweights)
+	if (mode.dual && parts == 4) || nweights > 64 || weightBits < 24 || weightBits > 96 {
+		return false
+	}
+
+	// Color endpoint modes, multiple partitions share the mode class and
+	// extra bits are stored below the weights.
+	var cems [4]int
+	part, colorStart, below := 0, 17, 0
+	if parts == 1 {
+		cems[0] = at(13, 4)
+	} else {
+		part, colorStart = at(13, 10), 29
+		cem := at(23, 6)
+		if cem&3 == 0 {
+			for i := 0; i < parts; i++ {
+				cems[i] = cem >> 2
+			}
+		} else {
+			below = 3*parts - 4
+			cem |= at(128-weightBits-below, below) << 6
+			base := cem&3 - 1
+			for i := 0; i < parts; i++ {
+				c := cem >> (2 + i) & 1
+				m := cem >> (2 + parts + 2*i) & 3
+				cems[i] = (base+c)<<2 | m
+			}
+		}
+	}
+	ccs := -1
+	if mode.dual {
+		below += 2
+		ccs = at(128-weightBits-below, 2)
+	}
+	nvalues := 0
+	for i := 0; i < parts; i++ {
+		nvalues += (cems[i]>>2 + 1) * 2
+	}
+	colorBits := 128 - weightBits - below - colorStart
+	levels := 0
+	for _, l := range astcLevels {
+		if iseBits(l, nvalues) <= colorBits {
+			levels = l
+		}
+	}
+	if nvalues > 18 || levels < 6 {
+		return false
+	}
+
+	values := iseDecode(lo, hi, colorStart, levels, nvalues)
+	for i, v := range values {
+		values[i] = unquantizeColor(levels, v)
+	}
+	var ep [4][2][4]int
+	for i := 0; i < parts; i++ {
+		n := (cems[i]>>2 + 1) * 2
+		var ok bool
+		ep[i][0], ep[i][1], ok = astcEndpoints(cems[i], values[:n])
+		if !ok {
+			return false
+		}
+		values = values[n:]
+	}
+
+	// Weights are stored from the top of the block in reverse bit order.
+	weights := iseDecode(bits.Reverse64(hi), bits.Reverse64(lo), 0, mode.levels, nweights)
+	for i, w := range weights {
+		weights[i] = unquantizeWeight(mode.levels, w)
+	}
+
+	ds := (1024 + bw/2) / (bw - 1)
+	dt := (1024 + bh/2) / (bh - 1)
+	for y := 0; y < bh; y++ {
+		for x := 0; x < bw; x++ {
+			var w [2]int
+			for p := 0; p < planes; p++ {
+				w[p] = astcInfill(weights, mode, p, planes, ds*x, dt*y)
+			}
+			if !mode.dual {
+				w[1] = w[0]
+			}
+			p := 0
+			if parts > 1 {
+				p = astcPartition(part, x, y, parts, bw*bh < 31)
+			}
+			var c [4]byte
+			for ch := 0; ch < 4; ch++ {
+				wt := w[0]
+				if ch == ccs {
+					wt = w[1]
+				}
+				c0, c1 := ep[p][0][ch]*257, ep[p][1][ch]*257
+				c[ch] = byte((c0*(64-wt) + c1*wt + 32) >> 6 >> 8)
+			}
+			dst[y*bw+x] = c
+		}
+	}
+	return true
+}
+
+// astcBlockMode decodes the 11 bit block mode.
+func astcBlockMode(m int) (astcMode, bool) {
+	bit := func(i int) int { return m >> i & 1 }
+	var gw, gh, r int
+	a := m >> 5 & 3
+	h, dual := bit(9), bit(10) == 1
+	if m&3 != 0 {
+		r = (m&3)<<1 | bit(4)
+		b := m >> 7 & 3
+		switch m >> 2 & 3 {
+		case 0:
+			gw, gh = b+4, a+2
+		case 1:
+			gw, gh = b+8, a+2
+		case 2:
+			gw, gh = a+2, b+8
+		default:
+			if bit(8) == 0 {
+				gw, gh = a+2, bit(7)+6
+			} else {
+				gw, gh = bit(7)+2, a+2
+			}
+		}
+	} else {
+		r = m>>1&6 | bit(4)
+		switch m >> 7 & 3 {
+		case 0:
+			gw, gh = 12, a+2
+		case 1:
+			gw, gh = a+2, 12
+		case 2:
+			gw, gh = a+6, m>>9&3+6
+			h, dual = 0, false
+		default:
+			switch a {
+			case 0:
+				gw, gh = 6, 10
+			case 1:
+				gw, gh = 10, 6
+			default:
+				return astcMode{}, false
+			}
+		}
+	}
+	if r < 2 {
+		return astcMode{}, false
+	}
+	return astcMode{gw, gh, astcWeightLevels[h][r], dual}, true
+}
+
+// astcInfill returns the weight of plane p at the fixed point block position
+// cs, ct by interpolating the weight grid.
+func astcInfill(weights []int, m astcMode, p, planes, cs, ct int) int {
+	gs := (cs*(m.gw-1) + 32) >> 6
+	gt := (ct*(m.gh-1) + 32) >> 6
+	js, fs := gs>>4, gs&15
+	jt, ft := gt>>4, gt&15
+	w11 := (fs*ft + 8) >> 4
+	w10 := ft - w11
+	w01 := fs - w11
+	w00 := 16 - fs - ft + w11
+
+	weight := func(x, y int) int {
+		if x >= m.gw || y >= m.gh {
+			return 0
+		}
+		return weights[(y*m.gw+x)*planes+p]
+	}
+	return (weight(js, jt)*w00 + weight(js+1, jt)*w01 +
+		weight(js, jt+1)*w10 + weight(js+1, jt+1)*w11 + 8) >> 4
+}
+
+// astcEndpoints decodes the LDR endpoint modes, HDR modes return false.
+func astcEndpoints(cem int, v []int) (e0, e1 [4]int, ok bool) {
+	switch cem {
+	case 0: // luminance direct
+		e0 = [4]int{v[0], v[0], v[0], 255}
+		e1 = [4]int{v[1], v[1], v[1], 255}
+	case 1: // luminance base+offset
+		l0 := v[0]>>2 | v[1]&0xc0
+		l1 := l0 + v[1]&0x3f
+		e0 = [4]int{l0, l0, l0, 255}
+		e1 = [4]int{l1, l1, l1, 255}
+	case 4: // luminance alpha direct
+		e0 = [4]int{v[0], v[0], v[0], v[2]}
+		e1 = [4]int{v[1], v[1], v[1], v[3]}
+	case 5: // luminance alpha base+offset
+		v1, v0 := bitTransferSigned(v[1], v[0])
+		v3, v2 := bitTransferSigned(v[3], v[2])
+		e0 = [4]int{v0, v0, v0, v2}
+		e1 = [4]int{v0 + v1, v0 + v1, v0 + v1, v2 + v3}
+	case 6: // rgb scale
+		e0 = [4]int{v[0] * v[3] >> 8, v[1] * v[3] >> 8, v[2] * v[3] >> 8, 255}
+		e1 = [4]int{v[0], v[1], v[2], 255}
+	case 8, 12: // rgb(a) direct
+		a0, a1 := 255, 255
+		if cem == 12 {
+			a0, a1 = v[6], v[7]
+		}
+		if v[1]+v[3]+v[5] >= v[0]+v[2]+v[4] {
+			e0 = [4]int{v[0], v[2], v[4], a0}
+			e1 = [4]int{v[1], v[3], v[5], a1}
+		} else {
+			e0 = blueContract(v[1], v[3], v[5], a1)
+			e1 = blueContract(v[0], v[2], v[4], a0)
+		}
+	case 9, 13: // rgb(a) base+offset
+		var d, base [4]int
+		for i := 0; i < 3; i++ {
+			d[i], base[i] = bitTransferSigned(v[2*i+1], v[2*i])
+		}
+		d[3], base[3] = 0, 255
+		if cem == 13 {
+			d[3], base[3] = bitTransferSigned(v[7], v[6])
+		}
+		if d[0]+d[1]+d[2] >= 0 {
+			e0 = base
+			e1 = [4]int{base[0] + d[0], base[1] + d[1], base[2] + d[2], base[3] + d[3]}
+		} else {
+			e0 = blueContract(base[0]+d[0], base[1]+d[1], base[2]+d[2], base[3]+d[3])
+			e1 = blueContract(base[0], base[1], base[2], base[3])
+		}
+	case 10: // rgb scale with alpha
+		e0 = [4]int{v[0] * v[3] >> 8, v[1] * v[3] >> 8, v[2] * v[3] >> 8, v[4]}
+		e1 = [4]int{v[0], v[1], v[2], v[5]}
+	default:
+		return e0, e1, false
+	}
+	for i := range e0 {
+		e0[i], e1[i] = int(clamp(e0[i])), int(clamp(e1[i]))
+	}
+	return e0, e1, true
+}
+
+// bitTransferSigned moves the top bit of the offset a to the base b and
+// returns the signed 6 bit offset and the base.
+func bitTransferSigned(a, b int) (int, int) {
+	b = b>>1 | a&0x80
+	a = a >> 1 & 0x3f
+	if a&0x20 != 0 {
+		a -= 0x40
+	}
+	return a, b
+}
+
+func blueContract(r, g, b, a int) [4]int {
+	return [4]int{(r + b) >> 1, (g + b) >> 1, b, a}
+}
+
+// astcPartition returns the partition of the pixel x, y.
+func astcPartition(seed, x, y, parts int, small bool) int {
+	if small {
+		x, y = x<<1, y<<1
+	}
+	seed += (parts - 1) * 1024
+	rnum := astcHash(uint32(seed))
+	var s [12]uint32
+	for i := 0; i < 8; i++ {
+		s[i] = rnum >> (4 * i) & 0xf
+	}
+	s[8] = rnum >> 18 & 0xf
+	s[9] = rnum >> 22 & 0xf
+	s[10] = rnum >> 26 & 0xf
+	s[11] = (rnum>>30 | rnum<<2) & 0xf
+	for i := range s {
+		s[i] *= s[i]
+	}
+	var sh1, sh2 uint32
+	if seed&1 != 0 {
+		sh1, sh2 = 4, 5
+		if seed&2 == 0 {
+			sh1 = 5
+		}
+		if parts == 3 {
+			sh2 = 6
+		}
+	} else {
+		sh1, sh2 = 5, 4
+		if parts == 3 {
+			sh1 = 6
+		}
+		if seed&2 == 0 {
+			sh2 = 5
+		}
+	}
+	sh3 := sh2
+	if seed&0x10 != 0 {
+		sh3 = sh1
+	}
+	for i := 0; i < 8; i += 2 {
+		s[i] >>= sh1
+		s[i+1] >>= sh2
+	}
+	for i := 8; i < 12; i++ {
+		s[i] >>= sh3
+	}
+	ux, uy := uint32(x), uint32(y)
+	a := (s[0]*ux + s[1]*uy + rnum>>14) & 0x3f
+	b := (s[2]*ux + s[3]*uy + rnum>>10) & 0x3f
+	c := (s[4]*ux + s[5]*uy + rnum>>6) & 0x3f
+	d := (s[6]*ux + s[7]*uy + rnum>>2) & 0x3f
+	if parts < 4 {
+		d = 0
+	}
+	if parts < 3 {
+		c = 0
+	}
+	switch {
+	case a >= b && a >= c && a >= d:
+		return 0
+	case b >= c && b >= d:
+		return 1
+	case c >= d:
+		return 2
+	}
+	return 3
+}
+
+func astcHash(p uint32) uint32 {
+	p ^= p >> 15
+	p -= p << 17
+	p += p << 7
+	p += p << 4
+	p ^= p >> 5
+	p += p << 16
+	p ^= p >> 7
+	p ^= p >> 3
+	p ^= p << 6
+	p ^= p >> 17
+	return p
+}
+
+// iseRange returns the trit or quint and the number of bits of each value
+// of the integer sequence range.
+func iseRange(levels int) (trit, quint bool, n int) {
+	switch {
+	case levels%3 == 0:
+		return true, false, bits.Len(uint(levels/3)) - 1
+	case levels%5 == 0:
+		return false, true, bits.Len(uint(levels/5)) - 1
+	}
+	return false, false, bits.Len(uint(levels)) - 1
+}
+
+// iseBits returns the number of bits used by count values.
+func iseBits(levels, count int) int {
+	trit, quint, n := iseRange(levels)
+	switch {
+	case trit:
+		return count*n + (8*count+4)/5
+	case quint:
+		return count*n + (7*count+2)/3
+	}
+	return count * n
+}
+
+// iseDecode decodes count integer sequence values at off, bits past the
+// sequence are read as 0.
+func iseDecode(lo, hi uint64, off, levels, count int) []int {
+	trit, quint, n := iseRange(levels)
+	end := off + iseBits(levels, count)
+	read := func(sz int) int {
+		v := 0
+		if off < end {
+			v = bits128(lo, hi, off, sz)
+			if off+sz > end {
+				v &= 1<<(end-off) - 1
+			}
+		}
+		off += sz
+		return v
+	}
+	ret := make([]int, 0, count+4)
+	switch {
+	case trit:
+		for len(ret) < count {
+			var m [5]int
+			var t int
+			for i, tb := range [5]int{2, 2, 1, 2, 1} {
+				m[i] = read(n)
+				t |= read(tb) << [5]int{0, 2, 4, 5, 7}[i]
+			}
+			for i, tv := range decodeTrits(t) {
+				ret = append(ret, tv<<n|m[i])
+			}
+		}
+	case quint:
+		for len(ret) < count {
+			var m [3]int
+			var q int
+			for i, qb := range [3]int{3, 2, 2} {
+				m[i] = read(n)
+				q |= read(qb) << [3]int{0, 3, 5}[i]
+			}
+			for i, qv := range decodeQuints(q) {
+				ret = append(ret, qv<<n|m[i])
+			}
+		}
+	default:
+		for len(ret) < count {
+			ret = append(ret, read(n))
+		}
+	}
+	return ret[:count]
+}
+
+// decodeTrits unpacks 5 base 3 values from 8 bits.
+func decodeTrits(t int) [5]int {
+	bit := func(i int) int { return t >> i & 1 }
+	var c, t0, t1, t2, t3, t4 int
+	if t>>2&7 == 7 {
+		c = (t>>5&7)<<2 | t&3
+		t4, t3 = 2, 2
+	} else {
+		c = t & 0x1f
+		if t>>5&3 == 3 {
+			t4, t3 = 2, bit(7)
+		} else {
+			t4, t3 = bit(7), t>>5&3
+		}
+	}
+	cb := func(i int) int { return c >> i & 1 }
+	switch {
+	case c&3 == 3:
+		t2, t1 = 2, cb(4)
+		t0 = cb(3)<<1 | cb(2)&^cb(3)
+	case c>>2&3 == 3:
+		t2, t1, t0 = 2, 2, c&3
+	default:
+		t2, t1 = cb(4), c>>2&3
+		t0 = cb(1)<<1 | cb(0)&^cb(1)
+	}
+	return [5]int{t0, t1, t2, t3, t4}
+}
+
+// decodeQuints unpacks 3 base 5 values from 7 bits.
+func decodeQuints(q int) [3]int {
+	bit := func(i int) int { return q >> i & 1 }
+	var q0, q1, q2 int
+	if q>>1&3 == 3 && q>>5&3 == 0 {
+		q2 = bit(0)<<2 | (bit(4)&^bit(0))<<1 | bit(3)&^bit(0)
+		q1, q0 = 4, 4
+	} else {
+		var c int
+		if q>>1&3 == 3 {
+			q2 = 4
+			c = (q>>3&3)<<3 | (^q>>5&3)<<1 | bit(0)
+		} else {
+			q2 = q >> 5 & 3
+			c = q & 0x1f
+		}
+		if c&7 == 5 {
+			q1, q0 = 4, c>>3&3
+		} else {
+			q1, q0 = c>>3&3, c&7
+		}
+	}
+	return [3]int{q0, q1, q2}
+}
+
+// unquantizeColor expands an integer sequence value to 8 bits.
+func unquantizeColor(levels, v int) int {
+	trit, quint, n := iseRange(levels)
+	if !trit && !quint {
+		return replicate(v, n, 8)
+	}
+	var b string
+	var c int
+	if trit {
+		b, c = [...]string{
+			1: "000000000", "b000b0bb0", "cb000cbcb", "dcb000dcb", "edcb000ed", "fedcb000f",
+		}[n], [...]int{1: 204, 93, 44, 22, 11, 5}[n]
+	} else {
+		b, c = [...]string{
+			1: "000000000", "b0000bb00", "cb0000cbc", "dcb0000dc", "edcb0000e",
+		}[n], [...]int{1: 113, 54, 26, 13, 6}[n]
+	}
+	return unquantize(v, n, b, c, 0x1ff, 0x80)
+}
+
+// unquantizeWeight expands an integer sequence value to the 0-64 range.
+func unquantizeWeight(levels, v int) int {
+	trit, quint, n := iseRange(levels)
+	var w int
+	switch {
+	case !trit && !quint:
+		w = replicate(v, n, 6)
+	case n == 0 && trit:
+		w = [3]int{0, 32, 63}[v]
+	case n == 0 && quint:
+		w = [5]int{0, 16, 32, 47, 63}[v]
+	case trit:
+		w = unquantize(v, n, [...]string{1: "0000000", "b000b0b", "cb000cb"}[n], [...]int{1: 50, 23, 11}[n], 0x7f, 0x20)
+	default:
+		w = unquantize(v, n, [...]string{1: "0000000", "b0000b0"}[n], [...]int{1: 28, 13}[n], 0x7f, 0x20)
+	}
+	if w > 32 {
+		w++
+	}
+	return w
+}
+
+// unquantize expands a trit or quint value with n bits, pattern spells the
+// bits of B from the highest where letters are the value bits from a.
+func unquantize(v, n int, pattern string, c, amask, top int) int {
+	m := v & (1<<n - 1)
+	a := 0
+	if m&1 != 0 {
+		a = amask
+	}
+	b := 0
+	for _, p := range pattern {
+		b <<= 1
+		if p != '0' {
+			b |= m >> (p - 'a') & 1
+		}
+	}
+	t := (v>>n)*c + b
+	t ^= a
+	return a&top | t>>2
+}
@@ -0,0 +1,100 @@
+package texdec
+
+import "encoding/binary"
+
+// decodeBC1 decodes a DXT1 block, in the 3 color mode the 4th color is
+// transparent black.
+func decodeBC1(dst [][4]byte, b []byte) {
+	decodeColor(dst, b, true)
+}
+
+// decodeBC2 decodes a DXT3 block with explicit 4 bit alpha.
+func decodeBC2(dst [][4]byte, b []byte) {
+	decodeColor(dst, b[8:], false)
+	alpha := binary.LittleEndian.Uint64(b)
+	for i := range dst {
+		dst[i][3] = byte(alpha>>(4*i)&0xF) * 17
+	}
+}
+
+// decodeBC3 decodes a DXT5 block with interpolated alpha.
+func decodeBC3(dst [][4]byte, b []byte) {
+	decodeColor(dst, b[8:], false)
+	decodeChannel(dst, b, 3)
+}
+
+// decodeBC4 decodes a single red channel block.
+func decodeBC4(dst [][4]byte, b []byte) {
+	decodeChannel(dst, b, 0)
+	for i := range dst {
+		dst[i][1], dst[i][2], dst[i][3] = 0, 0, 255
+	}
+}
+
+// decodeBC5 decodes a red and green channel block.
+func decodeBC5(dst [][4]byte, b []byte) {
+	decodeChannel(dst, b, 0)
+	decodeChannel(dst, b[8:], 1)
+	for i := range dst {
+		dst[i][2], dst[i][3] = 0, 255
+	}
+}
+
+// decodeColor decodes the RGB565 endpoints and 2 bit indices shared by BC1-3,
+// punch is true for BC1 which has the 3 color mode when c0 <= c1.
+func decodeColor(dst [][4]byte, b []byte, punch bool) {
+	c0 := binary.LittleEndian.Uint16(b)
+	c1 := binary.LittleEndian.Uint16(b[2:])
+	idx := binary.LittleEndian.Uint32(b[4:])
+
+	var pal [4][4]int
+	pal[0] = rgb565(c0)
+	pal[1] = rgb565(c1)
+	if c0 > c1 || !punch {
+		for i := 0; i < 3; i++ {
+			pal[2][i] = (2*pal[0][i] + pal[1][i]) / 3
+			pal[3][i] = (pal[0][i] + 2*pal[1][i]) / 3
+		}
+		pal[2][3], pal[3][3] = 255, 255
+	} else {
+		for i := 0; i < 3; i++ {
+			pal[2][i] = (pal[0][i] + pal[1][i]) / 2
+		}
+		pal[2][3] = 255
+	}
+	for i := range dst {
+		c := pal[idx>>(2*i)&3]
+		dst[i] = [4]byte{byte(c[0]), byte(c[1]), byte(c[2]), byte(c[3])}
+	}
+}
+
+// decodeChannel decodes a BC4 style block into channel ch.
+func decodeChannel(dst [][4]byte, b []byte, ch int) {
+	a0, a1 := int(b[0]), int(b[1])
+	var pal [8]int
+	pal[0], pal[1] = a0, a1
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			pal[i+1] = ((7-i)*a0 + i*a1) / 7
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			pal[i+1] = ((5-i)*a0 + i*a1) / 5
+		}
+		pal[6], pal[7] = 0, 255
+	}
+	var bits uint64
+	for i := 0; i < 6; i++ {
+		bits |= uint64(b[2+i]) << (8 * i)
+	}
+	for i := range dst {
+		dst[i][ch] = byte(pal[bits>>(3*i)&7])
+	}
+}
+
+func rgb565(c uint16) [4]int {
+	r := int(c >> 11 & 0x1F)
+	g := int(c >> 5 & 0x3F)
+	b := int(c & 0x1F)
+	return [4]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 255}
+}
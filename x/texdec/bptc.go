@@ -0,0 +1,479 @@
+package texdec
+
+// bptcWeights are the interpolation weights for 2, 3 and 4 bit indices.
+var bptcWeights = [5][]int{
+	2: {0, 21, 43, 64},
+	3: {0, 9, 18, 27, 37, 46, 55, 64},
+	4: {0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64},
+}
+
+// bptcPartition2 holds a bit per pixel set for the pixels of the second
+// subset, BC6H uses the first 32 partitions.
+var bptcPartition2 = [64]uint16{
+	0xcccc, 0x8888, 0xeeee, 0xecc8, 0xc880, 0xfeec, 0xfec8, 0xec80,
+	0xc800, 0xffec, 0xfe80, 0xe800, 0xffe8, 0xff00, 0xfff0, 0xf000,
+	0xf710, 0x008e, 0x7100, 0x08ce, 0x008c, 0x7310, 0x3100, 0x8cce,
+	0x088c, 0x3110, 0x6666, 0x366c, 0x17e8, 0x0ff0, 0x718e, 0x399c,
+	0xaaaa, 0xf0f0, 0x5a5a, 0x33cc, 0x3c3c, 0x55aa, 0x9696, 0xa55a,
+	0x73ce, 0x13c8, 0x324c, 0x3bdc, 0x6996, 0xc33c, 0x9966, 0x0660,
+	0x0272, 0x04e4, 0x4e40, 0x2720, 0xc936, 0x936c, 0x39c6, 0x639c,
+	0x9336, 0x9cc6, 0x817e, 0xe718, 0xccf0, 0x0fcc, 0x7744, 0xee22,
+}
+
+// bptcPartition3 holds 2 bits per pixel with the subset of each pixel.
+var bptcPartition3 = [64]uint32{
+	0xaa685050, 0x6a5a5040, 0x5a5a4200, 0x5450a0a8, 0xa5a50000, 0xa0a05050, 0x5555a0a0, 0x5a5a5050,
+	0xaa550000, 0xaa555500, 0xaaaa5500, 0x90909090, 0x94949494, 0xa4a4a4a4, 0xa9a59450, 0x2a0a4250,
+	0xa5945040, 0x0a425054, 0xa5a5a500, 0x55a0a0a0, 0xa8a85454, 0x6a6a4040, 0xa4a45000, 0x1a1a0500,
+	0x0050a4a4, 0xaaa59090, 0x14696914, 0x69691400, 0xa08585a0, 0xaa821414, 0x50a4a450, 0x6a5a0200,
+	0xa9a58000, 0x5090a0a8, 0xa8a09050, 0x24242424, 0x00aa5500, 0x24924924, 0x24499224, 0x50a50a50,
+	0x500aa550, 0xaaaa4444, 0x66660000, 0xa5a0a5a0, 0x50a050a0, 0x69286928, 0x44aaaa44, 0x66666600,
+	0xaa444444, 0x54a854a8, 0x95809580, 0x96969600, 0xa85454a8, 0x80959580, 0xaa141414, 0x96960000,
+	0xaaaa1414, 0xa05050a0, 0xa0a5a5a0, 0x96000000, 0x40804080, 0xa9a8a9a8, 0xaaaaaa44, 0x2a4a5254,
+}
+
+// Anchor pixels of the second subset of 2 subset partitions and of the
+// second and third subsets of 3 subset partitions, anchor indices are stored
+// with one bit less.
+var (
+	bptcAnchor2 = [64]uint8{
+		15, 15, 15, 15, 15, 15, 15, 15,
+		15, 15, 15, 15, 15, 15, 15, 15,
+		15, 2, 8, 2, 2, 8, 8, 15,
+		2, 8, 2, 2, 8, 8, 2, 2,
+		15, 15, 6, 8, 2, 8, 15, 15,
+		2, 8, 2, 2, 2, 15, 15, 6,
+		6, 2, 6, 8, 15, 15, 2, 2,
+		15, 15, 15, 15, 15, 2, 2, 15,
+	}
+	bptcAnchor3a = [64]uint8{
+		3, 3, 15, 15, 8, 3, 15, 15,
+		8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10,
+		5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15,
+		15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10,
+		5, 10, 8, 13, 15, 12, 3, 3,
+	}
+	bptcAnchor3b = [64]uint8{
+		15, 8, 8, 3, 15, 15, 3, 8,
+		15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8,
+		3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10,
+		6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15,
+		15, 15, 15, 15, 3, 15, 15, 8,
+	}
+)
+
+// bptcSubset returns the subset of pixel i.
+func bptcSubset(subsets, part, i int) int {
+	switch subsets {
+	case 2:
+		return int(bptcPartition2[part] >> i & 1)
+	case 3:
+		return int(bptcPartition3[part] >> (2 * i) & 3)
+	}
+	return 0
+}
+
+// bptcAnchor returns true if pixel i is the anchor of its subset.
+func bptcAnchor(subsets, part, i int) bool {
+	switch bptcSubset(subsets, part, i) {
+	case 0:
+		return i == 0
+	case 1:
+		if subsets == 2 {
+			return i == int(bptcAnchor2[part])
+		}
+		return i == int(bptcAnchor3a[part])
+	default:
+		return i == int(bptcAnchor3b[part])
+	}
+}
+
+func bptcInterp(e0, e1, w int) int {
+	return ((64-w)*e0 + w*e1 + 32) >> 6
+}
+
+type bc7Mode struct {
+	subsets    int
+	partBits   int
+	rotBits    int
+	selBits    int
+	colorBits  int
+	alphaBits  int
+	pBits      int // unique p-bit per endpoint
+	sharedBits int // p-bit shared by the subset endpoints
+	indexBits  int
+	index2Bits int
+}
+
+var bc7Modes = [8]bc7Mode{
+	{3, 4, 0, 0, 4, 0, 1, 0, 3, 0},
+	{2, 6, 0, 0, 6, 0, 0, 1, 3, 0},
+	{3, 6, 0, 0, 5, 0, 0, 0, 2, 0},
+	{2, 6, 0, 0, 7, 0, 1, 0, 2, 0},
+	{1, 0, 2, 1, 5, 6, 0, 0, 2, 3},
+	{1, 0, 2, 0, 7, 8, 0, 0, 2, 2},
+	{1, 0, 0, 0, 7, 7, 1, 0, 4, 0},
+	{2, 6, 0, 0, 5, 5, 1, 0, 2, 0},
+}
+
+// decodeBC7 decodes a BPTC unorm block, blocks with an invalid mode are
+// transparent black.
+func decodeBC7(dst [][4]byte, b []byte) {
+	r := newBitReader(b)
+	mode := 0
+	for mode < 8 && r.read(1) == 0 {
+		mode++
+	}
+	if mode == 8 {
+		for i := range dst {
+			dst[i] = [4]byte{}
+		}
+		return
+	}
+	m := bc7Modes[mode]
+	part := r.read(m.partBits)
+	rot := r.read(m.rotBits)
+	sel := r.read(m.selBits)
+
+	n := m.subsets * 2
+	var ep [6][4]int
+	for ch := 0; ch < 3; ch++ {
+		for i := 0; i < n; i++ {
+			ep[i][ch] = r.read(m.colorBits)
+		}
+	}
+	for i := 0; i < n && m.alphaBits > 0; i++ {
+		ep[i][3] = r.read(m.alphaBits)
+	}
+	cbits, abits := m.colorBits, m.alphaBits
+	if m.pBits > 0 || m.sharedBits > 0 {
+		var p [6]int
+		for i := 0; i < n; i++ {
+			if m.pBits > 0 {
+				p[i] = r.read(1)
+			} else if i%2 == 0 {
+				p[i] = r.read(1)
+				p[i+1] = p[i]
+			}
+		}
+		for i := 0; i < n; i++ {
+			for ch := 0; ch < 3; ch++ {
+				ep[i][ch] = ep[i][ch]<<1 | p[i]
+			}
+			if abits > 0 {
+				ep[i][3] = ep[i][3]<<1 | p[i]
+			}
+		}
+		cbits++
+		if abits > 0 {
+			abits++
+		}
+	}
+	for i := 0; i < n; i++ {
+		for ch := 0; ch < 3; ch++ {
+			ep[i][ch] = replicate(ep[i][ch], cbits, 8)
+		}
+		if abits > 0 {
+			ep[i][3] = replicate(ep[i][3], abits, 8)
+		} else {
+			ep[i][3] = 255
+		}
+	}
+	var idx, idx2 [16]int
+	for i := range idx {
+		bits := m.indexBits
+		if bptcAnchor(m.subsets, part, i) {
+			bits--
+		}
+		idx[i] = r.read(bits)
+	}
+	for i := 0; i < 16 && m.index2Bits > 0; i++ {
+		bits := m.index2Bits
+		if i == 0 {
+			bits--
+		}
+		idx2[i] = r.read(bits)
+	}
+
+	for i := range dst {
+		s := bptcSubset(m.subsets, part, i)
+		e0, e1 := ep[2*s], ep[2*s+1]
+		cw := bptcWeights[m.indexBits][idx[i]]
+		aw := cw
+		if m.index2Bits > 0 {
+			aw = bptcWeights[m.index2Bits][idx2[i]]
+			if sel == 1 {
+				cw, aw = aw, cw
+			}
+		}
+		var c [4]byte
+		for ch := 0; ch < 3; ch++ {
+			c[ch] = byte(bptcInterp(e0[ch], e1[ch], cw))
+		}
+		c[3] = byte(bptcInterp(e0[3], e1[3], aw))
+		switch rot {
+		case 1:
+			c[0], c[3] = c[3], c[0]
+		case 2:
+			c[1], c[3] = c[3], c[1]
+		case 3:
+			c[2], c[3] = c[3], c[2]
+		}
+		dst[i] = c
+	}
+}
+
+// bc6Field is a run of bits of an endpoint channel, the bits are read from
+// first towards last which is reversed in a few modes.
+type bc6Field struct {
+	ep, ch      uint8 // endpoint w, x, y, z and channel
+	first, last uint8
+}
+
+type bc6Mode struct {
+	regions     int
+	transformed bool
+	epBits      int
+	deltaBits   [3]int
+	fields      []bc6Field
+}
+
+const (
+	bc6W = iota
+	bc6X
+	bc6Y
+	bc6Z
+	bc6D // partition
+)
+
+// run returns a field run of endpoint ep and channel ch from bit first to
+// last.
+func run(ep, ch, first, last uint8) bc6Field { return bc6Field{ep, ch, first, last} }
+
+// bc6Modes are indexed by the 5 bit mode value, 2 bit modes use the values
+// 0 and 1.
+var bc6Modes = map[int]bc6Mode{
+	0x00: {2, true, 10, [3]int{5, 5, 5}, []bc6Field{
+		run(bc6Y, 1, 4, 4), run(bc6Y, 2, 4, 4), run(bc6Z, 2, 4, 4),
+		run(bc6W, 0, 0, 9), run(bc6W, 1, 0, 9), run(bc6W, 2, 0, 9),
+		run(bc6X, 0, 0, 4), run(bc6Z, 1, 4, 4), run(bc6Y, 1, 0, 3),
+		run(bc6X, 1, 0, 4), run(bc6Z, 2, 0, 0), run(bc6Z, 1, 0, 3),
+		run(bc6X, 2, 0, 4), run(bc6Z, 2, 1, 1), run(bc6Y, 2, 0, 3),
+		run(bc6Y, 0, 0, 4), run(bc6Z, 2, 2, 2), run(bc6Z, 0, 0, 4),
+		run(bc6Z, 2, 3, 3), run(bc6D, 0, 0, 4),
+	}},
+	0x01: {2, true, 7, [3]int{6, 6, 6}, []bc6Field{
+		run(bc6Y, 1, 5, 5), run(bc6Z, 1, 4, 4), run(bc6Z, 1, 5, 5),
+		run(bc6W, 0, 0, 6), run(bc6Z, 2, 0, 0), run(bc6Z, 2, 1, 1), run(bc6Y, 2, 4, 4),
+		run(bc6W, 1, 0, 6), run(bc6Y, 2, 5, 5), run(bc6Z, 2, 2, 2), run(bc6Y, 1, 4, 4),
+		run(bc6W, 2, 0, 6), run(bc6Z, 2, 3, 3), run(bc6Z, 2, 5, 5), run(bc6Z, 2, 4, 4),
+		run(bc6X, 0, 0, 5), run(bc6Y, 1, 0, 3), run(bc6X, 1, 0, 5), run(bc6Z, 1, 0, 3),
+		run(bc6X, 2, 0, 5), run(bc6Y, 2, 0, 3), run(bc6Y, 0, 0, 5), run(bc6Z, 0, 0, 5),
+		run(bc6D, 0, 0, 4),
+	}},
+	0x02: {2, true, 11, [3]int{5, 4, 4}, []bc6Field{
+		run(bc6W, 0, 0, 9), run(bc6W, 1, 0, 9), run(bc6W, 2, 0, 9),
+		run(bc6X, 0, 0, 4), run(bc6W, 0, 10, 10), run(bc6Y, 1, 0, 3),
+		run(bc6X, 1, 0, 3), run(bc6W, 1, 10, 10), run(bc6Z, 2, 0, 0),
+		run(bc6Z, 1, 0, 3), run(bc6X, 2, 0, 3), run(bc6W, 2, 10, 10),
+		run(bc6Z, 2, 1, 1), run(bc6Y, 2, 0, 3), run(bc6Y, 0, 0, 4),
+		run(bc6Z, 2, 2, 2), run(bc6Z, 0, 0, 4), run(bc6Z, 2, 3, 3),
+		run(bc6D, 0, 0, 4),
+	}},
+	0x06: {2, true, 11, [3]int{4, 5, 4}, []bc6Field{
+		run(bc6W, 0, 0, 9), run(bc6W, 1, 0, 9), run(bc6W, 2, 0, 9),
+		run(bc6X, 0, 0, 3), run(bc6W, 0, 10, 10), run(bc6Z, 1, 4, 4),
+		run(bc6Y, 1, 0, 3), run(bc6X, 1, 0, 4), run(bc6W, 1, 10, 10),
+		run(bc6Z, 1, 0, 3), run(bc6X, 2, 0, 3), run(bc6W, 2, 10, 10),
+		run(bc6Z, 2, 1, 1), run(bc6Y, 2, 0, 3), run(bc6Y, 0, 0, 3),
+		run(bc6Z, 2, 0, 0), run(bc6Z, 2, 2, 2), run(bc6Z, 0, 0, 3),
+		run(bc6Y, 1, 4, 4), run(bc6Z, 2, 3, 3), run(bc6D, 0, 0, 4),
+	}},
+	0x0a: {2, true, 11, [3]int{4, 4, 5}, []bc6Field{
+		run(bc6W, 0, 0, 9), run(bc6W, 1, 0, 9), run(bc6W, 2, 0, 9),
+		run(bc6X, 0, 0, 3), run(bc6W, 0, 10, 10), run(bc6Y, 2, 4, 4),
+		run(bc6Y, 1, 0, 3), run(bc6X, 1, 0, 3), run(bc6W, 1, 10, 10),
+		run(bc6Z, 2, 0, 0), run(bc6Z, 1, 0, 3), run(bc6X, 2, 0, 4),
+		run(bc6W, 2, 10, 10), run(bc6Y, 2, 0, 3), run(bc6Y, 0, 0, 3),
+		run(bc6Z, 2, 1, 1), run(bc6Z, 2, 2, 2), run(bc6Z, 0, 0, 3),
+		run(bc6Z, 2, 4, 4), run(bc6Z, 2, 3, 3), run(bc6D, 0, 0, 4),
+	}},
+	0x0e: {2, true, 9, [3]int{5, 5, 5}, []bc6Field{
+		run(bc6W, 0, 0, 8), run(bc6Y, 2, 4, 4), run(bc6W, 1, 0, 8),
+		run(bc6Y, 1, 4, 4), run(bc6W, 2, 0, 8), run(bc6Z, 2, 4, 4),
+		run(bc6X, 0, 0, 4), run(bc6Z, 1, 4, 4), run(bc6Y, 1, 0, 3),
+		run(bc6X, 1, 0, 4), run(bc6Z, 2, 0, 0), run(bc6Z, 1, 0, 3),
+		run(bc6X, 2, 0, 4), run(bc6Z, 2, 1, 1), run(bc6Y, 2, 0, 3),
+		run(bc6Y, 0, 0, 4), run(bc6Z, 2, 2, 2), run(bc6Z, 0, 0, 4),
+		run(bc6Z, 2, 3, 3), run(bc6D, 0, 0, 4),
+	}},
+	0x12: {2, true, 8, [3]int{6, 5, 5}, []bc6Field{
+		run(bc6W, 0, 0, 7), run(bc6Z, 1, 4, 4), run(bc6Y, 2, 4, 4),
+		run(bc6W, 1, 0, 7), run(bc6Z, 2, 2, 2), run(bc6Y, 1, 4, 4),
+		run(bc6W, 2, 0, 7), run(bc6Z, 2, 3, 3), run(bc6Z, 2, 4, 4),
+		run(bc6X, 0, 0, 5), run(bc6Y, 1, 0, 3), run(bc6X, 1, 0, 4),
+		run(bc6Z, 2, 0, 0), run(bc6Z, 1, 0, 3), run(bc6X, 2, 0, 4),
+		run(bc6Z, 2, 1, 1), run(bc6Y, 2, 0, 3), run(bc6Y, 0, 0, 5),
+		run(bc6Z, 0, 0, 5), run(bc6D, 0, 0, 4),
+	}},
+	0x16: {2, true, 8, [3]int{5, 6, 5}, []bc6Field{
+		run(bc6W, 0, 0, 7), run(bc6Z, 2, 0, 0), run(bc6Y, 2, 4, 4),
+		run(bc6W, 1, 0, 7), run(bc6Y, 1, 5, 5), run(bc6Y, 1, 4, 4),
+		run(bc6W, 2, 0, 7), run(bc6Z, 1, 5, 5), run(bc6Z, 2, 4, 4),
+		run(bc6X, 0, 0, 4), run(bc6Z, 1, 4, 4), run(bc6Y, 1, 0, 3),
+		run(bc6X, 1, 0, 5), run(bc6Z, 1, 0, 3), run(bc6X, 2, 0, 4),
+		run(bc6Z, 2, 1, 1), run(bc6Y, 2, 0, 3), run(bc6Y, 0, 0, 4),
+		run(bc6Z, 2, 2, 2), run(bc6Z, 0, 0, 4), run(bc6Z, 2, 3, 3),
+		run(bc6D, 0, 0, 4),
+	}},
+	0x1a: {2, true, 8, [3]int{5, 5, 6}, []bc6Field{
+		run(bc6W, 0, 0, 7), run(bc6Z, 2, 1, 1), run(bc6Y, 2, 4, 4),
+		run(bc6W, 1, 0, 7), run(bc6Y, 2, 5, 5), run(bc6Y, 1, 4, 4),
+		run(bc6W, 2, 0, 7), run(bc6Z, 2, 5, 5), run(bc6Z, 2, 4, 4),
+		run(bc6X, 0, 0, 4), run(bc6Z, 1, 4, 4), run(bc6Y, 1, 0, 3),
+		run(bc6X, 1, 0, 4), run(bc6Z, 2, 0, 0), run(bc6Z, 1, 0, 3),
+		run(bc6X, 2, 0, 5), run(bc6Y, 2, 0, 3), run(bc6Y, 0, 0, 4),
+		run(bc6Z, 2, 2, 2), run(bc6Z, 0, 0, 4), run(bc6Z, 2, 3, 3),
+		run(bc6D, 0, 0, 4),
+	}},
+	0x1e: {2, false, 6, [3]int{6, 6, 6}, []bc6Field{
+		run(bc6W, 0, 0, 5), run(bc6Z, 1, 4, 4), run(bc6Z, 2, 0, 0), run(bc6Z, 2, 1, 1), run(bc6Y, 2, 4, 4),
+		run(bc6W, 1, 0, 5), run(bc6Y, 1, 5, 5), run(bc6Y, 2, 5, 5), run(bc6Z, 2, 2, 2), run(bc6Y, 1, 4, 4),
+		run(bc6W, 2, 0, 5), run(bc6Z, 1, 5, 5), run(bc6Z, 2, 3, 3), run(bc6Z, 2, 5, 5), run(bc6Z, 2, 4, 4),
+		run(bc6X, 0, 0, 5), run(bc6Y, 1, 0, 3), run(bc6X, 1, 0, 5), run(bc6Z, 1, 0, 3),
+		run(bc6X, 2, 0, 5), run(bc6Y, 2, 0, 3), run(bc6Y, 0, 0, 5), run(bc6Z, 0, 0, 5),
+		run(bc6D, 0, 0, 4),
+	}},
+	0x03: {1, false, 10, [3]int{10, 10, 10}, []bc6Field{
+		run(bc6W, 0, 0, 9), run(bc6W, 1, 0, 9), run(bc6W, 2, 0, 9),
+		run(bc6X, 0, 0, 9), run(bc6X, 1, 0, 9), run(bc6X, 2, 0, 9),
+	}},
+	0x07: {1, true, 11, [3]int{9, 9, 9}, []bc6Field{
+		run(bc6W, 0, 0, 9), run(bc6W, 1, 0, 9), run(bc6W, 2, 0, 9),
+		run(bc6X, 0, 0, 8), run(bc6W, 0, 10, 10),
+		run(bc6X, 1, 0, 8), run(bc6W, 1, 10, 10),
+		run(bc6X, 2, 0, 8), run(bc6W, 2, 10, 10),
+	}},
+	0x0b: {1, true, 12, [3]int{8, 8, 8}, []bc6Field{
+		run(bc6W, 0, 0, 9), run(bc6W, 1, 0, 9), run(bc6W, 2, 0, 9),
+		run(bc6X, 0, 0, 7), run(bc6W, 0, 11, 10),
+		run(bc6X, 1, 0, 7), run(bc6W, 1, 11, 10),
+		run(bc6X, 2, 0, 7), run(bc6W, 2, 11, 10),
+	}},
+	0x0f: {1, true, 16, [3]int{4, 4, 4}, []bc6Field{
+		run(bc6W, 0, 0, 9), run(bc6W, 1, 0, 9), run(bc6W, 2, 0, 9),
+		run(bc6X, 0, 0, 3), run(bc6W, 0, 15, 10),
+		run(bc6X, 1, 0, 3), run(bc6W, 1, 15, 10),
+		run(bc6X, 2, 0, 3), run(bc6W, 2, 15, 10),
+	}},
+}
+
+// decodeBC6H decodes a BPTC unsigned float block into half floats, blocks
+// with a reserved mode are black.
+func decodeBC6H(dst [][4]uint16, b []byte) {
+	r := newBitReader(b)
+	mv := r.read(2)
+	if mv >= 2 {
+		mv |= r.read(3) << 2
+	}
+	m, ok := bc6Modes[mv]
+	if !ok {
+		for i := range dst {
+			dst[i] = [4]uint16{0, 0, 0, 0x3c00}
+		}
+		return
+	}
+	var ep [4][3]int
+	part := 0
+	for _, fd := range m.fields {
+		step := 1
+		if fd.first > fd.last {
+			step = -1
+		}
+		for bit := int(fd.first); ; bit += step {
+			v := r.read(1) << bit
+			if fd.ep == bc6D {
+				part |= v
+			} else {
+				ep[fd.ep][fd.ch] |= v
+			}
+			if bit == int(fd.last) {
+				break
+			}
+		}
+	}
+	n := m.regions * 2
+	mask := 1<<m.epBits - 1
+	for i := 1; i < n && m.transformed; i++ {
+		for ch := 0; ch < 3; ch++ {
+			d := signExtend(ep[i][ch], m.deltaBits[ch])
+			ep[i][ch] = (ep[0][ch] + d) & mask
+		}
+	}
+	for i := 0; i < n; i++ {
+		for ch := 0; ch < 3; ch++ {
+			ep[i][ch] = bc6Unquantize(ep[i][ch], m.epBits)
+		}
+	}
+
+	ibits := 3
+	if m.regions == 1 {
+		ibits = 4
+	}
+	for i := range dst {
+		bits := ibits
+		if bptcAnchor(m.regions, part, i) {
+			bits--
+		}
+		w := bptcWeights[ibits][r.read(bits)]
+		s := bptcSubset(m.regions, part, i)
+		e0, e1 := ep[2*s], ep[2*s+1]
+		var c [4]uint16
+		for ch := 0; ch < 3; ch++ {
+			c[ch] = uint16(bptcInterp(e0[ch], e1[ch], w) * 31 >> 6)
+		}
+		c[3] = 0x3c00 // 1.0
+		dst[i] = c
+	}
+}
+
+func bc6Unquantize(v, bits int) int {
+	switch {
+	case bits >= 15:
+		return v
+	case v == 0:
+		return 0
+	case v == 1<<bits-1:
+		return 0xffff
+	}
+	return (v<<16 + 0x8000) >> bits
+}
+
+func signExtend(v, bits int) int {
+	if v&(1<<(bits-1)) != 0 {
+		return v - 1<<bits
+	}
+	return v
+}
+
+// replicate expands the n bit value v to bits by repeating its bits.
+func replicate(v, n, bits int) int {
+	if n == 0 {
+		return 0
+	}
+	ret := 0
+	for shift := bits - n; shift > -n; shift -= n {
+		if shift >= 0 {
+			ret |= v << shift
+		} else {
+			ret |= v >> -shift
+		}
+	}
+	return ret
+}
@@ -0,0 +1,19 @@
+package texdec
+
+import "testing"
+
+func TestPartitionAnchors(t *testing.T) {
+	for subsets := 2; subsets <= 3; subsets++ {
+		for part := 0; part < 64; part++ {
+			anchors := 0
+			for i := 0; i < 16; i++ {
+				if bptcAnchor(subsets, part, i) {
+					anchors++
+				}
+			}
+			if anchors != subsets {
+				t.Errorf("%d subsets partition %d: want %d anchors, got %d", subsets, part, subsets, anchors)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package texdec
+
+import "encoding/binary"
+
+var etcModifiers = [8][4]int{
+	{2, 8, -2, -8},
+	{5, 17, -5, -17},
+	{9, 29, -9, -29},
+	{13, 42, -13, -42},
+	{18, 60, -18, -60},
+	{24, 80, -24, -80},
+	{33, 106, -33, -106},
+	{47, 183, -47, -183},
+}
+
+var etcDistances = [8]int{3, 6, 11, 16, 23, 32, 41, 64}
+
+var eacModifiers = [16][8]int{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+// decodeETC2RGB decodes an opaque ETC2 block.
+func decodeETC2RGB(dst [][4]byte, b []byte) {
+	decodeETC2(dst, binary.BigEndian.Uint64(b))
+}
+
+// decodeETC2RGBA decodes an EAC alpha block followed by an ETC2 color block.
+func decodeETC2RGBA(dst [][4]byte, b []byte) {
+	decodeETC2(dst, binary.BigEndian.Uint64(b[8:]))
+	decodeEAC(dst, binary.BigEndian.Uint64(b))
+}
+
+// decodeETC2 decodes the individual, differential, T, H and planar modes,
+// pixel indices are stored column by column.
+func decodeETC2(dst [][4]byte, bits uint64) {
+	var c1, c2 [3]int
+	if bits>>33&1 == 0 {
+		c1 = [3]int{ext4(bits >> 60), ext4(bits >> 52), ext4(bits >> 44)}
+		c2 = [3]int{ext4(bits >> 56), ext4(bits >> 48), ext4(bits >> 40)}
+	} else {
+		r, g, b := int(bits>>59&31), int(bits>>51&31), int(bits>>43&31)
+		r2 := r + signed3(bits>>56)
+		g2 := g + signed3(bits>>48)
+		b2 := b + signed3(bits>>40)
+		switch {
+		case r2 < 0 || r2 > 31:
+			decodeETC2T(dst, bits)
+			return
+		case g2 < 0 || g2 > 31:
+			decodeETC2H(dst, bits)
+			return
+		case b2 < 0 || b2 > 31:
+			decodeETC2Planar(dst, bits)
+			return
+		}
+		c1 = [3]int{ext5(r), ext5(g), ext5(b)}
+		c2 = [3]int{ext5(r2), ext5(g2), ext5(b2)}
+	}
+	flip := bits>>32&1 == 1
+	t1, t2 := bits>>37&7, bits>>34&7
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			c, t := c1, t1
+			if (!flip && x >= 2) || (flip && y >= 2) {
+				c, t = c2, t2
+			}
+			m := etcModifiers[t][etcIndex(bits, x, y)]
+			dst[y*4+x] = [4]byte{clamp(c[0] + m), clamp(c[1] + m), clamp(c[2] + m), 255}
+		}
+	}
+}
+
+func decodeETC2T(dst [][4]byte, bits uint64) {
+	c1 := [3]int{
+		ext4(bits>>59&3<<2 | bits>>56&3),
+		ext4(bits >> 52),
+		ext4(bits >> 48),
+	}
+	c2 := [3]int{ext4(bits >> 44), ext4(bits >> 40), ext4(bits >> 36)}
+	d := etcDistances[bits>>34&3<<1|bits>>32&1]
+	etcPaint(dst, bits, [4][3]int{c1, add(c2, d), c2, add(c2, -d)})
+}
+
+func decodeETC2H(dst [][4]byte, bits uint64) {
+	r1 := bits >> 59 & 15
+	g1 := bits>>56&7<<1 | bits>>52&1
+	b1 := bits>>51&1<<3 | bits>>47&7
+	r2, g2, b2 := bits>>43&15, bits>>39&15, bits>>35&15
+
+	di := bits>>34&1<<2 | bits>>32&1<<1
+	if r1<<8|g1<<4|b1 >= r2<<8|g2<<4|b2 {
+		di |= 1
+	}
+	d := etcDistances[di]
+	c1 := [3]int{ext4(r1), ext4(g1), ext4(b1)}
+	c2 := [3]int{ext4(r2), ext4(g2), ext4(b2)}
+	etcPaint(dst, bits, [4][3]int{add(c1, d), add(c1, -d), add(c2, d), add(c2, -d)})
+}
+
+func decodeETC2Planar(dst [][4]byte, bits uint64) {
+	o := [3]int{
+		ext6(bits >> 57),
+		ext7(bits>>56&1<<6 | bits>>49&63),
+		ext6(bits>>48&1<<5 | bits>>43&3<<3 | bits>>39&7),
+	}
+	h := [3]int{
+		ext6(bits>>34&31<<1 | bits>>32&1),
+		ext7(bits >> 25),
+		ext6(bits >> 19),
+	}
+	v := [3]int{ext6(bits >> 13), ext7(bits >> 6), ext6(bits)}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			var c [4]byte
+			for i := 0; i < 3; i++ {
+				c[i] = clamp((x*(h[i]-o[i]) + y*(v[i]-o[i]) + 4*o[i] + 2) >> 2)
+			}
+			c[3] = 255
+			dst[y*4+x] = c
+		}
+	}
+}
+
+// decodeEAC decodes an EAC alpha block into the alpha channel.
+func decodeEAC(dst [][4]byte, bits uint64) {
+	base := int(bits >> 56)
+	mul := int(bits >> 52 & 15)
+	mods := eacModifiers[bits>>48&15]
+	for p := 0; p < 16; p++ {
+		x, y := p/4, p%4
+		dst[y*4+x][3] = clamp(base + mods[bits>>(45-3*p)&7]*mul)
+	}
+}
+
+func etcPaint(dst [][4]byte, bits uint64, pal [4][3]int) {
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			c := pal[etcIndex(bits, x, y)]
+			dst[y*4+x] = [4]byte{clamp(c[0]), clamp(c[1]), clamp(c[2]), 255}
+		}
+	}
+}
+
+func etcIndex(bits uint64, x, y int) int {
+	p := x*4 + y
+	return int(bits>>(16+p)&1<<1 | bits>>p&1)
+}
+
+func add(c [3]int, d int) [3]int {
+	return [3]int{c[0] + d, c[1] + d, c[2] + d}
+}
+
+func signed3(v uint64) int {
+	return int(v&7^4) - 4
+}
+
+func ext4(v uint64) int { v &= 15; return int(v<<4 | v) }
+func ext5(v int) int    { return v<<3 | v>>2 }
+func ext6(v uint64) int { v &= 63; return int(v<<2 | v>>4) }
+func ext7(v uint64) int { v &= 127; return int(v<<1 | v>>6) }
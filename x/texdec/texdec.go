@@ -0,0 +1,147 @@
+// Package texdec decodes block compressed texture data into uncompressed
+// pixels, it is used as a fallback when the GPU doesn't support a compressed
+// format.
+//
+// Every compressed gorge format has a fallback: BC1-5, BC7, ETC2 and ASTC
+// are decoded to RGBA and BC6H is decoded to RGBA16F to keep its range. ASTC
+// blocks using HDR endpoints are decoded to the magenta error color.
+package texdec
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/stdiopt/gorge"
+)
+
+// blockFunc decodes a block into dst which holds the block pixels row by row.
+type blockFunc func(dst [][4]byte, block []byte)
+
+// block16Func decodes a block into half float pixels.
+type block16Func func(dst [][4]uint16, block []byte)
+
+var decoders = map[gorge.TextureFormat]blockFunc{
+	gorge.TextureFormatBC1:      decodeBC1,
+	gorge.TextureFormatBC2:      decodeBC2,
+	gorge.TextureFormatBC3:      decodeBC3,
+	gorge.TextureFormatBC4:      decodeBC4,
+	gorge.TextureFormatBC5:      decodeBC5,
+	gorge.TextureFormatBC7:      decodeBC7,
+	gorge.TextureFormatETC2RGB:  decodeETC2RGB,
+	gorge.TextureFormatETC2RGBA: decodeETC2RGBA,
+	gorge.TextureFormatASTC4x4:  decodeASTC(4, 4),
+	gorge.TextureFormatASTC6x6:  decodeASTC(6, 6),
+	gorge.TextureFormatASTC8x8:  decodeASTC(8, 8),
+}
+
+var decoders16 = map[gorge.TextureFormat]block16Func{
+	gorge.TextureFormatBC6H: decodeBC6H,
+}
+
+// Supported returns true if the format can be decoded.
+func Supported(f gorge.TextureFormat) bool {
+	_, ok := decoders[f]
+	_, ok16 := decoders16[f]
+	return ok || ok16
+}
+
+// Format returns the format of the pixels returned by Decode for the
+// compressed format f, RGBA16F for BC6H and RGBA for the others.
+func Format(f gorge.TextureFormat) gorge.TextureFormat {
+	if _, ok := decoders16[f]; ok {
+		return gorge.TextureFormatRGBA16F
+	}
+	return gorge.TextureFormatRGBA
+}
+
+// Decode decodes width x height pixels of block compressed data in format f
+// and returns the pixels in the format returned by Format.
+func Decode(f gorge.TextureFormat, width, height int, data []byte) ([]byte, error) {
+	dec, ok := decoders[f]
+	dec16, ok16 := decoders16[f]
+	if !ok && !ok16 {
+		return nil, fmt.Errorf("texdec: unsupported format %v", f)
+	}
+	if want := f.DataSize(width, height); len(data) < want {
+		return nil, fmt.Errorf("texdec: %v %dx%d: want %d bytes, got %d", f, width, height, want, len(data))
+	}
+	if ok {
+		return decode(f, width, height, data, dec), nil
+	}
+	pix := decode(f, width, height, data, dec16)
+	ret := make([]byte, len(pix)*2)
+	for i, v := range pix {
+		binary.LittleEndian.PutUint16(ret[i*2:], v)
+	}
+	return ret, nil
+}
+
+// decode decodes the blocks and returns 4 channels per pixel.
+func decode[T uint8 | uint16](f gorge.TextureFormat, width, height int, data []byte, dec func([][4]T, []byte)) []T {
+	bw, bh, bsz := f.BlockSize()
+	bx := (width + bw - 1) / bw
+	by := (height + bh - 1) / bh
+
+	pix := make([]T, width*height*4)
+	block := make([][4]T, bw*bh)
+	for j := 0; j < by; j++ {
+		for i := 0; i < bx; i++ {
+			off := (j*bx + i) * bsz
+			dec(block, data[off:off+bsz])
+			for y := 0; y < bh && j*bh+y < height; y++ {
+				for x := 0; x < bw && i*bw+x < width; x++ {
+					o := ((j*bh+y)*width + i*bw + x) * 4
+					copy(pix[o:o+4], block[y*bw+x][:])
+				}
+			}
+		}
+	}
+	return pix
+}
+
+func clamp(v int) byte {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return byte(v)
+}
+
+// bits128 returns n bits at off of a 128 bit little endian block, bits past
+// the block are 0.
+func bits128(lo, hi uint64, off, n int) int {
+	if n == 0 || off >= 128 {
+		return 0
+	}
+	var v uint64
+	if off >= 64 {
+		v = hi >> (off - 64)
+	} else {
+		v = lo >> off
+		if off > 0 {
+			v |= hi << (64 - off)
+		}
+	}
+	return int(v & (1<<n - 1))
+}
+
+// bitReader reads a 128 bit block from the lowest bit.
+type bitReader struct {
+	lo, hi uint64
+	pos    int
+}
+
+func newBitReader(b []byte) *bitReader {
+	return &bitReader{
+		lo: binary.LittleEndian.Uint64(b),
+		hi: binary.LittleEndian.Uint64(b[8:]),
+	}
+}
+
+func (r *bitReader) read(n int) int {
+	v := bits128(r.lo, r.hi, r.pos, n)
+	r.pos += n
+	return v
+}
@@ -0,0 +1,155 @@
+package texdec_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/texdec"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		format gorge.TextureFormat
+		w, h   int
+		data   []byte
+		// want is the first and last pixels.
+		want [2][4]byte
+	}{
+		{
+			name:   "bc1 4 colors",
+			format: gorge.TextureFormatBC1,
+			w:      4, h: 4,
+			// red, blue, indices: 0 in the first pixel, 2 in the last one.
+			data: []byte{0x00, 0xF8, 0x1F, 0x00, 0x00, 0x00, 0x00, 0x80},
+			want: [2][4]byte{{255, 0, 0, 255}, {170, 0, 85, 255}},
+		},
+		{
+			name:   "bc1 transparent",
+			format: gorge.TextureFormatBC1,
+			w:      4, h: 4,
+			// blue <= red selects the 3 color mode, index 3 is transparent.
+			data: []byte{0x1F, 0x00, 0x00, 0xF8, 0x00, 0x00, 0x00, 0xC0},
+			want: [2][4]byte{{0, 0, 255, 255}, {0, 0, 0, 0}},
+		},
+		{
+			name:   "bc3 alpha",
+			format: gorge.TextureFormatBC3,
+			w:      4, h: 4,
+			data: []byte{
+				// alpha 200, 100, index 0 then 1 in the last pixel.
+				200, 100, 0, 0, 0, 0, 0, 0x20,
+				0xFF, 0xFF, 0x00, 0x00, 0, 0, 0, 0,
+			},
+			want: [2][4]byte{{255, 255, 255, 200}, {255, 255, 255, 100}},
+		},
+		{
+			name:   "bc4",
+			format: gorge.TextureFormatBC4,
+			w:      2, h: 2,
+			data: []byte{10, 10, 0, 0, 0, 0, 0, 0},
+			want: [2][4]byte{{10, 0, 0, 255}, {10, 0, 0, 255}},
+		},
+		{
+			name:   "etc2 individual",
+			format: gorge.TextureFormatETC2RGB,
+			w:      4, h: 4,
+			// R 8/8, G 4/4, B 2/2, table 0, first pixel +2, last pixel -8.
+			data: []byte{0x88, 0x44, 0x22, 0x00, 0x80, 0x00, 0x80, 0x00},
+			want: [2][4]byte{{138, 70, 36, 255}, {128, 60, 26, 255}},
+		},
+		{
+			name:   "etc2 planar",
+			format: gorge.TextureFormatETC2RGBA,
+			w:      4, h: 4,
+			data: []byte{
+				// EAC alpha base 100, multiplier 1, table 13, index 4 is +0.
+				100, 0x1D, 0x92, 0x49, 0x24, 0x92, 0x49, 0x24,
+				// Planar block with every color 0 but blue overflowing.
+				0x00, 0x00, 0x04, 0x02, 0x00, 0x00, 0x00, 0x00,
+			},
+			want: [2][4]byte{{0, 0, 0, 100}, {0, 0, 0, 100}},
+		},
+		{
+			name:   "bc7 mode 6",
+			format: gorge.TextureFormatBC7,
+			w:      4, h: 4,
+			// Endpoints 0 and 255 with p-bits, last pixel index 5.
+			data: []byte{
+				0x40, 0xc0, 0x1f, 0xf0, 0x07, 0xfc, 0x01, 0x7f,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x50,
+			},
+			want: [2][4]byte{{0, 0, 0, 0}, {84, 84, 84, 84}},
+		},
+		{
+			name:   "bc6h",
+			format: gorge.TextureFormatBC6H,
+			w:      4, h: 4,
+			// Single region direct endpoints 0 and 495 which is 1.0, the
+			// pixels are half floats so want is red green of the first
+			// pixel and blue alpha of the last one.
+			data: []byte{
+				0x03, 0x00, 0x00, 0x00, 0x78, 0xef, 0xbd, 0xf7,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0,
+			},
+			want: [2][4]byte{{0, 0, 0, 0}, {0x00, 0x3c, 0x00, 0x3c}},
+		},
+		{
+			name:   "astc void extent",
+			format: gorge.TextureFormatASTC6x6,
+			w:      6, h: 6,
+			data: []byte{
+				0xfc, 0xfd, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+				0xff, 0xff, 0x00, 0x00, 0x00, 0x80, 0xff, 0xff,
+			},
+			want: [2][4]byte{{255, 0, 128, 255}, {255, 0, 128, 255}},
+		},
+		{
+			name:   "astc luminance",
+			format: gorge.TextureFormatASTC4x4,
+			w:      4, h: 4,
+			// 4x4 grid of 2 bit weights, endpoints 0 and 255, the last
+			// weight is 1 which is 21 of 64.
+			data: []byte{
+				0x42, 0x00, 0x00, 0xfe, 0x01, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+			},
+			want: [2][4]byte{{0, 0, 0, 255}, {84, 84, 84, 255}},
+		},
+		{
+			name:   "astc reserved",
+			format: gorge.TextureFormatASTC8x8,
+			w:      8, h: 8,
+			data: make([]byte, 16),
+			want: [2][4]byte{{255, 0, 255, 255}, {255, 0, 255, 255}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pix, err := texdec.Decode(tt.format, tt.w, tt.h, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, psz := texdec.Format(tt.format).BlockSize()
+			if len(pix) != tt.w*tt.h*psz {
+				t.Fatalf("\nwant: %v\n got: %v\n", tt.w*tt.h*psz, len(pix))
+			}
+			got := [2][4]byte{}
+			copy(got[0][:], pix)
+			copy(got[1][:], pix[len(pix)-4:])
+			if !bytes.Equal(got[0][:], tt.want[0][:]) || !bytes.Equal(got[1][:], tt.want[1][:]) {
+				t.Errorf("\nwant: %v\n got: %v\n", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := texdec.Decode(gorge.TextureFormatRGBA, 4, 4, make([]byte, 64)); err == nil {
+		t.Error("want error for unsupported format")
+	}
+	if _, err := texdec.Decode(gorge.TextureFormatBC1, 8, 8, make([]byte, 8)); err == nil {
+		t.Error("want error for short data")
+	}
+}
@@ -0,0 +1,54 @@
+package vorbis
+
+// bitReader reads little endian bit fields from a packet, reading past the
+// end sets eop and returns zeros.
+type bitReader struct {
+	buf []byte
+	pos int
+	eop bool
+}
+
+func (b *bitReader) read(n uint) uint32 {
+	if b.pos+int(n) > len(b.buf)*8 {
+		b.pos = len(b.buf) * 8
+		b.eop = true
+		return 0
+	}
+	var v uint32
+	for i := uint(0); i < n; {
+		off := uint(b.pos & 7)
+		take := 8 - off
+		if take > n-i {
+			take = n - i
+		}
+		v |= uint32(b.buf[b.pos>>3]>>off) & (1<<take - 1) << i
+		b.pos += int(take)
+		i += take
+	}
+	return v
+}
+
+func (b *bitReader) flag() bool {
+	return b.read(1) == 1
+}
+
+// peek returns up to n bits without advancing and the number of bits
+// available.
+func (b *bitReader) peek(n uint) (uint32, uint) {
+	if left := len(b.buf)*8 - b.pos; left < int(n) {
+		n = uint(left)
+	}
+	pos := b.pos
+	v := b.read(n)
+	b.pos = pos
+	return v, n
+}
+
+// ilog returns the number of bits needed to represent v.
+func ilog(v int) uint {
+	n := uint(0)
+	for ; v > 0; v >>= 1 {
+		n++
+	}
+	return n
+}
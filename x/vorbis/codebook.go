@@ -0,0 +1,235 @@
+package vorbis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	codebookSync = 0x564342
+	fastBits     = 10
+	maxValues    = 1 << 24
+)
+
+type fastEntry struct {
+	entry  int32
+	length uint8
+}
+
+type codebook struct {
+	dims    int
+	entries int
+	// tree holds node pairs, positive values are the next node, negative
+	// values are leaves with -(entry+1) and zero is an unused branch.
+	tree []int32
+	// fast decodes codewords up to fastBits long in a single lookup.
+	fast []fastEntry
+	// values holds dims values per entry for vector lookups.
+	values []float32
+}
+
+func readCodebook(br *bitReader) (*codebook, error) {
+	if br.read(24) != codebookSync {
+		return nil, errors.New("invalid codebook sync")
+	}
+	cb := &codebook{
+		dims:    int(br.read(16)),
+		entries: int(br.read(24)),
+	}
+	if cb.dims == 0 || cb.entries > maxValues {
+		return nil, errors.New("invalid codebook size")
+	}
+	lengths := make([]uint8, cb.entries)
+	if ordered := br.flag(); ordered {
+		length := uint8(br.read(5) + 1)
+		for cur := 0; cur < cb.entries; length++ {
+			n := int(br.read(ilog(cb.entries - cur)))
+			if cur+n > cb.entries || length > 32 {
+				return nil, errors.New("invalid ordered codebook lengths")
+			}
+			for i := 0; i < n; i++ {
+				lengths[cur+i] = length
+			}
+			cur += n
+		}
+	} else {
+		sparse := br.flag()
+		for i := range lengths {
+			if sparse && !br.flag() {
+				continue
+			}
+			lengths[i] = uint8(br.read(5) + 1)
+		}
+	}
+	if br.eop {
+		return nil, errors.New("truncated codebook")
+	}
+	if err := cb.build(lengths); err != nil {
+		return nil, err
+	}
+
+	switch lookup := br.read(4); lookup {
+	case 0:
+	case 1, 2:
+		min := float32Unpack(br.read(32))
+		delta := float32Unpack(br.read(32))
+		bits := uint(br.read(4) + 1)
+		seq := br.flag()
+		n := cb.entries * cb.dims
+		if lookup == 1 {
+			n = lookup1Values(cb.entries, cb.dims)
+		}
+		if n == 0 || cb.entries*cb.dims > maxValues || n*int(bits) > len(br.buf)*8-br.pos {
+			return nil, errors.New("invalid codebook lookup size")
+		}
+		mult := make([]float32, n)
+		for i := range mult {
+			mult[i] = float32(br.read(bits))
+		}
+		if br.eop {
+			return nil, errors.New("truncated codebook lookup")
+		}
+		cb.values = make([]float32, cb.entries*cb.dims)
+		for e := 0; e < cb.entries; e++ {
+			last := float32(0)
+			div := 1
+			for i := 0; i < cb.dims; i++ {
+				off := e*cb.dims + i
+				if lookup == 1 {
+					off = e / div % n
+					div *= n
+				}
+				v := mult[off]*delta + min + last
+				if seq {
+					last = v
+				}
+				cb.values[e*cb.dims+i] = v
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid codebook lookup type %d", lookup)
+	}
+	return cb, nil
+}
+
+// build assigns the lowest available codeword of each length in entry order
+// and builds the decoding tables.
+func (cb *codebook) build(lengths []uint8) error {
+	var marker [33]uint32
+	cb.tree = make([]int32, 2)
+	cb.fast = make([]fastEntry, 1<<fastBits)
+	for e, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		word := marker[l]
+		if l < 32 && word>>l != 0 {
+			return errors.New("overspecified codebook")
+		}
+		for j := l; j > 0; j-- {
+			if marker[j]&1 != 0 {
+				if j == 1 {
+					marker[1]++
+				} else {
+					marker[j] = marker[j-1] << 1
+				}
+				break
+			}
+			marker[j]++
+		}
+		for j, prev := l+1, word; j < 33 && marker[j]>>1 == prev; j++ {
+			prev = marker[j]
+			marker[j] = marker[j-1] << 1
+		}
+		cb.insert(e, l, word)
+	}
+	return nil
+}
+
+// insert adds the codeword read most significant bit first.
+func (cb *codebook) insert(entry int, length uint8, word uint32) {
+	node := 0
+	for i := int(length) - 1; i >= 0; i-- {
+		slot := node*2 + int(word>>uint(i)&1)
+		if i == 0 {
+			cb.tree[slot] = int32(-entry - 1)
+			break
+		}
+		if cb.tree[slot] == 0 {
+			cb.tree[slot] = int32(len(cb.tree) / 2)
+			cb.tree = append(cb.tree, 0, 0)
+		}
+		node = int(cb.tree[slot])
+	}
+	if length > fastBits {
+		return
+	}
+	// The bit reader returns the first bit in the lowest position.
+	var rev uint32
+	for i := uint8(0); i < length; i++ {
+		rev |= (word >> (length - 1 - i) & 1) << i
+	}
+	for i := rev; i < 1<<fastBits; i += 1 << length {
+		cb.fast[i] = fastEntry{int32(entry), length}
+	}
+}
+
+// decode returns the next entry or -1 at the end of the packet or on an
+// invalid codeword.
+func (cb *codebook) decode(br *bitReader) int {
+	if v, n := br.peek(fastBits); n == fastBits {
+		if f := cb.fast[v]; f.length > 0 {
+			br.pos += int(f.length)
+			return int(f.entry)
+		}
+	}
+	node := 0
+	for {
+		c := cb.tree[node*2+int(br.read(1))]
+		switch {
+		case br.eop:
+			return -1
+		case c < 0:
+			return int(-c - 1)
+		case c == 0:
+			return -1
+		}
+		node = int(c)
+	}
+}
+
+// vector returns the values for an entry.
+func (cb *codebook) vector(entry int) []float32 {
+	return cb.values[entry*cb.dims : (entry+1)*cb.dims]
+}
+
+func float32Unpack(x uint32) float32 {
+	mantissa := float64(x & 0x1FFFFF)
+	if x&0x80000000 != 0 {
+		mantissa = -mantissa
+	}
+	exp := int(x&0x7FE00000) >> 21
+	return float32(math.Ldexp(mantissa, exp-788))
+}
+
+// lookup1Values returns the greatest value whose dims power is not greater
+// than entries.
+func lookup1Values(entries, dims int) int {
+	r := int(math.Floor(math.Pow(float64(entries), 1/float64(dims))))
+	for pow(r+1, dims) <= entries {
+		r++
+	}
+	for r > 0 && pow(r, dims) > entries {
+		r--
+	}
+	return r
+}
+
+func pow(v, n int) int {
+	r := 1
+	for i := 0; i < n && r <= 1<<24; i++ {
+		r *= v
+	}
+	return r
+}
@@ -0,0 +1,376 @@
+// Package vorbis decodes Ogg Vorbis files into gorge.AudioClipData.
+//
+// Only floor type 1 is supported, floor type 0 is not used by any encoder
+// since the early betas.
+package vorbis
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/stdiopt/gorge"
+)
+
+const (
+	packetIdent   = 1
+	packetComment = 3
+	packetSetup   = 5
+)
+
+type mapping struct {
+	magnitude []int
+	angle     []int
+	mux       []int
+	floors    []int
+	residues  []int
+}
+
+type mode struct {
+	blockFlag int
+	mapping   int
+}
+
+type decoder struct {
+	channels   int
+	sampleRate int
+	blocksize  [2]int
+
+	books    []*codebook
+	floors   []*floor1
+	residues []*residue
+	mappings []*mapping
+	modes    []mode
+
+	imdct   [2]*imdct
+	windows map[[3]int][]float32
+
+	// prev holds the right half of the previous windowed block per channel.
+	prev  [][]float32
+	prevN int
+}
+
+// Decode reads an Ogg Vorbis file, samples are decoded as
+// gorge.AudioFormatF32.
+func Decode(rd io.Reader) (*gorge.AudioClipData, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, fmt.Errorf("vorbis: %w", err)
+	}
+	packets, granule, err := readPackets(data)
+	if err != nil {
+		return nil, fmt.Errorf("vorbis: %w", err)
+	}
+	if len(packets) < 3 {
+		return nil, errors.New("vorbis: missing headers")
+	}
+	d := &decoder{windows: map[[3]int][]float32{}}
+	if err := d.readIdent(packets[0]); err != nil {
+		return nil, fmt.Errorf("vorbis: %w", err)
+	}
+	if !isHeader(packets[1], packetComment) {
+		return nil, errors.New("vorbis: invalid comment header")
+	}
+	if err := d.readSetup(packets[2]); err != nil {
+		return nil, fmt.Errorf("vorbis: setup header: %w", err)
+	}
+
+	var out []float32
+	for i, p := range packets[3:] {
+		if out, err = d.decodePacket(p, out); err != nil {
+			return nil, fmt.Errorf("vorbis: packet %d: %w", i+3, err)
+		}
+	}
+	// The last granule position is the total number of frames.
+	if n := int(granule) * d.channels; granule > 0 && len(out) > n {
+		out = out[:n]
+	}
+	buf := make([]byte, len(out)*4)
+	for i, v := range out {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+	}
+	return &gorge.AudioClipData{
+		Format:     gorge.AudioFormatF32,
+		SampleRate: d.sampleRate,
+		Channels:   d.channels,
+		Data:       buf,
+	}, nil
+}
+
+func isHeader(p []byte, typ byte) bool {
+	return len(p) >= 7 && p[0] == typ && string(p[1:7]) == "vorbis"
+}
+
+func (d *decoder) readIdent(p []byte) error {
+	if !isHeader(p, packetIdent) || len(p) < 30 {
+		return errors.New("invalid identification header")
+	}
+	if v := binary.LittleEndian.Uint32(p[7:]); v != 0 {
+		return fmt.Errorf("unsupported version %d", v)
+	}
+	d.channels = int(p[11])
+	d.sampleRate = int(binary.LittleEndian.Uint32(p[12:]))
+	d.blocksize = [2]int{1 << (p[28] & 0xF), 1 << (p[28] >> 4)}
+	switch {
+	case d.channels == 0 || d.sampleRate == 0:
+		return errors.New("invalid identification header")
+	case d.blocksize[0] < 64 || d.blocksize[1] > 8192 || d.blocksize[0] > d.blocksize[1]:
+		return fmt.Errorf("invalid block sizes %v", d.blocksize)
+	case p[29]&1 == 0:
+		return errors.New("missing identification framing bit")
+	}
+	d.imdct = [2]*imdct{newIMDCT(d.blocksize[0]), newIMDCT(d.blocksize[1])}
+	return nil
+}
+
+func (d *decoder) readSetup(p []byte) error {
+	if !isHeader(p, packetSetup) {
+		return errors.New("invalid header")
+	}
+	br := &bitReader{buf: p[7:]}
+
+	d.books = make([]*codebook, br.read(8)+1)
+	for i := range d.books {
+		cb, err := readCodebook(br)
+		if err != nil {
+			return fmt.Errorf("codebook %d: %w", i, err)
+		}
+		d.books[i] = cb
+	}
+	for i := br.read(6) + 1; i > 0; i-- {
+		if br.read(16) != 0 {
+			return errors.New("invalid time domain transform")
+		}
+	}
+	d.floors = make([]*floor1, br.read(6)+1)
+	for i := range d.floors {
+		if typ := br.read(16); typ != 1 {
+			return fmt.Errorf("unsupported floor type %d", typ)
+		}
+		f, err := readFloor1(br, len(d.books))
+		if err != nil {
+			return fmt.Errorf("floor %d: %w", i, err)
+		}
+		d.floors[i] = f
+	}
+	d.residues = make([]*residue, br.read(6)+1)
+	for i := range d.residues {
+		r, err := readResidue(br, d.books)
+		if err != nil {
+			return fmt.Errorf("residue %d: %w", i, err)
+		}
+		d.residues[i] = r
+	}
+	d.mappings = make([]*mapping, br.read(6)+1)
+	for i := range d.mappings {
+		m, err := d.readMapping(br)
+		if err != nil {
+			return fmt.Errorf("mapping %d: %w", i, err)
+		}
+		d.mappings[i] = m
+	}
+	d.modes = make([]mode, br.read(6)+1)
+	for i := range d.modes {
+		m := mode{blockFlag: int(br.read(1))}
+		if br.read(16) != 0 || br.read(16) != 0 {
+			return fmt.Errorf("mode %d: invalid window or transform type", i)
+		}
+		m.mapping = int(br.read(8))
+		if m.mapping >= len(d.mappings) {
+			return fmt.Errorf("mode %d: invalid mapping", i)
+		}
+		d.modes[i] = m
+	}
+	if !br.flag() || br.eop {
+		return errors.New("missing framing bit")
+	}
+	return nil
+}
+
+func (d *decoder) readMapping(br *bitReader) (*mapping, error) {
+	if br.read(16) != 0 {
+		return nil, errors.New("invalid mapping type")
+	}
+	submaps := 1
+	if br.flag() {
+		submaps = int(br.read(4) + 1)
+	}
+	m := &mapping{mux: make([]int, d.channels)}
+	if br.flag() {
+		bits := ilog(d.channels - 1)
+		for i := br.read(8) + 1; i > 0; i-- {
+			mag, ang := int(br.read(bits)), int(br.read(bits))
+			if mag == ang || mag >= d.channels || ang >= d.channels {
+				return nil, errors.New("invalid channel coupling")
+			}
+			m.magnitude = append(m.magnitude, mag)
+			m.angle = append(m.angle, ang)
+		}
+	}
+	if br.read(2) != 0 {
+		return nil, errors.New("invalid reserved field")
+	}
+	if submaps > 1 {
+		for i := range m.mux {
+			m.mux[i] = int(br.read(4))
+			if m.mux[i] >= submaps {
+				return nil, errors.New("invalid submap")
+			}
+		}
+	}
+	for i := 0; i < submaps; i++ {
+		br.read(8) // unused time configuration
+		f, r := int(br.read(8)), int(br.read(8))
+		if f >= len(d.floors) || r >= len(d.residues) {
+			return nil, errors.New("invalid submap floor or residue")
+		}
+		m.floors = append(m.floors, f)
+		m.residues = append(m.residues, r)
+	}
+	return m, nil
+}
+
+// decodePacket decodes an audio packet and appends the finished interleaved
+// samples to out.
+func (d *decoder) decodePacket(p []byte, out []float32) ([]float32, error) {
+	br := &bitReader{buf: p}
+	if br.flag() {
+		// Not an audio packet.
+		return out, nil
+	}
+	modeNum := int(br.read(ilog(len(d.modes) - 1)))
+	if modeNum >= len(d.modes) {
+		return nil, fmt.Errorf("invalid mode %d", modeNum)
+	}
+	md := d.modes[modeNum]
+	n := d.blocksize[md.blockFlag]
+	prevFlag, nextFlag := md.blockFlag, md.blockFlag
+	if md.blockFlag == 1 {
+		prevFlag, nextFlag = int(br.read(1)), int(br.read(1))
+	}
+	mp := d.mappings[md.mapping]
+	half := n / 2
+
+	floors := make([][]int, d.channels)
+	for ch := range floors {
+		floors[ch] = d.floors[mp.floors[mp.mux[ch]]].decode(br, d.books)
+	}
+	// Coupled channels are decoded if either one has a floor.
+	skip := make([]bool, d.channels)
+	for ch := range skip {
+		skip[ch] = floors[ch] == nil
+	}
+	for i, mag := range mp.magnitude {
+		ang := mp.angle[i]
+		if !skip[mag] || !skip[ang] {
+			skip[mag], skip[ang] = false, false
+		}
+	}
+
+	res := make([][]float32, d.channels)
+	for ch := range res {
+		res[ch] = make([]float32, half)
+	}
+	for sub, ri := range mp.residues {
+		var vs [][]float32
+		var vskip []bool
+		for ch := range res {
+			if mp.mux[ch] == sub {
+				vs = append(vs, res[ch])
+				vskip = append(vskip, skip[ch])
+			}
+		}
+		d.residues[ri].decode(br, d.books, vs, vskip, half)
+	}
+
+	for i := len(mp.magnitude) - 1; i >= 0; i-- {
+		mag, ang := res[mp.magnitude[i]], res[mp.angle[i]]
+		for j := range mag {
+			m, a := mag[j], ang[j]
+			switch {
+			case m > 0 && a > 0:
+				mag[j], ang[j] = m, m-a
+			case m > 0:
+				mag[j], ang[j] = m+a, m
+			case a > 0:
+				mag[j], ang[j] = m, m+a
+			default:
+				mag[j], ang[j] = m-a, m
+			}
+		}
+	}
+
+	window := d.window(md.blockFlag, prevFlag, nextFlag)
+	curve := make([]float32, half)
+	cur := make([][]float32, d.channels)
+	for ch := range cur {
+		cur[ch] = make([]float32, n)
+		if floors[ch] == nil {
+			continue
+		}
+		d.floors[mp.floors[mp.mux[ch]]].render(floors[ch], curve)
+		for j := range curve {
+			res[ch][j] *= curve[j]
+		}
+		d.imdct[md.blockFlag].transform(res[ch], cur[ch])
+		for j, w := range window {
+			cur[ch][j] *= w
+		}
+	}
+
+	if d.prev != nil {
+		pn := d.prevN
+		l := pn/4 + n/4
+		for k := 0; k < l; k++ {
+			i := k + n/4 - pn/4
+			for ch := range cur {
+				var s float32
+				if k < pn/2 {
+					s = d.prev[ch][k]
+				}
+				if i >= 0 {
+					s += cur[ch][i]
+				}
+				out = append(out, s)
+			}
+		}
+	}
+	d.prev = make([][]float32, d.channels)
+	for ch := range cur {
+		d.prev[ch] = cur[ch][half:]
+	}
+	d.prevN = n
+	return out, nil
+}
+
+// window returns the window for a block size and its neighbors block sizes.
+func (d *decoder) window(block, prev, next int) []float32 {
+	key := [3]int{block, prev, next}
+	if w, ok := d.windows[key]; ok {
+		return w
+	}
+	n := d.blocksize[block]
+	pn, nn := d.blocksize[prev], d.blocksize[next]
+	w := make([]float32, n)
+	slope := func(x float64) float32 {
+		s := math.Sin(x * math.Pi / 2)
+		return float32(math.Sin(math.Pi / 2 * s * s))
+	}
+	leftStart, leftN := n/4-pn/4, pn/2
+	rightStart, rightN := n*3/4-nn/4, nn/2
+	for i := range w {
+		switch {
+		case i < leftStart:
+		case i < leftStart+leftN:
+			w[i] = slope((float64(i-leftStart) + 0.5) / float64(leftN))
+		case i < rightStart:
+			w[i] = 1
+		case i < rightStart+rightN:
+			w[i] = slope((float64(i-rightStart)+0.5)/float64(rightN) + 1)
+		}
+	}
+	d.windows[key] = w
+	return w
+}
@@ -0,0 +1,223 @@
+package vorbis_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/vorbis"
+)
+
+type bitWriter struct {
+	buf []byte
+	n   uint
+}
+
+func (w *bitWriter) write(v uint64, bits uint) {
+	for i := uint(0); i < bits; i++ {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[len(w.buf)-1] |= byte(v>>i&1) << (w.n % 8)
+		w.n++
+	}
+}
+
+func (w *bitWriter) bytes(s string) {
+	for _, c := range []byte(s) {
+		w.write(uint64(c), 8)
+	}
+}
+
+func oggCRC(b []byte) uint32 {
+	var c uint32
+	for _, v := range b {
+		c ^= uint32(v) << 24
+		for i := 0; i < 8; i++ {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04C11DB7
+			} else {
+				c <<= 1
+			}
+		}
+	}
+	return c
+}
+
+// page writes an ogg page holding whole packets under 255 bytes.
+func page(seq uint32, granule int64, packets ...[]byte) []byte {
+	p := make([]byte, 27, 27+len(packets))
+	copy(p, "OggS")
+	binary.LittleEndian.PutUint64(p[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(p[14:], 1)
+	binary.LittleEndian.PutUint32(p[18:], seq)
+	p[26] = byte(len(packets))
+	for _, pk := range packets {
+		p = append(p, byte(len(pk)))
+	}
+	for _, pk := range packets {
+		p = append(p, pk...)
+	}
+	binary.LittleEndian.PutUint32(p[22:], oggCRC(p))
+	return p
+}
+
+const blocksize = 64
+
+// headers returns a mono stream with 64 sample blocks, a flat floor and a
+// single codebook mapping 1 bit codewords to 0 and 1.
+func headers(floorType uint64) [][]byte {
+	ident := &bitWriter{}
+	ident.write(1, 8)
+	ident.bytes("vorbis")
+	ident.write(0, 32)
+	ident.write(1, 8)
+	ident.write(8000, 32)
+	ident.write(0, 96)
+	ident.write(6|6<<4, 8)
+	ident.write(1, 8)
+
+	comment := &bitWriter{}
+	comment.write(3, 8)
+	comment.bytes("vorbis")
+	comment.write(0, 64)
+	comment.write(1, 8)
+
+	setup := &bitWriter{}
+	setup.write(5, 8)
+	setup.bytes("vorbis")
+	setup.write(0, 8) // 1 codebook
+	setup.write(0x564342, 24)
+	setup.write(1, 16) // dimensions
+	setup.write(2, 24) // entries
+	setup.write(0, 1)  // unordered
+	setup.write(0, 1)  // not sparse
+	setup.write(0, 5)  // length 1
+	setup.write(0, 5)
+	setup.write(1, 4) // lookup type 1
+	setup.write(0, 32)
+	setup.write(788<<21|1, 32) // delta 1.0
+	setup.write(0, 4)          // 1 bit values
+	setup.write(0, 1)
+	setup.write(0, 1) // multiplicands
+	setup.write(1, 1)
+	setup.write(0, 6) // 1 time domain transform
+	setup.write(0, 16)
+	setup.write(0, 6) // 1 floor
+	setup.write(floorType, 16)
+	setup.write(0, 5) // no partitions
+	setup.write(0, 2) // multiplier 1
+	setup.write(5, 4) // range bits
+	setup.write(0, 6) // 1 residue
+	setup.write(1, 16)
+	setup.write(0, 24)
+	setup.write(blocksize/2, 24)
+	setup.write(blocksize/2-1, 24) // a single partition
+	setup.write(0, 6)              // 1 classification
+	setup.write(0, 8)
+	setup.write(1, 3) // first pass only
+	setup.write(0, 1)
+	setup.write(0, 8)
+	setup.write(0, 6) // 1 mapping
+	setup.write(0, 16)
+	setup.write(0, 1)
+	setup.write(0, 1)
+	setup.write(0, 2)
+	setup.write(0, 8)
+	setup.write(0, 8)
+	setup.write(0, 8)
+	setup.write(0, 6) // 1 mode
+	setup.write(0, 1)
+	setup.write(0, 32)
+	setup.write(0, 8)
+	setup.write(1, 1)
+
+	return [][]byte{ident.buf, comment.buf, setup.buf}
+}
+
+// audio writes a packet with a single unit coefficient at k.
+func audio(k int) []byte {
+	w := &bitWriter{}
+	w.write(0, 1)
+	w.write(1, 1)   // floor used
+	w.write(255, 8) // flat floor at 1.0
+	w.write(255, 8)
+	w.write(0, 1) // classification
+	for i := 0; i < blocksize/2; i++ {
+		if i == k {
+			w.write(1, 1)
+		} else {
+			w.write(0, 1)
+		}
+	}
+	return w.buf
+}
+
+// block returns the windowed inverse MDCT of a unit coefficient at k.
+func block(k int) []float64 {
+	const n = blocksize
+	y := make([]float64, n)
+	for i := range y {
+		s := math.Sin((float64(i) + 0.5) / (n / 2) * math.Pi / 2)
+		if i >= n/2 {
+			s = math.Sin((float64(i-n/2)+0.5)/(n/2)*math.Pi/2 + math.Pi/2)
+		}
+		w := math.Sin(math.Pi / 2 * s * s)
+		y[i] = w * math.Cos(2*math.Pi/n*(float64(i)+0.5+n/4)*(float64(k)+0.5))
+	}
+	return y
+}
+
+func TestDecode(t *testing.T) {
+	hdr := headers(1)
+	var file []byte
+	file = append(file, page(0, 0, hdr[0])...)
+	file = append(file, page(1, 0, hdr[1], hdr[2])...)
+	file = append(file, page(2, 60, audio(1), audio(2), audio(3))...)
+
+	clip, err := vorbis.Decode(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clip.Format != gorge.AudioFormatF32 || clip.SampleRate != 8000 || clip.Channels != 1 {
+		t.Errorf("want F32 8000Hz mono, got %v %dHz %d channels", clip.Format, clip.SampleRate, clip.Channels)
+	}
+	// The first block only primes the overlap and the granule trims the
+	// last 4 samples.
+	if clip.Frames() != 60 {
+		t.Fatalf("\nwant: %v\n got: %v\n", 60, clip.Frames())
+	}
+	blocks := [][]float64{block(1), block(2), block(3)}
+	for i := 0; i < 60; i++ {
+		b, j := i/(blocksize/2), i%(blocksize/2)
+		want := blocks[b][blocksize/2+j] + blocks[b+1][j]
+		got := math.Float32frombits(binary.LittleEndian.Uint32(clip.Data[i*4:]))
+		if math.Abs(float64(got)-want) > 1e-4 {
+			t.Fatalf("sample %d\nwant: %v\n got: %v\n", i, want, got)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	hdr := headers(0)
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"ogg", []byte("RIFF"), "invalid ogg page"},
+		{"headers", page(0, 0, hdr[0]), "missing headers"},
+		{"floor0", page(0, 0, hdr...), "unsupported floor type 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := vorbis.Decode(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("\nwant: %v\n got: %v\n", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package vorbis
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var floor1Range = [4]int{256, 128, 86, 64}
+
+// floor1InverseDB maps floor values to amplitudes.
+var floor1InverseDB = func() (t [256]float32) {
+	for i := range t {
+		t[i] = float32(math.Pow(10, (float64(i+1)*140/256-140)/20))
+	}
+	return t
+}()
+
+type floor1 struct {
+	partitionClass []int
+	classDims      []int
+	classSubs      []uint
+	classMaster    []int
+	subBooks       [][]int
+	multiplier     int
+	xList          []int
+	// sorted holds the xList indexes in ascending x order.
+	sorted []int
+	low    []int
+	high   []int
+}
+
+func readFloor1(br *bitReader, books int) (*floor1, error) {
+	f := &floor1{}
+	maxClass := -1
+	f.partitionClass = make([]int, br.read(5))
+	for i := range f.partitionClass {
+		c := int(br.read(4))
+		f.partitionClass[i] = c
+		if c > maxClass {
+			maxClass = c
+		}
+	}
+	n := maxClass + 1
+	f.classDims = make([]int, n)
+	f.classSubs = make([]uint, n)
+	f.classMaster = make([]int, n)
+	f.subBooks = make([][]int, n)
+	for c := 0; c < n; c++ {
+		f.classDims[c] = int(br.read(3) + 1)
+		f.classSubs[c] = uint(br.read(2))
+		if f.classSubs[c] > 0 {
+			f.classMaster[c] = int(br.read(8))
+			if f.classMaster[c] >= books {
+				return nil, errors.New("invalid floor1 class book")
+			}
+		}
+		f.subBooks[c] = make([]int, 1<<f.classSubs[c])
+		for j := range f.subBooks[c] {
+			b := int(br.read(8)) - 1
+			if b >= books {
+				return nil, errors.New("invalid floor1 subclass book")
+			}
+			f.subBooks[c][j] = b
+		}
+	}
+	f.multiplier = int(br.read(2) + 1)
+	rangeBits := uint(br.read(4))
+	f.xList = []int{0, 1 << rangeBits}
+	for _, c := range f.partitionClass {
+		for j := 0; j < f.classDims[c]; j++ {
+			f.xList = append(f.xList, int(br.read(rangeBits)))
+		}
+	}
+	if br.eop {
+		return nil, errors.New("truncated floor1")
+	}
+	if len(f.xList) > 65 {
+		return nil, errors.New("invalid floor1 values")
+	}
+
+	f.sorted = make([]int, len(f.xList))
+	for i := range f.sorted {
+		f.sorted[i] = i
+	}
+	sort.SliceStable(f.sorted, func(a, b int) bool {
+		return f.xList[f.sorted[a]] < f.xList[f.sorted[b]]
+	})
+	for i := 1; i < len(f.sorted); i++ {
+		if f.xList[f.sorted[i]] == f.xList[f.sorted[i-1]] {
+			return nil, errors.New("duplicate floor1 x values")
+		}
+	}
+	// Neighbors are the closest previous x values below and above.
+	f.low = make([]int, len(f.xList))
+	f.high = make([]int, len(f.xList))
+	for i := 2; i < len(f.xList); i++ {
+		lx, hx := -1, math.MaxInt32
+		for j := 0; j < i; j++ {
+			x := f.xList[j]
+			if x < f.xList[i] && x > lx {
+				lx, f.low[i] = x, j
+			}
+			if x > f.xList[i] && x < hx {
+				hx, f.high[i] = x, j
+			}
+		}
+	}
+	return f, nil
+}
+
+// decode returns the floor y values or nil if the channel is unused.
+func (f *floor1) decode(br *bitReader, books []*codebook) []int {
+	if !br.flag() {
+		return nil
+	}
+	rng := floor1Range[f.multiplier-1]
+	bits := ilog(rng - 1)
+	y := make([]int, len(f.xList))
+	y[0] = int(br.read(bits))
+	y[1] = int(br.read(bits))
+	off := 2
+	for _, c := range f.partitionClass {
+		cbits := f.classSubs[c]
+		csub := 1<<cbits - 1
+		cval := 0
+		if cbits > 0 {
+			cval = books[f.classMaster[c]].decode(br)
+		}
+		for j := 0; j < f.classDims[c]; j++ {
+			if b := f.subBooks[c][cval&csub]; b >= 0 {
+				y[off+j] = books[b].decode(br)
+			}
+			cval >>= cbits
+		}
+		off += f.classDims[c]
+	}
+	if br.eop {
+		return nil
+	}
+	return y
+}
+
+// render synthesizes the floor curve into out.
+func (f *floor1) render(y []int, out []float32) {
+	rng := floor1Range[f.multiplier-1]
+	step2 := make([]bool, len(y))
+	final := make([]int, len(y))
+	step2[0], step2[1] = true, true
+	final[0], final[1] = y[0], y[1]
+	for i := 2; i < len(y); i++ {
+		lo, hi := f.low[i], f.high[i]
+		pred := renderPoint(f.xList[lo], final[lo], f.xList[hi], final[hi], f.xList[i])
+		val := y[i]
+		highRoom, lowRoom := rng-pred, pred
+		room := highRoom
+		if lowRoom < room {
+			room = lowRoom
+		}
+		room *= 2
+		if val == 0 {
+			final[i] = pred
+			continue
+		}
+		step2[lo], step2[hi], step2[i] = true, true, true
+		switch {
+		case val >= room && highRoom > lowRoom:
+			final[i] = val - lowRoom + pred
+		case val >= room:
+			final[i] = pred - val + highRoom - 1
+		case val&1 == 1:
+			final[i] = pred - (val+1)/2
+		default:
+			final[i] = pred + val/2
+		}
+	}
+
+	lx, hx := 0, 0
+	ly := final[f.sorted[0]] * f.multiplier
+	hy := ly
+	for _, i := range f.sorted[1:] {
+		if !step2[i] {
+			continue
+		}
+		hx, hy = f.xList[i], final[i]*f.multiplier
+		renderLine(lx, ly, hx, hy, out)
+		lx, ly = hx, hy
+	}
+	if hx < len(out) {
+		renderLine(hx, hy, len(out), hy, out)
+	}
+}
+
+func renderPoint(x0, y0, x1, y1, x int) int {
+	dy := y1 - y0
+	ady := dy
+	if ady < 0 {
+		ady = -ady
+	}
+	off := ady * (x - x0) / (x1 - x0)
+	if dy < 0 {
+		return y0 - off
+	}
+	return y0 + off
+}
+
+func renderLine(x0, y0, x1, y1 int, out []float32) {
+	dy := y1 - y0
+	adx := x1 - x0
+	ady := dy
+	if ady < 0 {
+		ady = -ady
+	}
+	base := dy / adx
+	sy := base + 1
+	if dy < 0 {
+		sy = base - 1
+	}
+	abase := base
+	if abase < 0 {
+		abase = -abase
+	}
+	ady -= abase * adx
+	y, e := y0, 0
+	for x := x0; x < x1 && x < len(out); x++ {
+		if x > x0 {
+			e += ady
+			if e >= adx {
+				e -= adx
+				y += sy
+			} else {
+				y += base
+			}
+		}
+		out[x] = floor1InverseDB[clampY(y)]
+	}
+}
+
+func clampY(y int) int {
+	switch {
+	case y < 0:
+		return 0
+	case y > 255:
+		return 255
+	}
+	return y
+}
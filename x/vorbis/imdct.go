@@ -0,0 +1,97 @@
+package vorbis
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// imdct computes the inverse MDCT of n/2 coefficients into n samples
+// through a DCT-IV evaluated with a complex FFT of size n.
+type imdct struct {
+	n     int
+	pre   []complex128
+	post  []complex128
+	roots []complex128
+	rev   []int
+	buf   []complex128
+	u     []float64
+}
+
+func newIMDCT(n int) *imdct {
+	half := n / 2
+	m := &imdct{
+		n:     n,
+		pre:   make([]complex128, half),
+		post:  make([]complex128, half),
+		roots: make([]complex128, n/2),
+		rev:   make([]int, n),
+		buf:   make([]complex128, n),
+		u:     make([]float64, half),
+	}
+	for k := range m.pre {
+		m.pre[k] = cmplx.Exp(complex(0, -math.Pi*float64(k)/float64(n)))
+		m.post[k] = cmplx.Exp(complex(0, -math.Pi*(float64(k)/2+0.25)/float64(half)))
+	}
+	for j := range m.roots {
+		m.roots[j] = cmplx.Exp(complex(0, -2*math.Pi*float64(j)/float64(n)))
+	}
+	bits := ilog(n - 1)
+	for i := range m.rev {
+		r := 0
+		for b := uint(0); b < bits; b++ {
+			r |= (i >> b & 1) << (bits - 1 - b)
+		}
+		m.rev[i] = r
+	}
+	return m
+}
+
+// transform writes y[i] = sum(X[k] * cos(2pi/n * (i + 1/2 + n/4) * (k + 1/2))).
+func (m *imdct) transform(in, out []float32) {
+	half := m.n / 2
+	for k := range m.buf {
+		m.buf[k] = 0
+	}
+	for k, x := range in[:half] {
+		m.buf[k] = complex(float64(x), 0) * m.pre[k]
+	}
+	m.fft()
+	for k := range m.u {
+		m.u[k] = real(m.buf[k] * m.post[k])
+	}
+	// The output is the DCT-IV shifted by a quarter, extended with its odd
+	// and even symmetries.
+	for i := range out[:m.n] {
+		t := i + half/2
+		switch {
+		case t < half:
+			out[i] = float32(m.u[t])
+		case t < 2*half:
+			out[i] = float32(-m.u[2*half-1-t])
+		default:
+			out[i] = float32(-m.u[t-2*half])
+		}
+	}
+}
+
+func (m *imdct) fft() {
+	a := m.buf
+	n := len(a)
+	for i, j := range m.rev {
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		h := size / 2
+		step := n / size
+		for start := 0; start < n; start += size {
+			for k := 0; k < h; k++ {
+				u := a[start+k]
+				v := a[start+k+h] * m.roots[k*step]
+				a[start+k] = u + v
+				a[start+k+h] = u - v
+			}
+		}
+	}
+}
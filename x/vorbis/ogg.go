@@ -0,0 +1,86 @@
+package vorbis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var oggCRC = func() (t [256]uint32) {
+	for i := range t {
+		c := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04C11DB7
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+// readPackets joins the pages of the first logical stream into packets and
+// returns the last granule position.
+func readPackets(data []byte) ([][]byte, int64, error) {
+	var (
+		packets [][]byte
+		cur     []byte
+		serial  uint32
+		granule int64 = -1
+		first         = true
+	)
+	for len(data) > 0 {
+		if len(data) < 27 || !bytes.HasPrefix(data, []byte("OggS")) {
+			return nil, 0, errors.New("invalid ogg page")
+		}
+		nseg := int(data[26])
+		if len(data) < 27+nseg {
+			return nil, 0, errors.New("truncated ogg page")
+		}
+		segs := data[27 : 27+nseg]
+		size := 27 + nseg
+		for _, s := range segs {
+			size += int(s)
+		}
+		if len(data) < size {
+			return nil, 0, errors.New("truncated ogg page")
+		}
+		page := data[:size]
+		data = data[size:]
+
+		var crc uint32
+		for i, v := range page {
+			if i >= 22 && i < 26 {
+				v = 0
+			}
+			crc = crc<<8 ^ oggCRC[byte(crc>>24)^v]
+		}
+		if crc != binary.LittleEndian.Uint32(page[22:]) {
+			return nil, 0, errors.New("ogg page crc mismatch")
+		}
+
+		s := binary.LittleEndian.Uint32(page[14:])
+		if first {
+			serial, first = s, false
+		}
+		if s != serial {
+			continue
+		}
+		if g := int64(binary.LittleEndian.Uint64(page[6:])); g != -1 {
+			granule = g
+		}
+		body := page[27+nseg:]
+		for _, s := range segs {
+			cur = append(cur, body[:s]...)
+			body = body[s:]
+			// A lacing value below 255 ends the packet.
+			if s < 255 {
+				packets = append(packets, cur)
+				cur = nil
+			}
+		}
+	}
+	return packets, granule, nil
+}
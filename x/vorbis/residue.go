@@ -0,0 +1,165 @@
+package vorbis
+
+import (
+	"errors"
+	"fmt"
+)
+
+type residue struct {
+	typ             int
+	begin, end      int
+	partitionSize   int
+	classifications int
+	classBook       int
+	// books holds the book for each classification and pass, -1 if unused.
+	books [][8]int
+}
+
+func readResidue(br *bitReader, books []*codebook) (*residue, error) {
+	r := &residue{typ: int(br.read(16))}
+	if r.typ > 2 {
+		return nil, fmt.Errorf("invalid residue type %d", r.typ)
+	}
+	r.begin = int(br.read(24))
+	r.end = int(br.read(24))
+	r.partitionSize = int(br.read(24) + 1)
+	r.classifications = int(br.read(6) + 1)
+	r.classBook = int(br.read(8))
+	if r.classBook >= len(books) {
+		return nil, errors.New("invalid residue class book")
+	}
+	cascade := make([]uint32, r.classifications)
+	for i := range cascade {
+		cascade[i] = br.read(3)
+		if br.flag() {
+			cascade[i] |= br.read(5) << 3
+		}
+	}
+	r.books = make([][8]int, r.classifications)
+	for i := range r.books {
+		for j := range r.books[i] {
+			r.books[i][j] = -1
+			if cascade[i]&(1<<uint(j)) == 0 {
+				continue
+			}
+			b := int(br.read(8))
+			if b >= len(books) || books[b].values == nil {
+				return nil, errors.New("invalid residue book")
+			}
+			r.books[i][j] = b
+		}
+	}
+	if br.eop {
+		return nil, errors.New("truncated residue")
+	}
+	return r, nil
+}
+
+// decode adds the residue vectors of size n into vs, vectors flagged in skip
+// are not decoded.
+func (r *residue) decode(br *bitReader, books []*codebook, vs [][]float32, skip []bool, n int) {
+	if r.typ != 2 {
+		r.decodeVectors(br, books, vs, skip, n)
+		return
+	}
+	decode := false
+	for _, s := range skip {
+		decode = decode || !s
+	}
+	if !decode {
+		return
+	}
+	// Type 2 decodes a single interleaved vector.
+	ch := len(vs)
+	v := make([]float32, n*ch)
+	r.decodeVectors(br, books, [][]float32{v}, []bool{false}, n*ch)
+	for i, s := range v {
+		vs[i%ch][i/ch] += s
+	}
+}
+
+func (r *residue) decodeVectors(br *bitReader, books []*codebook, vs [][]float32, skip []bool, n int) {
+	begin, end := r.begin, r.end
+	if begin > n {
+		begin = n
+	}
+	if end > n {
+		end = n
+	}
+	psize := r.partitionSize
+	parts := (end - begin) / psize
+	if parts <= 0 {
+		return
+	}
+	classBook := books[r.classBook]
+	words := classBook.dims
+	classes := make([][]int, len(vs))
+	for i := range classes {
+		classes[i] = make([]int, parts+words)
+	}
+	for pass := 0; pass < 8; pass++ {
+		for p := 0; p < parts; {
+			if pass == 0 {
+				for j, v := range classes {
+					if skip[j] {
+						continue
+					}
+					tmp := classBook.decode(br)
+					if tmp < 0 {
+						return
+					}
+					for i := words - 1; i >= 0; i-- {
+						v[p+i] = tmp % r.classifications
+						tmp /= r.classifications
+					}
+				}
+			}
+			for i := 0; i < words && p < parts; i, p = i+1, p+1 {
+				for j, v := range vs {
+					if skip[j] {
+						continue
+					}
+					b := r.books[classes[j][p]][pass]
+					if b < 0 {
+						continue
+					}
+					off := begin + p*psize
+					if !r.partition(br, books[b], v[off:off+psize]) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// partition decodes a partition, returns false at the end of the packet.
+func (r *residue) partition(br *bitReader, book *codebook, v []float32) bool {
+	if r.typ == 0 {
+		step := len(v) / book.dims
+		for i := 0; i < step; i++ {
+			e := book.decode(br)
+			if e < 0 {
+				return false
+			}
+			for k, s := range book.vector(e) {
+				v[i+k*step] += s
+			}
+		}
+		return true
+	}
+	for i := 0; i < len(v); {
+		e := book.decode(br)
+		if e < 0 {
+			return false
+		}
+		for _, s := range book.vector(e) {
+			if i >= len(v) {
+				break
+			}
+			v[i] += s
+			i++
+		}
+	}
+	return true
+}
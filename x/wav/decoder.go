@@ -0,0 +1,138 @@
+// Package wav decodes PCM and IEEE float RIFF wave files into
+// gorge.AudioClipData.
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/stdiopt/gorge"
+)
+
+// Format tags.
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xFFFE
+)
+
+// maxFmtSize is the largest fmt chunk accepted, the extensible format uses 40
+// bytes.
+const maxFmtSize = 64
+
+type fmtChunk struct {
+	FormatTag     uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// Decode reads a wave file, samples keep their format except 64 bit floats
+// which are converted to 32 bits.
+func Decode(rd io.Reader) (*gorge.AudioClipData, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(rd, riff[:]); err != nil {
+		return nil, fmt.Errorf("wav: %w", err)
+	}
+	if string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, errors.New("wav: invalid header")
+	}
+
+	var f *fmtChunk
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(rd, hdr[:]); err != nil {
+			return nil, fmt.Errorf("wav: missing data chunk: %w", err)
+		}
+		id := string(hdr[:4])
+		size := int64(binary.LittleEndian.Uint32(hdr[4:]))
+		switch id {
+		case "fmt ":
+			if size > maxFmtSize {
+				return nil, fmt.Errorf("wav: fmt chunk of %d bytes", size)
+			}
+			buf := make([]byte, size+size&1)
+			if _, err := io.ReadFull(rd, buf); err != nil {
+				return nil, fmt.Errorf("wav: fmt chunk: %w", err)
+			}
+			var err error
+			if f, err = readFmt(buf[:size]); err != nil {
+				return nil, err
+			}
+		case "data":
+			if f == nil {
+				return nil, errors.New("wav: data chunk before fmt chunk")
+			}
+			data, err := io.ReadAll(io.LimitReader(rd, size))
+			if err != nil {
+				return nil, fmt.Errorf("wav: data chunk: %w", err)
+			}
+			return clipData(f, data)
+		default:
+			// Chunks are word aligned.
+			if _, err := io.CopyN(io.Discard, rd, size+size&1); err != nil {
+				return nil, fmt.Errorf("wav: %q chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+func readFmt(buf []byte) (*fmtChunk, error) {
+	f := &fmtChunk{}
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, f); err != nil {
+		return nil, fmt.Errorf("wav: fmt chunk: %w", err)
+	}
+	if f.FormatTag == formatExtensible {
+		// The sub format GUID starts with the format tag.
+		if len(buf) < 26 {
+			return nil, errors.New("wav: short extensible fmt chunk")
+		}
+		f.FormatTag = binary.LittleEndian.Uint16(buf[24:])
+	}
+	if f.Channels == 0 || f.SampleRate == 0 {
+		return nil, errors.New("wav: invalid fmt chunk")
+	}
+	return f, nil
+}
+
+func clipData(f *fmtChunk, data []byte) (*gorge.AudioClipData, error) {
+	var format gorge.AudioFormat
+	switch {
+	case f.FormatTag == formatPCM && f.BitsPerSample == 8:
+		format = gorge.AudioFormatU8
+	case f.FormatTag == formatPCM && f.BitsPerSample == 16:
+		format = gorge.AudioFormatS16
+	case f.FormatTag == formatPCM && f.BitsPerSample == 24:
+		format = gorge.AudioFormatS24
+	case f.FormatTag == formatPCM && f.BitsPerSample == 32:
+		format = gorge.AudioFormatS32
+	case f.FormatTag == formatFloat && f.BitsPerSample == 32:
+		format = gorge.AudioFormatF32
+	case f.FormatTag == formatFloat && f.BitsPerSample == 64:
+		format = gorge.AudioFormatF32
+		out := make([]byte, len(data)/8*4)
+		for i := 0; i+8 <= len(data); i += 8 {
+			v := math.Float64frombits(binary.LittleEndian.Uint64(data[i:]))
+			binary.LittleEndian.PutUint32(out[i/2:], math.Float32bits(float32(v)))
+		}
+		data = out
+	default:
+		return nil, fmt.Errorf("wav: unsupported format %d with %d bits", f.FormatTag, f.BitsPerSample)
+	}
+	// Drop a trailing partial frame.
+	frame := format.Size() * int(f.Channels)
+	data = data[:len(data)/frame*frame]
+
+	return &gorge.AudioClipData{
+		Format:     format,
+		SampleRate: int(f.SampleRate),
+		Channels:   int(f.Channels),
+		Data:       data,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package wav_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stdiopt/gorge"
+	"github.com/stdiopt/gorge/x/wav"
+)
+
+// writeWAV builds a wave file with an odd sized chunk before the data.
+func writeWAV(tag, channels uint16, rate uint32, bits uint16, data []byte) []byte {
+	le := binary.LittleEndian
+	body := &bytes.Buffer{}
+	body.WriteString("WAVE")
+	body.WriteString("fmt ")
+	binary.Write(body, le, []uint32{16})                                   // nolint: errcheck
+	binary.Write(body, le, []uint16{tag, channels})                        // nolint: errcheck
+	binary.Write(body, le, []uint32{rate, rate * uint32(channels*bits/8)}) // nolint: errcheck
+	binary.Write(body, le, []uint16{channels * bits / 8, bits})            // nolint: errcheck
+	body.WriteString("LIST\x03\x00\x00\x00\x01\x02\x03\x00")
+	body.WriteString("data")
+	binary.Write(body, le, uint32(len(data))) // nolint: errcheck
+	body.Write(data)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("RIFF")
+	binary.Write(buf, le, uint32(body.Len())) // nolint: errcheck
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("pcm16 mono", func(t *testing.T) {
+		data := []byte{1, 0, 2, 0, 3, 0}
+		clip, err := wav.Decode(bytes.NewReader(writeWAV(1, 1, 48000, 16, data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clip.Format != gorge.AudioFormatS16 || clip.SampleRate != 48000 || clip.Channels != 1 {
+			t.Errorf("want S16 48000Hz mono, got %v %dHz %d channels", clip.Format, clip.SampleRate, clip.Channels)
+		}
+		if !bytes.Equal(clip.Data, data) || clip.Frames() != 3 {
+			t.Errorf("\nwant: %v\n got: %v\n", data, clip.Data)
+		}
+	})
+	t.Run("float64 stereo", func(t *testing.T) {
+		data := make([]byte, 16)
+		binary.LittleEndian.PutUint64(data, math.Float64bits(0.5))
+		binary.LittleEndian.PutUint64(data[8:], math.Float64bits(-1))
+		clip, err := wav.Decode(bytes.NewReader(writeWAV(3, 2, 22050, 64, data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clip.Format != gorge.AudioFormatF32 || clip.Frames() != 1 {
+			t.Fatalf("want 1 F32 frame, got %v %d", clip.Format, clip.Frames())
+		}
+		l := math.Float32frombits(binary.LittleEndian.Uint32(clip.Data))
+		r := math.Float32frombits(binary.LittleEndian.Uint32(clip.Data[4:]))
+		if l != 0.5 || r != -1 {
+			t.Errorf("\nwant: %v %v\n got: %v %v\n", 0.5, -1, l, r)
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			data []byte
+			want string
+		}{
+			{[]byte("RIFF\x00\x00\x00\x00AVI "), "invalid header"},
+			{writeWAV(2, 1, 8000, 4, []byte{0}), "unsupported format"},
+			{[]byte("RIFF\x0c\x00\x00\x00WAVEfmt \xf0\xff\xff\xff"), "fmt chunk"},
+		}
+		for _, tt := range tests {
+			_, err := wav.Decode(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("\nwant: %v\n got: %v\n", tt.want, err)
+			}
+		}
+	})
+}